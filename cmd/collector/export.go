@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jenkins-x/cd-indicators/internal/export"
+	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/spf13/pflag"
+)
+
+var (
+	exportOptions struct {
+		dataset     string
+		format      string
+		owner       string
+		from        string
+		to          string
+		excludeBots bool
+		output      string
+	}
+)
+
+// runExport implements the "export" subcommand, which dumps a dataset to a file
+func runExport(args []string) {
+	flags := pflag.NewFlagSet("export", pflag.ExitOnError)
+	flags.StringVar(&exportOptions.dataset, "dataset", "", fmt.Sprintf("Dataset to export - one of: %s", strings.Join(store.Datasets(), ", ")))
+	flags.StringVar(&exportOptions.format, "format", string(export.FormatCSV), "Output format - one of: csv, ndjson or parquet")
+	flags.StringVar(&exportOptions.owner, "owner", "", "Only export the rows of this git owner/organization. Leave empty to export all")
+	flags.StringVar(&exportOptions.from, "from", "", "Start of the time range (inclusive), as a date (YYYY-MM-DD) or a RFC 3339 timestamp. Default: 90 days before the end")
+	flags.StringVar(&exportOptions.to, "to", "", "End of the time range (exclusive), as a date (YYYY-MM-DD) or a RFC 3339 timestamp. Default: now")
+	flags.BoolVar(&exportOptions.excludeBots, "exclude-bots", false, "Exclude the automated changes: the pull requests, pipelines and releases of bots, and the deployments of these releases")
+	flags.StringVarP(&exportOptions.output, "output", "o", "-", "Path of the file to write. Default to the standard output")
+	for _, name := range []string{"storage", "postgres-uri", "sqlite-path", "log-level", "log-level-db"} {
+		flags.AddFlag(pflag.Lookup(name))
+	}
+	_ = flags.Parse(args)
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	logger := newLogger()
+	log := logger.WithField("dataset", exportOptions.dataset).WithField("format", exportOptions.format)
+
+	format, err := export.ParseFormat(exportOptions.format)
+	if err != nil {
+		log.WithError(err).Fatal("Invalid export format")
+	}
+	filter, err := export.NewFilter(exportOptions.owner, exportOptions.from, exportOptions.to)
+	if err != nil {
+		log.WithError(err).Fatal("Invalid export time range")
+	}
+	filter.ExcludeBots = exportOptions.excludeBots
+
+	if options.storage == "memory" {
+		log.Fatal("The export subcommand can't read the in-memory storage of a running collector: use its /api/export endpoint instead")
+	}
+	s, closeStore := newStore(ctx, logger)
+	defer closeStore()
+	if s.Export == nil {
+		log.WithField("storage", options.storage).Fatal("The storage doesn't support exports")
+	}
+
+	var w io.Writer = os.Stdout
+	if exportOptions.output != "-" {
+		f, err := os.Create(exportOptions.output)
+		if err != nil {
+			log.WithError(err).Fatal("Failed to create the output file")
+		}
+		defer f.Close()
+		w = f
+	}
+
+	log.WithField("from", filter.From).WithField("to", filter.To).WithField("owner", filter.Owner).WithField("excludeBots", filter.ExcludeBots).Info("Exporting dataset")
+	err = export.Export(ctx, s.Export, exportOptions.dataset, format, filter, w)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to export dataset")
+	}
+}
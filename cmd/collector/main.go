@@ -7,20 +7,38 @@ import (
 	"github.com/jackc/pgx/v5/tracelog"
 	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
 	logrusadapter "github.com/jackc/pgx-logrus"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jenkins-x/cd-indicators/collector"
+	"github.com/jenkins-x/cd-indicators/internal/api"
+	"github.com/jenkins-x/cd-indicators/internal/cdevents"
+	"github.com/jenkins-x/cd-indicators/internal/config"
+	"github.com/jenkins-x/cd-indicators/internal/health"
 	"github.com/jenkins-x/cd-indicators/internal/kube"
 	"github.com/jenkins-x/cd-indicators/internal/lighthouse"
+	"github.com/jenkins-x/cd-indicators/internal/retention"
 	"github.com/jenkins-x/cd-indicators/internal/version"
+	"github.com/jenkins-x/cd-indicators/internal/webhook"
 	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/jenkins-x/cd-indicators/store/memory"
+	"github.com/jenkins-x/cd-indicators/store/postgres"
+	"github.com/jenkins-x/cd-indicators/store/sqlite"
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/go-scm/scm/factory"
 	jxclientset "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned"
+	lhclientset "github.com/jenkins-x/lighthouse-client/pkg/clientset/versioned"
 	"github.com/scylladb/go-set/strset"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/pflag"
+	tektonclientset "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 )
 
 var (
@@ -28,30 +46,101 @@ var (
 		namespace           string
 		resyncInterval      time.Duration
 		gitOwners           []string
+		configPath          string
+		configReload        time.Duration
+		storage             string
 		postgresURI         string
+		sqlitePath          string
 		lighthouseHMACKey   string
+		gitWebhooks         bool
+		gitWebhookSecret    string
+		gitKind             string
+		gitServer           string
+		gitToken            string
 		kubeConfigPath      string
 		listenAddr          string
 		logLevelForPostgres string
 		logLevel            string
 		printVersion        bool
+		retentionDays       map[string]int
+		retentionArchive    []string
+		retentionInterval   time.Duration
+		shutdownTimeout     time.Duration
+		leaderElection      bool
+		leaseName           string
+		leaseNamespace      string
+		leaseDuration       time.Duration
+		leaseRenewDeadline  time.Duration
+		leaseRetryPeriod    time.Duration
+		apiToken            string
+		identitiesRefresh   time.Duration
+		teamLabel           string
+		pipelineSource      string
+		lighthouseJobs      bool
+		workloadNamespaces  map[string]string
+		helmNamespaces      map[string]string
+		gitOpsSources       []string
+		cdEvents            bool
+		cdEventsToken       string
+		cdEventsSink        string
+		cdEventsSinkToken   string
 	}
 )
 
 func init() {
 	pflag.StringVar(&options.namespace, "namespace", "jx", "Name of the jx namespace")
+	pflag.StringVar(&options.storage, "storage", "postgres", "Storage backend - one of: postgres, sqlite or memory. The memory storage loses everything on restart")
 	pflag.StringVar(&options.postgresURI, "postgres-uri", "postgres://localhost:5432/indicators", "URI of the postgres DB to connnect to")
+	pflag.StringVar(&options.sqlitePath, "sqlite-path", "indicators.db", "Path of the SQLite database file, when using the sqlite storage")
 	pflag.DurationVar(&options.resyncInterval, "resync-interval", 1*time.Hour, "Resync interval between full re-list operations")
 	pflag.StringSliceVar(&options.gitOwners, "git-owners", []string{}, "List of git owners/organizations to collect indicators from. Leave empty to collect from all")
+	pflag.StringVar(&options.configPath, "config", "", "Path of the YAML config file, defining the owners/repositories filters, environment aliases, bot authors and approval label. It is reloaded on changes")
+	pflag.DurationVar(&options.configReload, "config-reload-interval", 30*time.Second, "Interval between checks of the config file for changes")
 	pflag.StringVar(&options.lighthouseHMACKey, "lighthouse-hmac-key", os.Getenv("LIGHTHOUSE_HMAC_KEY"), "HMAC key used by Lighthouse to sign the webhooks")
+	pflag.BoolVar(&options.gitWebhooks, "git-webhooks", false, "Receive the native webhooks of the git server on /git/events, for the clusters without Lighthouse. They are parsed according to the git kind")
+	pflag.StringVar(&options.gitWebhookSecret, "git-webhook-secret", os.Getenv("GIT_WEBHOOK_SECRET"), "Secret of the native webhooks of the git server, used to validate their signature. Leave empty to accept the unsigned webhooks")
+	pflag.StringVar(&options.gitKind, "git-kind", "github", "Kind of git server, used to retrieve the data which is not part of the webhooks, such as the pull requests size - one of: github, gitlab, bitbucketserver, bitbucketcloud, gitea")
+	pflag.StringVar(&options.gitServer, "git-server", "https://github.com", "URL of the git server")
+	pflag.StringVar(&options.gitToken, "git-token", os.Getenv("GIT_TOKEN"), "Token used to access the git server. Leave empty to disable the git API calls")
 	pflag.StringVar(&options.listenAddr, "listen-addr", ":8080", "Address on which the HTTP server will listen for incoming connections")
 	pflag.StringVar(&options.logLevel, "log-level", "INFO", "Log level - one of: trace, debug, info, warn(ing), error, fatal or panic")
 	pflag.StringVar(&options.logLevelForPostgres, "log-level-db", "WARN", "Log level for the database operations - one of: trace, debug, info, warn, error or none")
 	pflag.StringVar(&options.kubeConfigPath, "kubeconfig", kube.DefaultKubeConfigPath(), "Kubernetes Config Path. Default: KUBECONFIG env var value")
+	pflag.StringToIntVar(&options.retentionDays, "retention-days", map[string]int{}, fmt.Sprintf("Number of days to keep the raw rows of a table, as table=days pairs. Tables: %s. Leave empty to keep everything", strings.Join(store.RetentionTables(), ", ")))
+	pflag.StringSliceVar(&options.retentionArchive, "retention-archive", []string{}, "List of tables whose expired rows are archived instead of just being deleted")
+	pflag.DurationVar(&options.retentionInterval, "retention-interval", 24*time.Hour, "Interval between runs of the retention job")
+	pflag.DurationVar(&options.shutdownTimeout, "shutdown-timeout", 30*time.Second, "Maximum duration to wait for the in-flight requests to complete on shutdown")
+	pflag.BoolVar(&options.leaderElection, "leader-election", false, "Elect a leader between the replicas, which is the only one running the informers. Required when running more than 1 replica")
+	pflag.StringVar(&options.leaseName, "leader-election-lease-name", "cd-indicators", "Name of the Lease used for the leader election")
+	pflag.StringVar(&options.leaseNamespace, "leader-election-namespace", os.Getenv("POD_NAMESPACE"), "Namespace of the Lease used for the leader election. Default: POD_NAMESPACE env var value, or the jx namespace")
+	pflag.DurationVar(&options.leaseDuration, "leader-election-lease-duration", 15*time.Second, "Duration that the non-leader replicas wait before trying to acquire the leadership")
+	pflag.DurationVar(&options.leaseRenewDeadline, "leader-election-renew-deadline", 10*time.Second, "Duration that the leader retries refreshing its leadership before giving it up")
+	pflag.DurationVar(&options.leaseRetryPeriod, "leader-election-retry-period", 2*time.Second, "Duration between leader election actions")
+	pflag.StringVar(&options.apiToken, "api-token", os.Getenv("API_TOKEN"), "Bearer token required by the write requests of the API, such as the identities changes, and by the exports. Leave empty to disable the write requests")
+	pflag.DurationVar(&options.identitiesRefresh, "identities-refresh-interval", 1*time.Minute, "Interval between reloads of the identities managed through the API, to get the changes made through the other replicas")
+	pflag.StringVar(&options.pipelineSource, "pipeline-source", collector.PipelineSourcePipelineActivities, "Source of the pipelines - one of: pipelineactivities (Jenkins X) or tekton (the Tekton PipelineRuns and TaskRuns, for the clusters without Jenkins X pipelines)")
+	pflag.BoolVar(&options.lighthouseJobs, "lighthouse-jobs", true, "Collect the LighthouseJobs, for the pipelines queue latency, the periodic jobs and the retests. Disable it on the clusters without Lighthouse")
+	pflag.StringToStringVar(&options.workloadNamespaces, "workload-namespaces", map[string]string{}, "Namespaces of the environments whose Deployments and StatefulSets rollouts are collected as deployments, as namespace=environment pairs - for the environments whose git provider doesn't emit deployment events. Leave empty to disable it")
+	pflag.StringToStringVar(&options.helmNamespaces, "helm-namespaces", map[string]string{}, "Namespaces of the environments whose Helm releases revisions are collected - and stored as deployments when they succeed - as namespace=environment pairs. Leave empty to disable it")
+	pflag.StringSliceVar(&options.gitOpsSources, "gitops-sources", []string{}, "GitOps tools whose syncs are collected - and stored as deployments when they succeed - from their resources in all the namespaces: argocd (the Applications) and/or flux (the HelmReleases and Kustomizations). Leave empty to disable it")
+	pflag.BoolVar(&options.cdEvents, "cdevents", false, "Collect the pipelines, releases, deployments and incidents of the CDEvents - sent as CloudEvents - received on /cdevents, from the tools other than Jenkins X and Lighthouse")
+	pflag.StringVar(&options.cdEventsToken, "cdevents-token", os.Getenv("CDEVENTS_TOKEN"), "Bearer token required by the CDEvents endpoint. Leave empty to accept the unauthenticated CDEvents")
+	pflag.StringVar(&options.cdEventsSink, "cdevents-sink", "", "URL of the HTTP endpoint to which the stored pipelines, releases, deployments and merged pull requests are published, as CDEvents sent as CloudEvents. Leave empty to disable it")
+	pflag.StringVar(&options.cdEventsSinkToken, "cdevents-sink-token", os.Getenv("CDEVENTS_SINK_TOKEN"), "Bearer token sent to the CDEvents sink. Leave empty to send unauthenticated CDEvents")
+	pflag.StringVar(&options.teamLabel, "team-label", "team", "Label of the SourceRepositories holding the team owning the repository. Leave empty to ignore the SourceRepository labels")
 	pflag.BoolVar(&options.printVersion, "version", false, "Print the version")
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		runExport(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "rebuild" {
+		runRebuild(os.Args[2:])
+		return
+	}
+
 	pflag.Parse()
 
 	if options.printVersion {
@@ -59,17 +148,12 @@ func main() {
 		return
 	}
 
-	ctx, cancelFunc := context.WithCancel(context.Background())
+	// the context is done on SIGTERM, which stops the informers and the background jobs
+	ctx, cancelFunc := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
 	defer cancelFunc()
 
-	logger := logrus.New()
-	logLevel, err := logrus.ParseLevel(options.logLevel)
-	if err != nil {
-		logger.WithField("logLevel", options.logLevel).WithError(err).Error("Invalid log level")
-	} else {
-		logger.SetLevel(logLevel)
-	}
-	logger.WithField("logLevel", logLevel).Info("Starting")
+	logger := newLogger()
+	logger.WithField("logLevel", logger.GetLevel()).Info("Starting")
 
 	kConfig, err := kube.NewConfig(options.kubeConfigPath)
 	if err != nil {
@@ -79,25 +163,111 @@ func main() {
 	if err != nil {
 		logger.WithError(err).Fatal("failed to create a Jenkins X client")
 	}
+	var lighthouseClient lhclientset.Interface
+	if options.lighthouseJobs {
+		lighthouseClient, err = lhclientset.NewForConfig(kConfig)
+		if err != nil {
+			logger.WithError(err).Fatal("failed to create a Lighthouse client")
+		}
+	}
+	var tektonClient tektonclientset.Interface
+	if options.pipelineSource == collector.PipelineSourceTekton {
+		tektonClient, err = tektonclientset.NewForConfig(kConfig)
+		if err != nil {
+			logger.WithError(err).Fatal("failed to create a Tekton client")
+		}
+	}
+	var kubeClient kubernetes.Interface
+	if len(options.workloadNamespaces) > 0 || len(options.helmNamespaces) > 0 {
+		kubeClient, err = kubernetes.NewForConfig(kConfig)
+		if err != nil {
+			logger.WithError(err).Fatal("failed to create a Kubernetes client")
+		}
+	}
 
-	dbconf, err := pgxpool.ParseConfig(options.postgresURI)
-	if err != nil {
-		logger.WithError(err).Fatal("Failed to parse postgresURI")
+	var dynamicClient dynamic.Interface
+	if len(options.gitOpsSources) > 0 {
+		dynamicClient, err = dynamic.NewForConfig(kConfig)
+		if err != nil {
+			logger.WithError(err).Fatal("failed to create a dynamic Kubernetes client")
+		}
 	}
-	pgLogLevel, err := tracelog.LogLevelFromString(strings.ToLower(options.logLevelForPostgres))
-	if err != nil {
-		logger.WithField("logLevel", strings.ToLower(options.logLevelForPostgres)).WithError(err).Fatal("Invalid log level for database operations")
+
+	var leaderElection *collector.LeaderElection
+	if options.leaderElection {
+		leaderElection = newLeaderElection(kConfig, logger)
 	}
-	dbconf.ConnConfig.Tracer = &tracelog.TraceLog{Logger: logrusadapter.NewLogger(logger), LogLevel: pgLogLevel}
-	dbpool, err := pgxpool.NewWithConfig(ctx, dbconf)
-	if err != nil {
-		logger.WithError(err).Fatal("Failed to connect to database")
+
+	s, closeStore := newStore(ctx, logger)
+	defer closeStore()
+
+	healthChecker := &health.Checker{
+		Timeout: 5 * time.Second,
+		Logger:  logger,
+	}
+	if s.Health != nil {
+		healthChecker.Register("store", s.Health.Ping)
 	}
-	defer dbpool.Close()
 
-	s, err := store.New(ctx, dbpool)
-	if err != nil {
-		logger.WithError(err).Fatal("Failed to initialize the store")
+	archivedTables := strset.New(options.retentionArchive...)
+	var retentionPolicies []store.RetentionPolicy
+	for table, days := range options.retentionDays {
+		policy := store.RetentionPolicy{
+			Table:   table,
+			MaxAge:  time.Duration(days) * 24 * time.Hour,
+			Archive: archivedTables.Has(table),
+		}
+		if err = policy.Validate(); err != nil {
+			logger.WithError(err).Fatal("Invalid retention policy")
+		}
+		retentionPolicies = append(retentionPolicies, policy)
+	}
+	retentionJob := &retention.Job{
+		Store:    s.Retention,
+		Policies: retentionPolicies,
+		Interval: options.retentionInterval,
+		Logger:   logger,
+	}
+
+	if options.cdEventsSink != "" {
+		err = (&cdevents.Publisher{
+			Notifier: s.Notifier,
+			Sink: &cdevents.HTTPSink{
+				URL:    options.cdEventsSink,
+				Token:  options.cdEventsSinkToken,
+				Client: &http.Client{Timeout: 10 * time.Second},
+			},
+			Source:    "cd-indicators/" + options.namespace,
+			GitKind:   options.gitKind,
+			GitServer: options.gitServer,
+			Logger:    logger,
+		}).Start(ctx)
+		if err != nil {
+			logger.WithError(err).Fatal("Failed to start the CDEvents publisher")
+		}
+	}
+
+	identityCache := &collector.IdentityCache{
+		Store:    s.Identities,
+		Interval: options.identitiesRefresh,
+		Logger:   logger,
+	}
+	if err = identityCache.Start(ctx); err != nil {
+		logger.WithError(err).Fatal("Failed to start the identities cache")
+	}
+
+	filter := &collector.Filter{
+		Config:     newConfig(ctx, logger),
+		Identities: identityCache.Identities,
+		Logger:     logger,
+	}
+
+	var gitClient *scm.Client
+	if options.gitToken != "" {
+		gitClient, err = factory.NewClient(options.gitKind, options.gitServer, options.gitToken)
+		if err != nil {
+			logger.WithError(err).Fatal("failed to create a git client")
+		}
 	}
 
 	lighthouseHandler := lighthouse.Handler{
@@ -105,29 +275,205 @@ func main() {
 		Logger:      logger,
 	}
 
+	var cdEventsHandler *cdevents.Handler
+	if options.cdEvents {
+		cdEventsHandler = &cdevents.Handler{
+			Token:  options.cdEventsToken,
+			Logger: logger,
+		}
+	}
+
 	logger.WithField("namespace", options.namespace).WithField("resyncInterval", options.resyncInterval).Info("Starting Collector")
 	err = (&collector.Collector{
-		JXClient:          jxClient,
-		Namespace:         options.namespace,
-		ResyncInterval:    options.resyncInterval,
-		GitOwners:         strset.New(options.gitOwners...),
-		Store:             s,
-		LighthouseHandler: &lighthouseHandler,
-		Logger:            logger,
+		JXClient:           jxClient,
+		TektonClient:       tektonClient,
+		PipelineSource:     options.pipelineSource,
+		LighthouseClient:   lighthouseClient,
+		KubeClient:         kubeClient,
+		WorkloadNamespaces: options.workloadNamespaces,
+		HelmNamespaces:     options.helmNamespaces,
+		DynamicClient:      dynamicClient,
+		GitOpsSources:      options.gitOpsSources,
+		Namespace:          options.namespace,
+		ResyncInterval:     options.resyncInterval,
+		Filter:             filter,
+		Store:              s,
+		LighthouseHandler:  &lighthouseHandler,
+		CDEventsHandler:    cdEventsHandler,
+		GitClient:          gitClient,
+		TeamLabel:          options.teamLabel,
+		LeaderElection:     leaderElection,
+		RetentionJob:       retentionJob,
+		Health:             healthChecker,
+		Logger:             logger,
 	}).Start(ctx)
 	if err != nil {
 		logger.WithError(err).Fatal("Failed to start the collector")
 	}
 
 	http.Handle("/lighthouse/events", &lighthouseHandler)
+	if options.gitWebhooks {
+		webhooks, err := factory.NewWebHookService(options.gitKind)
+		if err != nil {
+			logger.WithField("gitKind", options.gitKind).WithError(err).Fatal("failed to create the git webhook service")
+		}
+		if webhooks == nil {
+			logger.WithField("gitKind", options.gitKind).Fatal("The git webhooks are not supported for this git kind: disable the --git-webhooks flag, and use the Lighthouse events")
+		}
+		if options.gitWebhookSecret == "" {
+			logger.Warning("No git webhook secret: the unsigned webhooks are accepted")
+		}
+		http.Handle("/git/events", &webhook.Handler{
+			Webhooks: webhooks,
+			Secret:   options.gitWebhookSecret,
+			Dispatch: lighthouseHandler.HandleWebhook,
+			Logger:   logger,
+		})
+	}
+	if cdEventsHandler != nil {
+		http.Handle("/cdevents", cdEventsHandler)
+	}
+	http.Handle("/api/", &api.Handler{
+		Store:             s,
+		Token:             options.apiToken,
+		IdentitiesChanged: identityCache.Refresh,
+		Logger:            logger,
+	})
 
 	http.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	})
+	http.Handle("/readyz", healthChecker)
+
+	server := &http.Server{
+		Addr: options.listenAddr,
+	}
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		<-ctx.Done()
+		logger.WithField("timeout", options.shutdownTimeout).Info("Shutting down")
+		healthChecker.ShutDown()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), options.shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			logger.WithError(err).Error("Failed to drain the in-flight requests")
+		}
+	}()
 
 	logger.WithField("listenAddr", options.listenAddr).Info("Starting HTTP Server")
-	err = http.ListenAndServe(options.listenAddr, nil)
+	err = server.ListenAndServe()
 	if !errors.Is(err, http.ErrServerClosed) {
 		logger.WithError(err).Fatal("failed to start HTTP server")
 	}
+	// ListenAndServe returns as soon as the shutdown starts: wait for the in-flight requests before closing the store
+	<-drained
+	logger.Info("Stopped")
+}
+
+func newLogger() *logrus.Logger {
+	logger := logrus.New()
+	logLevel, err := logrus.ParseLevel(options.logLevel)
+	if err != nil {
+		logger.WithField("logLevel", options.logLevel).WithError(err).Error("Invalid log level")
+	} else {
+		logger.SetLevel(logLevel)
+	}
+	return logger
+}
+
+// newConfig returns a function giving the current config: either reloaded from the config file, or static
+func newConfig(ctx context.Context, logger *logrus.Logger) func() *config.Config {
+	// the owners given as flags are added to the included owners of the config file
+	defaults := func(cfg *config.Config) {
+		cfg.Owners.Include = append(cfg.Owners.Include, options.gitOwners...)
+	}
+
+	if options.configPath == "" {
+		cfg := new(config.Config)
+		defaults(cfg)
+		return func() *config.Config { return cfg }
+	}
+
+	watcher := &config.Watcher{
+		Path:     options.configPath,
+		Interval: options.configReload,
+		Defaults: defaults,
+		Logger:   logger,
+	}
+	if err := watcher.Start(ctx); err != nil {
+		logger.WithError(err).Fatal("Failed to load the config file")
+	}
+	return watcher.Config
+}
+
+func newLeaderElection(kConfig *rest.Config, logger *logrus.Logger) *collector.LeaderElection {
+	kubeClient, err := kubernetes.NewForConfig(kConfig)
+	if err != nil {
+		logger.WithError(err).Fatal("failed to create a Kubernetes client")
+	}
+	identity, err := os.Hostname()
+	if err != nil {
+		logger.WithError(err).Fatal("failed to retrieve the hostname, used as the leader election identity")
+	}
+	namespace := options.leaseNamespace
+	if namespace == "" {
+		namespace = options.namespace
+	}
+	return &collector.LeaderElection{
+		KubeClient:    kubeClient,
+		Namespace:     namespace,
+		LeaseName:     options.leaseName,
+		Identity:      identity,
+		LeaseDuration: options.leaseDuration,
+		RenewDeadline: options.leaseRenewDeadline,
+		RetryPeriod:   options.leaseRetryPeriod,
+		Logger:        logger,
+	}
+}
+
+// newStore returns the store for the configured storage backend, and a function to release its resources
+func newStore(ctx context.Context, logger *logrus.Logger) (*store.Store, func()) {
+	log := logger.WithField("storage", options.storage)
+	switch options.storage {
+	case "postgres":
+		dbpool := newDBPool(ctx, logger)
+		s, err := postgres.New(ctx, dbpool)
+		if err != nil {
+			log.WithError(err).Fatal("Failed to initialize the store")
+		}
+		return s, dbpool.Close
+	case "sqlite":
+		db, err := sqlite.Open(options.sqlitePath)
+		if err != nil {
+			log.WithError(err).Fatal("Failed to open the SQLite database")
+		}
+		s, err := sqlite.New(ctx, db)
+		if err != nil {
+			log.WithError(err).Fatal("Failed to initialize the store")
+		}
+		return s, func() { _ = db.Close() }
+	case "memory":
+		return memory.New(), func() {}
+	default:
+		log.Fatal("Invalid storage backend")
+		return nil, nil
+	}
+}
+
+func newDBPool(ctx context.Context, logger *logrus.Logger) *pgxpool.Pool {
+	dbconf, err := pgxpool.ParseConfig(options.postgresURI)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to parse postgresURI")
+	}
+	pgLogLevel, err := tracelog.LogLevelFromString(strings.ToLower(options.logLevelForPostgres))
+	if err != nil {
+		logger.WithField("logLevel", strings.ToLower(options.logLevelForPostgres)).WithError(err).Fatal("Invalid log level for database operations")
+	}
+	dbconf.ConnConfig.Tracer = &tracelog.TraceLog{Logger: logrusadapter.NewLogger(logger), LogLevel: pgLogLevel}
+	dbpool, err := pgxpool.NewWithConfig(ctx, dbconf)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to connect to database")
+	}
+	return dbpool
 }
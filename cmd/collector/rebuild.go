@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+
+	"github.com/jenkins-x/cd-indicators/collector"
+	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/spf13/pflag"
+)
+
+var (
+	rebuildOptions struct {
+		owner      string
+		repository string
+	}
+)
+
+// runRebuild implements the "rebuild" subcommand, which rebuilds the pull requests, pipelines and deployments from their events,
+// so that a fix of the merge or durations logic - or a change of the config, such as the identities or the environment aliases - also applies to the past
+func runRebuild(args []string) {
+	flags := pflag.NewFlagSet("rebuild", pflag.ExitOnError)
+	flags.StringVar(&rebuildOptions.owner, "owner", "", "Only rebuild the pull requests, pipelines and deployments of this git owner/organization. Leave empty to rebuild all")
+	flags.StringVar(&rebuildOptions.repository, "repository", "", "Only rebuild the pull requests, pipelines and deployments of this repository. Leave empty to rebuild all")
+	for _, name := range []string{"storage", "postgres-uri", "sqlite-path", "config", "git-owners", "log-level", "log-level-db"} {
+		flags.AddFlag(pflag.Lookup(name))
+	}
+	_ = flags.Parse(args)
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	logger := newLogger()
+	log := logger.WithField("owner", rebuildOptions.owner).WithField("repository", rebuildOptions.repository)
+
+	if options.storage == "memory" {
+		log.Fatal("The rebuild subcommand can't rebuild the in-memory storage of a running collector")
+	}
+	s, closeStore := newStore(ctx, logger)
+	defer closeStore()
+	if s.Rebuild == nil {
+		log.WithField("storage", options.storage).Fatal("The storage doesn't support rebuilds")
+	}
+
+	identities, err := s.Identities.List(ctx)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to load the identities")
+	}
+	filter := &collector.Filter{
+		Config:     newConfig(ctx, logger),
+		Identities: func() []store.Identity { return identities },
+		Logger:     logger,
+	}
+	pullRequestCollector := &collector.PullRequestCollector{
+		Filter: filter,
+		Logger: logger,
+	}
+
+	log.Info("Rebuilding the pull requests, pipelines and deployments")
+	result, err := s.Rebuild.Rebuild(ctx, store.RebuildFilter{
+		Owner:      rebuildOptions.owner,
+		Repository: rebuildOptions.repository,
+	}, store.Rebuilder{
+		PullRequest: pullRequestCollector.PullRequestFromEvent,
+		Person:      filter.Person,
+		Pipeline:    filter.PipelineFromEvent,
+		Deployment:  filter.DeploymentFromEvent,
+	})
+	if err != nil {
+		log.WithError(err).Fatal("Failed to rebuild")
+	}
+	log.
+		WithField("pullRequestEvents", result.PullRequestEvents).
+		WithField("pullRequests", result.PullRequests).
+		WithField("incompletePullRequests", result.IncompletePullRequests).
+		WithField("pipelines", result.Pipelines).
+		WithField("deployments", result.Deployments).
+		Info("Rebuilt")
+}
@@ -0,0 +1,71 @@
+package collector
+
+import (
+	"strings"
+
+	"github.com/jenkins-x/cd-indicators/store"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// argoCDApplicationSync returns the outcome of the last sync operation of an Argo CD Application:
+// a failed or errored operation fails the sync, while a succeeded operation is only a success once the Application is healthy -
+// or a failure if it is degraded.
+func argoCDApplicationSync(u *unstructured.Unstructured) (gitOpsSync, bool) {
+	phase, _, _ := unstructured.NestedString(u.Object, "status", "operationState", "phase")
+	message, _, _ := unstructured.NestedString(u.Object, "status", "operationState", "message")
+	finishedAt, _, _ := unstructured.NestedString(u.Object, "status", "operationState", "finishedAt")
+
+	var status string
+	switch phase {
+	case "Failed", "Error":
+		status = store.GitOpsSyncStatusFailed
+	case "Succeeded":
+		health, _, _ := unstructured.NestedString(u.Object, "status", "health", "status")
+		switch health {
+		case "Healthy":
+			status = store.GitOpsSyncStatusSucceeded
+		case "Degraded":
+			status = store.GitOpsSyncStatusFailed
+			if healthMessage, _, _ := unstructured.NestedString(u.Object, "status", "health", "message"); healthMessage != "" {
+				message = healthMessage
+			}
+		default:
+			// still progressing, suspended or unknown
+			return gitOpsSync{}, false
+		}
+	default:
+		// running or terminating
+		return gitOpsSync{}, false
+	}
+
+	// the applications with multiple sources are named after their first source
+	source, found, _ := unstructured.NestedMap(u.Object, "spec", "source")
+	if !found {
+		if sources, _, _ := unstructured.NestedSlice(u.Object, "spec", "sources"); len(sources) > 0 {
+			source, _ = sources[0].(map[string]interface{})
+		}
+	}
+	chart, _, _ := unstructured.NestedString(source, "chart")
+
+	revision, _, _ := unstructured.NestedString(u.Object, "status", "operationState", "syncResult", "revision")
+	if revision == "" {
+		if revisions, _, _ := unstructured.NestedStringSlice(u.Object, "status", "operationState", "syncResult", "revisions"); len(revisions) > 0 {
+			revision = revisions[0]
+		}
+	}
+	namespace, _, _ := unstructured.NestedString(u.Object, "spec", "destination", "namespace")
+
+	applications := []string{u.GetName()}
+	if chart != "" && chart != u.GetName() {
+		applications = append(applications, chart)
+	}
+	return gitOpsSync{
+		Applications: applications,
+		Namespace:    namespace,
+		Revision:     revision,
+		Version:      gitOpsVersion(u, revision),
+		Status:       status,
+		Message:      strings.TrimSpace(message),
+		Time:         parseGitOpsTime(finishedAt),
+	}, true
+}
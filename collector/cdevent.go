@@ -0,0 +1,397 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jenkins-x/cd-indicators/internal/cdevents"
+	"github.com/jenkins-x/cd-indicators/store"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/scylladb/go-set/strset"
+	"github.com/sirupsen/logrus"
+)
+
+// pipelineRunStartTTL is how long the start of a pipeline run is kept, waiting for its finished event
+const pipelineRunStartTTL = 24 * time.Hour
+
+// CDEventCollector collects the pipelines, releases, deployments and incidents of the CDEvents sent by the tools
+// other than Jenkins X and Lighthouse:
+// the finished pipeline runs, the published artifacts, the deployed, upgraded or rolled back services, and the incidents.
+// The CDEvents don't link the pipeline runs to a git repository: it is read from their custom data, see cdevents.CustomData.
+type CDEventCollector struct {
+	CDEventsHandler *cdevents.Handler
+	Filter          *Filter
+	PipelineStore   store.PipelineStore
+	ReleaseStore    store.ReleaseStore
+	DeploymentStore store.DeploymentStore
+	IncidentStore   store.IncidentStore
+	Logger          *logrus.Logger
+
+	pipelineRunStarts pipelineRunStarts
+}
+
+func (c *CDEventCollector) Start(_ context.Context) error { // nolint: unparam
+	c.CDEventsHandler.RegisterHandler(c.handleEvent)
+	return nil
+}
+
+func (c *CDEventCollector) handleEvent(ctx context.Context, event cdevents.Event) error {
+	log := c.Logger.WithField("type", event.Context.Type).WithField("id", event.Context.ID)
+
+	subject, predicate := event.Kind()
+	switch {
+	case subject == cdevents.SubjectPipelineRun && (predicate == cdevents.PredicateQueued || predicate == cdevents.PredicateStarted):
+		c.pipelineRunStarts.add(pipelineRunKey(event), eventTime(event), predicate == cdevents.PredicateStarted)
+	case subject == cdevents.SubjectPipelineRun && predicate == cdevents.PredicateFinished:
+		return c.storePipelineRun(ctx, event)
+	case subject == cdevents.SubjectArtifact && predicate == cdevents.PredicatePublished:
+		return c.storeArtifact(ctx, event)
+	case subject == cdevents.SubjectService &&
+		(predicate == cdevents.PredicateDeployed || predicate == cdevents.PredicateUpgraded || predicate == cdevents.PredicateRolledBack):
+		return c.storeService(ctx, event)
+	case subject == cdevents.SubjectIncident &&
+		(predicate == cdevents.PredicateDetected || predicate == cdevents.PredicateReported || predicate == cdevents.PredicateResolved):
+		return c.storeIncident(ctx, event, predicate == cdevents.PredicateResolved)
+	default:
+		log.Trace("Ignoring CDEvent")
+	}
+	return nil
+}
+
+// storePipelineRun stores the pipeline of a finished pipeline run, whose repository is in its custom data.
+// Its build and start time are also read from its custom data, or else from its subject id and its started - or queued - event:
+// without start time, its duration is 0.
+// The start of the run is forgotten once the pipeline is stored - or ignored - so that it is still known when the event is redelivered.
+func (c *CDEventCollector) storePipelineRun(ctx context.Context, event cdevents.Event) (err error) {
+	var content cdevents.PipelineRunContent
+	if err := event.Content(&content); err != nil {
+		return fmt.Errorf("invalid CDEvent %s: %w", event, err)
+	}
+	log := c.Logger.WithField("type", event.Context.Type).WithField("id", event.Context.ID).WithField("pipeline", content.PipelineName)
+
+	custom := event.Custom()
+	key := pipelineRunKey(event)
+	startTime, started := c.pipelineRunStarts.get(key)
+	defer func() {
+		if err == nil {
+			c.pipelineRunStarts.remove(key)
+		}
+	}()
+	owner, repository := cdevents.Repository(custom.Repository)
+	if owner == "" || repository == "" {
+		log.Debug("Ignoring pipeline run CDEvent with no git repository in its custom data")
+		return nil
+	}
+	build := custom.Build
+	if build == 0 {
+		build = pipelineRunBuild(event.Subject.ID)
+	}
+	if build == 0 {
+		log.Debug("Ignoring pipeline run CDEvent with no build in its custom data nor subject id")
+		return nil
+	}
+	pipelineContext := custom.Context
+	if pipelineContext == "" {
+		pipelineContext = content.PipelineName
+	}
+	if pipelineContext == "" {
+		log.Debug("Ignoring pipeline run CDEvent with no context")
+		return nil
+	}
+	if !c.Filter.AllowsRepository(owner, repository) {
+		return nil
+	}
+
+	endTime := eventTime(event)
+	switch {
+	case custom.StartTime != nil && !custom.StartTime.IsZero():
+		startTime = custom.StartTime.In(time.UTC)
+	case !started:
+		log.Debug("Pipeline run CDEvent with no start time in its custom data nor started event: its duration is 0")
+		startTime = endTime
+	}
+	pipeline := store.Pipeline{
+		Type:        store.PipelineTypeRelease,
+		Owner:       owner,
+		Repository:  repository,
+		PullRequest: custom.PullRequest,
+		Context:     pipelineContext,
+		Build:       build,
+		Status:      string(pipelineRunStatus(content.Outcome)),
+		StartTime:   startTime,
+		EndTime:     endTime,
+	}
+	if custom.PullRequest > 0 {
+		pipeline.Type = store.PipelineTypePullRequest
+	}
+	if custom.Author != "" {
+		pipeline.Author = c.Filter.Person(custom.Author)
+		pipeline.IsBot = c.Filter.IsBot(custom.Author)
+		pipeline.AuthorLogin = custom.Author
+	}
+	pipeline.Duration = pipeline.EndTime.Sub(pipeline.StartTime)
+
+	log.WithField("repo", owner+"/"+repository).WithField("build", pipeline.Build).Debug("Storing pipeline")
+	if err := c.PipelineStore.Add(ctx, pipeline); err != nil {
+		return fmt.Errorf("failed to store the pipeline of CDEvent %s: %w", event, err)
+	}
+	return nil
+}
+
+// storeArtifact stores the release of a published artifact: the version of its package URL, in its git repository
+func (c *CDEventCollector) storeArtifact(ctx context.Context, event cdevents.Event) error {
+	log := c.Logger.WithField("type", event.Context.Type).WithField("id", event.Context.ID).WithField("artifact", event.Subject.ID)
+
+	owner, repository, version, ok := c.artifact(event, event.Subject.ID)
+	if !ok {
+		log.Trace("Ignoring artifact CDEvent with no git repository or version")
+		return nil
+	}
+	if !c.Filter.AllowsRepository(owner, repository) {
+		return nil
+	}
+
+	var (
+		contributors = strset.New()
+		bots         = strset.New()
+	)
+	for _, login := range event.Custom().Contributors {
+		switch {
+		case login == "":
+			continue
+		case c.Filter.IsBot(login):
+			contributors.Add(login)
+			bots.Add(login)
+		default:
+			contributors.Add(c.Filter.Person(login))
+		}
+	}
+	release := store.Release{
+		Owner:        owner,
+		Repository:   repository,
+		Version:      version,
+		Contributors: contributors.List(),
+		ReleaseTime:  eventTime(event),
+		// a release with only bot contributors - such as a dependency upgrade - is automated
+		IsBot: !bots.IsEmpty() && bots.Size() == contributors.Size(),
+	}
+
+	log.WithField("release", release.String()).Debug("Storing release")
+	if err := c.ReleaseStore.Add(ctx, release); err != nil {
+		return fmt.Errorf("failed to store the release of CDEvent %s: %w", event, err)
+	}
+	return nil
+}
+
+// storeService stores the deployment of the artifact of a service in an environment
+func (c *CDEventCollector) storeService(ctx context.Context, event cdevents.Event) error {
+	var content cdevents.ServiceContent
+	if err := event.Content(&content); err != nil {
+		return fmt.Errorf("invalid CDEvent %s: %w", event, err)
+	}
+	log := c.Logger.WithField("type", event.Context.Type).WithField("id", event.Context.ID).WithField("artifact", content.ArtifactID)
+
+	owner, repository, version, ok := c.artifact(event, content.ArtifactID)
+	if !ok {
+		log.Trace("Ignoring service CDEvent with no git repository or version")
+		return nil
+	}
+	if content.Environment.ID == "" {
+		log.Trace("Ignoring service CDEvent with no environment")
+		return nil
+	}
+	if !c.Filter.AllowsRepository(owner, repository) {
+		return nil
+	}
+
+	d := store.Deployment{
+		Owner:             owner,
+		Repository:        repository,
+		Version:           version,
+		Environment:       c.Filter.Environment(owner, repository, content.Environment.ID),
+		DeploymentTime:    eventTime(event),
+		SourceEnvironment: content.Environment.ID,
+	}
+	log.WithField("deployment", d.String()).Debug("Storing deployment")
+	if err := c.DeploymentStore.Add(ctx, d); err != nil {
+		return fmt.Errorf("failed to store the deployment of CDEvent %s: %w", event, err)
+	}
+	return nil
+}
+
+// storeIncident stores a detected or reported incident, or its resolution.
+// Its repository and version are the ones of its artifact - or else the repository of the custom data of the event.
+func (c *CDEventCollector) storeIncident(ctx context.Context, event cdevents.Event, resolved bool) error {
+	var content cdevents.IncidentContent
+	if err := event.Content(&content); err != nil {
+		return fmt.Errorf("invalid CDEvent %s: %w", event, err)
+	}
+	log := c.Logger.WithField("type", event.Context.Type).WithField("id", event.Context.ID).WithField("incident", event.Subject.ID)
+
+	if event.Subject.ID == "" || content.Environment.ID == "" {
+		log.Debug("Ignoring incident CDEvent with no id or environment")
+		return nil
+	}
+	var owner, repository, version string
+	if content.ArtifactID != "" {
+		owner, repository, version, _ = c.artifact(event, content.ArtifactID)
+	}
+	if owner == "" || repository == "" {
+		owner, repository = cdevents.Repository(event.Custom().Repository)
+	}
+	if owner == "" || repository == "" {
+		log.Debug("Ignoring incident CDEvent with no git repository in its artifact id nor custom data")
+		return nil
+	}
+	if !c.Filter.AllowsRepository(owner, repository) {
+		return nil
+	}
+
+	incident := store.Incident{
+		Source:        event.SubjectSource(),
+		ID:            event.Subject.ID,
+		Owner:         owner,
+		Repository:    repository,
+		Environment:   c.Filter.Environment(owner, repository, content.Environment.ID),
+		Version:       version,
+		Description:   content.Description,
+		DetectionTime: eventTime(event),
+	}
+	if resolved {
+		// the detection time is replaced by the one of the detected event, even if it comes later
+		resolutionTime := incident.DetectionTime
+		incident.ResolutionTime = &resolutionTime
+	}
+	log.WithField("resolved", resolved).Debug("Storing incident")
+	if err := c.IncidentStore.Add(ctx, incident); err != nil {
+		return fmt.Errorf("failed to store the incident of CDEvent %s: %w", event, err)
+	}
+	return nil
+}
+
+// artifact returns the git repository and version of an artifact: the repository of the custom data of the event,
+// or else the owner and name of its package URL
+func (c *CDEventCollector) artifact(event cdevents.Event, artifactID string) (owner, repository, version string, ok bool) {
+	purl, err := cdevents.ParsePackageURL(artifactID)
+	if err != nil {
+		c.Logger.WithField("id", event.Context.ID).WithError(err).Debug("Invalid artifact id")
+		return "", "", "", false
+	}
+	owner, repository = cdevents.Repository(event.Custom().Repository)
+	if owner == "" || repository == "" {
+		owner, repository = purl.Owner(), purl.Name
+	}
+	version = purl.PackageVersion()
+	return owner, repository, version, owner != "" && repository != "" && version != ""
+}
+
+// pipelineRunKey identifies the pipeline run of an event, to match its finished event with its started or queued event
+func pipelineRunKey(event cdevents.Event) string {
+	if event.Subject.ID == "" {
+		return ""
+	}
+	return event.SubjectSource() + " " + event.Subject.ID
+}
+
+// pipelineRunBuild returns the build number of a pipeline run with none in its custom data:
+// the number ending its subject id - such as "myapp-release-42" - or else a hash of its subject id.
+// It returns 0 when the subject id is empty.
+func pipelineRunBuild(subjectID string) int {
+	if subjectID == "" {
+		return 0
+	}
+	start := len(subjectID)
+	for start > 0 && subjectID[start-1] >= '0' && subjectID[start-1] <= '9' {
+		start--
+	}
+	if build, err := strconv.Atoi(subjectID[start:]); err == nil && build > 0 {
+		return build
+	}
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(subjectID))
+	if build := int(hash.Sum32() & math.MaxInt32); build > 0 {
+		return build
+	}
+	return 1
+}
+
+// pipelineRunStarts are the start times of the pipeline runs seen starting - or queued - until their finished event
+type pipelineRunStarts struct {
+	mu     sync.Mutex
+	starts map[string]pipelineRunStart
+}
+
+type pipelineRunStart struct {
+	time    time.Time
+	started bool
+	added   time.Time
+}
+
+// add keeps the start time of a pipeline run: the time of its started event, or else of its queued event.
+// The starts of the runs never finished are dropped after pipelineRunStartTTL.
+func (s *pipelineRunStarts) add(key string, t time.Time, started bool) {
+	if key == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for k, start := range s.starts {
+		if now.Sub(start.added) > pipelineRunStartTTL {
+			delete(s.starts, k)
+		}
+	}
+	if s.starts == nil {
+		s.starts = map[string]pipelineRunStart{}
+	}
+	if existing, found := s.starts[key]; found && existing.started && !started {
+		return
+	}
+	s.starts[key] = pipelineRunStart{time: t, started: started, added: now}
+}
+
+// get returns the start time of a pipeline run, if known
+func (s *pipelineRunStarts) get(key string) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	start, found := s.starts[key]
+	return start.time, found
+}
+
+// remove forgets the start time of a pipeline run
+func (s *pipelineRunStarts) remove(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.starts, key)
+}
+
+// pipelineRunStatus maps the outcome of a pipeline run to the status of the PipelineActivities
+func pipelineRunStatus(outcome string) jenkinsv1.ActivityStatusType {
+	switch strings.ToLower(outcome) {
+	case cdevents.OutcomeSuccess:
+		return jenkinsv1.ActivityStatusTypeSucceeded
+	case cdevents.OutcomeCancel:
+		return jenkinsv1.ActivityStatusTypeCancelled
+	case cdevents.OutcomeError:
+		return jenkinsv1.ActivityStatusTypeError
+	default:
+		return jenkinsv1.ActivityStatusTypeFailed
+	}
+}
+
+// eventTime returns the time of the event - or now if it has none
+func eventTime(event cdevents.Event) time.Time {
+	if event.Context.Timestamp.IsZero() {
+		return time.Now().UTC()
+	}
+	return event.Context.Timestamp.In(time.UTC)
+}
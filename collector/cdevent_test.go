@@ -0,0 +1,239 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/cd-indicators/internal/cdevents"
+	"github.com/jenkins-x/cd-indicators/internal/config"
+	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/jenkins-x/cd-indicators/store/memory"
+	"github.com/scylladb/go-set/strset"
+	"github.com/sirupsen/logrus"
+)
+
+func testCDEvent(eventType, subjectID string, timestamp time.Time, content, customData any) cdevents.Event {
+	var event cdevents.Event
+	event.Context.ID = eventType + "-" + subjectID
+	event.Context.Source = "/ci"
+	event.Context.Type = eventType
+	event.Context.Timestamp = timestamp
+	event.Subject.ID = subjectID
+	if content != nil {
+		event.Subject.Content, _ = json.Marshal(content)
+	}
+	if customData != nil {
+		event.CustomData, _ = json.Marshal(customData)
+	}
+	return event
+}
+
+func TestCDEventCollectorStoresThePipelineRuns(t *testing.T) {
+	var (
+		queued  = time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+		started = queued.Add(time.Minute)
+		end     = started.Add(5 * time.Minute)
+		content = cdevents.PipelineRunContent{PipelineName: "release", Outcome: cdevents.OutcomeSuccess}
+		custom  = cdevents.CustomData{Repository: "https://github.com/org/app.git"}
+	)
+	s := memory.New()
+	c := &CDEventCollector{
+		Filter:        newTestFilter(&config.Config{}),
+		PipelineStore: s.Pipelines,
+		Logger:        logrus.New(),
+	}
+
+	events := []cdevents.Event{
+		// the start time and build of the run are the ones of its started event and subject id
+		testCDEvent("dev.cdevents.pipelinerun.queued.0.2.0", "app-release-42", queued, content, custom),
+		testCDEvent("dev.cdevents.pipelinerun.started.0.2.0", "app-release-42", started, content, custom),
+		testCDEvent("dev.cdevents.pipelinerun.finished.0.2.0", "app-release-42", end, content, custom),
+		// without started event nor number in its subject id
+		testCDEvent("dev.cdevents.pipelinerun.finished.0.2.0", "f81d4fae", end, content, custom),
+		// ignored: no git repository
+		testCDEvent("dev.cdevents.pipelinerun.finished.0.2.0", "web-release-1", end, content, nil),
+	}
+	for _, event := range events {
+		if err := c.handleEvent(context.Background(), event); err != nil {
+			t.Fatalf("failed to handle %s: %v", event, err)
+		}
+	}
+
+	pipelines := s.Pipelines.(*memory.PipelineStore).List()
+	if len(pipelines) != 2 {
+		t.Fatalf("expected 2 pipelines, got %d", len(pipelines))
+	}
+	release := pipelines[0]
+	if release.Owner != "org" || release.Repository != "app" || release.Context != "release" || release.Build != 42 || release.Status != "Succeeded" {
+		t.Errorf("expected the succeeded build 42 of the release of org/app, got %+v", release)
+	}
+	if !release.StartTime.Equal(started) || !release.EndTime.Equal(end) || release.Duration != 5*time.Minute {
+		t.Errorf("expected the pipeline to run from its started event for 5 minutes, got %s to %s (%s)", release.StartTime, release.EndTime, release.Duration)
+	}
+	unstarted := pipelines[1]
+	if unstarted.Build != pipelineRunBuild("f81d4fae") || unstarted.Build <= 0 {
+		t.Errorf("expected the build of the pipeline to be the hash of its subject id, got %d", unstarted.Build)
+	}
+	if !unstarted.StartTime.Equal(end) || unstarted.Duration != 0 {
+		t.Errorf("expected the pipeline without start time to last 0, got %s (%s)", unstarted.StartTime, unstarted.Duration)
+	}
+	if _, found := c.pipelineRunStarts.get(pipelineRunKey(events[0])); found {
+		t.Errorf("expected the start of the finished pipeline run to be forgotten")
+	}
+}
+
+// failingPipelineStore fails to store the first pipeline, as a database which is down
+type failingPipelineStore struct {
+	store.PipelineStore
+	failed bool
+}
+
+func (s *failingPipelineStore) Add(ctx context.Context, p store.Pipeline) error {
+	if !s.failed {
+		s.failed = true
+		return errors.New("database is down")
+	}
+	return s.PipelineStore.Add(ctx, p)
+}
+
+func TestCDEventCollectorKeepsTheStartOfTheRedeliveredPipelineRuns(t *testing.T) {
+	var (
+		started = time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+		end     = started.Add(5 * time.Minute)
+		content = cdevents.PipelineRunContent{PipelineName: "release", Outcome: cdevents.OutcomeSuccess}
+		custom  = cdevents.CustomData{Repository: "org/app"}
+	)
+	s := memory.New()
+	c := &CDEventCollector{
+		Filter:        newTestFilter(&config.Config{}),
+		PipelineStore: &failingPipelineStore{PipelineStore: s.Pipelines},
+		Logger:        logrus.New(),
+	}
+
+	if err := c.handleEvent(context.Background(), testCDEvent("dev.cdevents.pipelinerun.started.0.2.0", "app-release-42", started, content, custom)); err != nil {
+		t.Fatal(err)
+	}
+	finished := testCDEvent("dev.cdevents.pipelinerun.finished.0.2.0", "app-release-42", end, content, custom)
+	if err := c.handleEvent(context.Background(), finished); err == nil {
+		t.Fatal("expected the first delivery of the finished event to fail")
+	}
+	if err := c.handleEvent(context.Background(), finished); err != nil {
+		t.Fatal(err)
+	}
+
+	pipelines := s.Pipelines.(*memory.PipelineStore).List()
+	if len(pipelines) != 1 {
+		t.Fatalf("expected 1 pipeline, got %d", len(pipelines))
+	}
+	if !pipelines[0].StartTime.Equal(started) || pipelines[0].Duration != 5*time.Minute {
+		t.Errorf("expected the redelivered pipeline run to start with its started event, got %s (%s)", pipelines[0].StartTime, pipelines[0].Duration)
+	}
+	if _, found := c.pipelineRunStarts.get(pipelineRunKey(finished)); found {
+		t.Errorf("expected the start of the stored pipeline run to be forgotten")
+	}
+}
+
+func TestCDEventCollectorKeepsTheBotContributorsOfTheReleases(t *testing.T) {
+	released := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	s := memory.New()
+	c := &CDEventCollector{
+		Filter: newTestFilter(&config.Config{
+			Identities: []store.Identity{{Person: "alice", Aliases: []string{"alice-gh"}}},
+		}),
+		ReleaseStore: s.Releases,
+		Logger:       logrus.New(),
+	}
+
+	events := []cdevents.Event{
+		testCDEvent("dev.cdevents.artifact.published.0.2.0", "pkg:github/org/app@1.0.0", released, nil, cdevents.CustomData{Contributors: []string{"alice-gh", "renovate[bot]"}}),
+		testCDEvent("dev.cdevents.artifact.published.0.2.0", "pkg:github/org/app@1.0.1", released, nil, cdevents.CustomData{Contributors: []string{"renovate[bot]"}}),
+	}
+	for _, event := range events {
+		if err := c.handleEvent(context.Background(), event); err != nil {
+			t.Fatalf("failed to handle %s: %v", event, err)
+		}
+	}
+
+	releases := s.Releases.(*memory.ReleaseStore).List()
+	if len(releases) != 2 {
+		t.Fatalf("expected 2 releases, got %d", len(releases))
+	}
+	if contributors := releases[0].Contributors; !strset.New(contributors...).IsEqual(strset.New("alice", "renovate[bot]")) || releases[0].IsBot {
+		t.Errorf("expected the release of alice and a bot to be kept with both contributors, got %v (bot: %v)", contributors, releases[0].IsBot)
+	}
+	if contributors := releases[1].Contributors; len(contributors) != 1 || contributors[0] != "renovate[bot]" || !releases[1].IsBot {
+		t.Errorf("expected the release of the bot to be automated, got %v (bot: %v)", contributors, releases[1].IsBot)
+	}
+}
+
+func TestPipelineRunBuild(t *testing.T) {
+	tests := map[string]int{
+		"":               0,
+		"42":             42,
+		"app-release-42": 42,
+	}
+	for subjectID, expected := range tests {
+		if build := pipelineRunBuild(subjectID); build != expected {
+			t.Errorf("expected the build of %q to be %d, got %d", subjectID, expected, build)
+		}
+	}
+	for _, subjectID := range []string{"f81d4fae", "app-release-0"} {
+		if build := pipelineRunBuild(subjectID); build <= 0 || build != pipelineRunBuild(subjectID) {
+			t.Errorf("expected the build of %q to be a stable positive hash, got %d", subjectID, build)
+		}
+	}
+}
+
+func TestCDEventCollectorStoresTheIncidents(t *testing.T) {
+	var (
+		detected = time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+		resolved = detected.Add(2 * time.Hour)
+	)
+	s := memory.New()
+	c := &CDEventCollector{
+		Filter: newTestFilter(&config.Config{
+			Environments: []config.EnvironmentAliases{{Aliases: map[string][]string{"production": {"prod-eu"}}}},
+		}),
+		IncidentStore: s.Incidents,
+		Logger:        logrus.New(),
+	}
+
+	incident := cdevents.IncidentContent{
+		Description: "5xx errors",
+		Environment: cdevents.Reference{ID: "prod-eu"},
+		ArtifactID:  "pkg:github/org/app@v1.2.3",
+	}
+	events := []cdevents.Event{
+		// the resolution is received before the detection
+		testCDEvent("dev.cdevents.incident.resolved.0.2.0", "INC-1", resolved, cdevents.IncidentContent{Environment: incident.Environment, ArtifactID: incident.ArtifactID}, nil),
+		testCDEvent("dev.cdevents.incident.detected.0.2.0", "INC-1", detected, incident, nil),
+		// no artifact: the repository of the custom data, with no version
+		testCDEvent("dev.cdevents.incident.reported.0.2.0", "INC-2", detected, cdevents.IncidentContent{Environment: cdevents.Reference{ID: "staging"}}, cdevents.CustomData{Repository: "org/web"}),
+		// ignored: no environment
+		testCDEvent("dev.cdevents.incident.detected.0.2.0", "INC-3", detected, cdevents.IncidentContent{ArtifactID: incident.ArtifactID}, nil),
+	}
+	for _, event := range events {
+		if err := c.handleEvent(context.Background(), event); err != nil {
+			t.Fatalf("failed to handle %s: %v", event, err)
+		}
+	}
+
+	incidents := s.Incidents.(*memory.IncidentStore).List()
+	if len(incidents) != 2 {
+		t.Fatalf("expected 2 incidents, got %d", len(incidents))
+	}
+	app := incidents[0]
+	if app.Source != "/ci" || app.ID != "INC-1" || app.Owner != "org" || app.Repository != "app" || app.Version != "1.2.3" || app.Environment != "production" {
+		t.Errorf("expected the incident INC-1 of org/app 1.2.3 in production, got %+v", app)
+	}
+	if timeToRestore, ok := app.TimeToRestore(); !ok || timeToRestore != 2*time.Hour || app.Description != "5xx errors" {
+		t.Errorf("expected the incident %q to be restored in 2 hours, got %s (resolved: %v)", app.Description, timeToRestore, ok)
+	}
+	web := incidents[1]
+	if web.Owner != "org" || web.Repository != "web" || web.Version != "" || web.Environment != "staging" || web.ResolutionTime != nil {
+		t.Errorf("expected the unresolved incident of org/web in staging, got %+v", web)
+	}
+}
@@ -5,62 +5,236 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/jenkins-x/cd-indicators/internal/cdevents"
+	"github.com/jenkins-x/cd-indicators/internal/health"
 	"github.com/jenkins-x/cd-indicators/internal/lighthouse"
+	"github.com/jenkins-x/cd-indicators/internal/retention"
 	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/jenkins-x/go-scm/scm"
 	jxclientset "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned"
-	"github.com/scylladb/go-set/strset"
+	lhclientset "github.com/jenkins-x/lighthouse-client/pkg/clientset/versioned"
 	"github.com/sirupsen/logrus"
+	tektonclientset "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+// The sources of the pipelines
+const (
+	PipelineSourcePipelineActivities = "pipelineactivities"
+	PipelineSourceTekton             = "tekton"
 )
 
 type Collector struct {
-	JXClient          *jxclientset.Clientset
+	JXClient *jxclientset.Clientset
+	// TektonClient is only required by the tekton pipeline source
+	TektonClient tektonclientset.Interface
+	// PipelineSource is the source of the pipelines: the Jenkins X PipelineActivities - by default - or the Tekton PipelineRuns
+	PipelineSource string
+	// LighthouseClient is optional: when set, the LighthouseJobs are collected
+	LighthouseClient lhclientset.Interface
+	// KubeClient is only required to collect the deployments from the workloads or the Helm releases
+	KubeClient kubernetes.Interface
+	// WorkloadNamespaces maps the namespaces of the environments to their name: their workloads rollouts are collected
+	// as deployments. Empty disables this deployment source
+	WorkloadNamespaces map[string]string
+	// HelmNamespaces maps the namespaces of the environments to their name: the revisions of their Helm releases
+	// are collected, and stored as deployments when they succeed. Empty disables this deployment source
+	HelmNamespaces map[string]string
+	// DynamicClient is only required to collect the syncs of the GitOps tools
+	DynamicClient dynamic.Interface
+	// GitOpsSources are the GitOps tools - argocd or flux - whose syncs are collected, and stored as deployments when they succeed.
+	// Empty disables this deployment source
+	GitOpsSources     []string
 	Namespace         string
 	ResyncInterval    time.Duration
-	GitOwners         *strset.Set
+	Filter            *Filter
 	Store             *store.Store
 	LighthouseHandler *lighthouse.Handler
-	Logger            *logrus.Logger
+	// CDEventsHandler is optional: when set, the pipelines, releases and deployments of the received CDEvents are collected
+	CDEventsHandler *cdevents.Handler
+	// GitClient is optional: it is used to retrieve data which is not part of the webhooks
+	GitClient *scm.Client
+	// TeamLabel is the SourceRepository label holding the team of the repository. Empty disables this team source
+	TeamLabel string
+	// LeaderElection is optional: when set, the informers only run on the elected leader,
+	// so that multiple replicas don't all write the same resources
+	LeaderElection *LeaderElection
+	// RetentionJob is optional: when set, it runs with the informers - on the elected leader only,
+	// so that the replicas don't all roll up and delete the same rows
+	RetentionJob *retention.Job
+	// Health is optional: when set, the informers register their readiness checks
+	Health *health.Checker
+	Logger *logrus.Logger
 
 	pipelineActivityCollector *PipelineActivityCollector
+	tektonCollector           *TektonCollector
 	releaseCollector          *ReleaseCollector
 	pullRequestCollector      *PullRequestCollector
 	deploymentCollector       *DeploymentCollector
+	teamCollector             *TeamCollector
+	repositoryCollector       *RepositoryCollector
+	lighthouseJobCollector    *LighthouseJobCollector
+	workloadCollector         *WorkloadCollector
+	helmReleaseCollector      *HelmReleaseCollector
+	gitOpsCollector           *GitOpsCollector
+	cdEventCollector          *CDEventCollector
 }
 
 func (c *Collector) Start(ctx context.Context) error {
-	c.pipelineActivityCollector = &PipelineActivityCollector{
-		JXClient:       c.JXClient,
-		Namespace:      c.Namespace,
-		ResyncInterval: c.ResyncInterval,
-		GitOwners:      c.GitOwners,
-		Store:          c.Store.Pipelines,
-		Logger:         c.Logger,
+	switch c.PipelineSource {
+	case "", PipelineSourcePipelineActivities:
+		c.pipelineActivityCollector = &PipelineActivityCollector{
+			JXClient:       c.JXClient,
+			Namespace:      c.Namespace,
+			ResyncInterval: c.ResyncInterval,
+			Filter:         c.Filter,
+			Store:          c.Store.Pipelines,
+			Logger:         c.Logger,
+		}
+	case PipelineSourceTekton:
+		c.tektonCollector = &TektonCollector{
+			TektonClient:   c.TektonClient,
+			Namespace:      c.Namespace,
+			ResyncInterval: c.ResyncInterval,
+			Filter:         c.Filter,
+			Store:          c.Store.Pipelines,
+			Logger:         c.Logger,
+		}
+	default:
+		return fmt.Errorf("unknown pipeline source %q: must be %s or %s", c.PipelineSource, PipelineSourcePipelineActivities, PipelineSourceTekton)
 	}
 	c.releaseCollector = &ReleaseCollector{
 		JXClient:          c.JXClient,
 		Namespace:         c.Namespace,
 		ResyncInterval:    c.ResyncInterval,
-		GitOwners:         c.GitOwners,
+		Filter:            c.Filter,
 		Store:             c.Store.Releases,
 		LighthouseHandler: c.LighthouseHandler,
 		Logger:            c.Logger,
 	}
 	c.pullRequestCollector = &PullRequestCollector{
-		GitOwners:         c.GitOwners,
+		Filter:            c.Filter,
 		Store:             c.Store.PullRequests,
+		EventStore:        c.Store.PullRequestEvents,
+		DeploymentStore:   c.Store.Deployments,
 		LighthouseHandler: c.LighthouseHandler,
+		GitClient:         c.GitClient,
 		Logger:            c.Logger,
 	}
 	c.deploymentCollector = &DeploymentCollector{
-		GitOwners:         c.GitOwners,
+		Filter:            c.Filter,
 		Store:             c.Store.Deployments,
 		LighthouseHandler: c.LighthouseHandler,
 		Logger:            c.Logger,
 	}
+	if c.CDEventsHandler != nil {
+		c.cdEventCollector = &CDEventCollector{
+			CDEventsHandler: c.CDEventsHandler,
+			Filter:          c.Filter,
+			PipelineStore:   c.Store.Pipelines,
+			ReleaseStore:    c.Store.Releases,
+			DeploymentStore: c.Store.Deployments,
+			IncidentStore:   c.Store.Incidents,
+			Logger:          c.Logger,
+		}
+	}
 
-	if err := c.pipelineActivityCollector.Start(ctx); err != nil {
-		return fmt.Errorf("failed to start PipelineActivity Collector: %w", err)
+	c.teamCollector = &TeamCollector{
+		JXClient:       c.JXClient,
+		Namespace:      c.Namespace,
+		ResyncInterval: c.ResyncInterval,
+		TeamLabel:      c.TeamLabel,
+		Filter:         c.Filter,
+		Store:          c.Store.Teams,
+		GitClient:      c.GitClient,
+		Logger:         c.Logger,
+	}
+	c.repositoryCollector = &RepositoryCollector{
+		JXClient:       c.JXClient,
+		Namespace:      c.Namespace,
+		ResyncInterval: c.ResyncInterval,
+		Filter:         c.Filter,
+		Store:          c.Store.Repositories,
+		Logger:         c.Logger,
+	}
+
+	if c.LighthouseClient != nil {
+		c.lighthouseJobCollector = &LighthouseJobCollector{
+			LighthouseClient: c.LighthouseClient,
+			Namespace:        c.Namespace,
+			ResyncInterval:   c.ResyncInterval,
+			Filter:           c.Filter,
+			Store:            c.Store.LighthouseJobs,
+			Logger:           c.Logger,
+		}
+	}
+	if len(c.WorkloadNamespaces) > 0 {
+		c.workloadCollector = &WorkloadCollector{
+			KubeClient:     c.KubeClient,
+			JXClient:       c.JXClient,
+			Namespace:      c.Namespace,
+			Namespaces:     c.WorkloadNamespaces,
+			ResyncInterval: c.ResyncInterval,
+			Filter:         c.Filter,
+			Store:          c.Store.Deployments,
+			Logger:         c.Logger,
+		}
+	}
+	if len(c.HelmNamespaces) > 0 {
+		c.helmReleaseCollector = &HelmReleaseCollector{
+			KubeClient:      c.KubeClient,
+			JXClient:        c.JXClient,
+			Namespace:       c.Namespace,
+			Namespaces:      c.HelmNamespaces,
+			ResyncInterval:  c.ResyncInterval,
+			Filter:          c.Filter,
+			Store:           c.Store.HelmReleases,
+			DeploymentStore: c.Store.Deployments,
+			Logger:          c.Logger,
+		}
+	}
+	if len(c.GitOpsSources) > 0 {
+		if _, err := gitOpsSourceResources(c.GitOpsSources); err != nil {
+			return err
+		}
+		c.gitOpsCollector = &GitOpsCollector{
+			DynamicClient:   c.DynamicClient,
+			JXClient:        c.JXClient,
+			Namespace:       c.Namespace,
+			Sources:         c.GitOpsSources,
+			ResyncInterval:  c.ResyncInterval,
+			Filter:          c.Filter,
+			Store:           c.Store.GitOpsSyncs,
+			DeploymentStore: c.Store.Deployments,
+			Logger:          c.Logger,
+		}
 	}
+
+	if c.Health != nil {
+		if c.pipelineActivityCollector != nil {
+			c.Health.Register("pipelineactivities-informer", c.pipelineActivityCollector.Ready)
+		}
+		if c.tektonCollector != nil {
+			c.Health.Register("pipelineruns-informer", c.tektonCollector.Ready)
+		}
+		c.Health.Register("releases-informer", c.releaseCollector.Ready)
+		c.Health.Register("sourcerepositories-informer", c.teamCollector.Ready)
+		c.Health.Register("repositories-informer", c.repositoryCollector.Ready)
+		if c.lighthouseJobCollector != nil {
+			c.Health.Register("lighthousejobs-informer", c.lighthouseJobCollector.Ready)
+		}
+		if c.workloadCollector != nil {
+			c.Health.Register("workloads-informer", c.workloadCollector.Ready)
+		}
+		if c.helmReleaseCollector != nil {
+			c.Health.Register("helm-releases-informer", c.helmReleaseCollector.Ready)
+		}
+		if c.gitOpsCollector != nil {
+			c.Health.Register("gitops-informer", c.gitOpsCollector.Ready)
+		}
+	}
+
 	if err := c.releaseCollector.Start(ctx); err != nil {
 		return fmt.Errorf("failed to start Release Collector: %w", err)
 	}
@@ -70,6 +244,66 @@ func (c *Collector) Start(ctx context.Context) error {
 	if err := c.deploymentCollector.Start(ctx); err != nil {
 		return fmt.Errorf("failed to start Deployment Collector: %w", err)
 	}
+	if c.cdEventCollector != nil {
+		if err := c.cdEventCollector.Start(ctx); err != nil {
+			return fmt.Errorf("failed to start CDEvent Collector: %w", err)
+		}
+	}
+
+	if c.LeaderElection == nil {
+		return c.startInformers(ctx)
+	}
+	go c.LeaderElection.Run(ctx, c.startInformers)
+
+	return nil
+}
+
+// startInformers starts the collectors watching Kubernetes resources, until the given context is done
+func (c *Collector) startInformers(ctx context.Context) error {
+	if c.pipelineActivityCollector != nil {
+		if err := c.pipelineActivityCollector.Start(ctx); err != nil {
+			return fmt.Errorf("failed to start PipelineActivity Collector: %w", err)
+		}
+	}
+	if c.tektonCollector != nil {
+		if err := c.tektonCollector.Start(ctx); err != nil {
+			return fmt.Errorf("failed to start Tekton Collector: %w", err)
+		}
+	}
+	if err := c.releaseCollector.StartInformer(ctx); err != nil {
+		return fmt.Errorf("failed to start Release Collector informer: %w", err)
+	}
+	if err := c.teamCollector.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start Team Collector: %w", err)
+	}
+	if err := c.repositoryCollector.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start Repository Collector: %w", err)
+	}
+	if c.lighthouseJobCollector != nil {
+		if err := c.lighthouseJobCollector.Start(ctx); err != nil {
+			return fmt.Errorf("failed to start LighthouseJob Collector: %w", err)
+		}
+	}
+	if c.workloadCollector != nil {
+		if err := c.workloadCollector.Start(ctx); err != nil {
+			return fmt.Errorf("failed to start Workload Collector: %w", err)
+		}
+	}
+	if c.helmReleaseCollector != nil {
+		if err := c.helmReleaseCollector.Start(ctx); err != nil {
+			return fmt.Errorf("failed to start HelmRelease Collector: %w", err)
+		}
+	}
+	if c.gitOpsCollector != nil {
+		if err := c.gitOpsCollector.Start(ctx); err != nil {
+			return fmt.Errorf("failed to start GitOps Collector: %w", err)
+		}
+	}
+	if c.RetentionJob != nil {
+		if err := c.RetentionJob.Start(ctx); err != nil {
+			return fmt.Errorf("failed to start the retention job: %w", err)
+		}
+	}
 
 	return nil
 }
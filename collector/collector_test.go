@@ -0,0 +1,18 @@
+package collector
+
+import (
+	"testing"
+	"time"
+)
+
+// waitFor waits until the condition - typically on the entities stored by an informer - is true, or fails the test
+func waitFor(t *testing.T, description string, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", description)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
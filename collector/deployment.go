@@ -7,13 +7,12 @@ import (
 	"github.com/jenkins-x/cd-indicators/internal/lighthouse"
 	"github.com/jenkins-x/cd-indicators/store"
 	"github.com/jenkins-x/go-scm/scm"
-	"github.com/scylladb/go-set/strset"
 	"github.com/sirupsen/logrus"
 )
 
 type DeploymentCollector struct {
-	GitOwners         *strset.Set
-	Store             *store.DeploymentStore
+	Filter            *Filter
+	Store             store.DeploymentStore
 	LighthouseHandler *lighthouse.Handler
 	Logger            *logrus.Logger
 }
@@ -38,20 +37,17 @@ func (c *DeploymentCollector) handleWebhook(webhook scm.Webhook) error {
 }
 
 func (c *DeploymentCollector) storeDeployment(deployment scm.Deployment, status scm.DeploymentStatus) error {
-	if !c.GitOwners.IsEmpty() && !c.GitOwners.Has(deployment.Namespace) {
-		c.Logger.
-			WithField("owner", deployment.Namespace).
-			WithField("allowed-owners", c.GitOwners.String()).
-			Debug("Ignoring Deployment with not-allowed git owner")
+	if !c.Filter.AllowsRepository(deployment.Namespace, deployment.Name) {
 		return nil
 	}
 
 	d := store.Deployment{
-		Owner:          deployment.Namespace,
-		Repository:     deployment.Name,
-		Version:        strings.TrimPrefix(deployment.Ref, "v"),
-		Environment:    deployment.Environment,
-		DeploymentTime: status.Created,
+		Owner:             deployment.Namespace,
+		Repository:        deployment.Name,
+		Version:           strings.TrimPrefix(deployment.Ref, "v"),
+		Environment:       c.Filter.Environment(deployment.Namespace, deployment.Name, deployment.Environment),
+		DeploymentTime:    status.Created,
+		SourceEnvironment: deployment.Environment,
 	}
 
 	c.Logger.WithField("deployment", d.String()).Debugf("Storing deployment %#v", d)
@@ -0,0 +1,125 @@
+package collector
+
+import (
+	"time"
+
+	"github.com/jenkins-x/cd-indicators/internal/config"
+	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/scylladb/go-set/strset"
+	"github.com/sirupsen/logrus"
+)
+
+// Filter applies the configuration rules shared by all the collectors.
+// The configuration is retrieved on each call, so that it can be reloaded at any time.
+type Filter struct {
+	Config func() *config.Config
+	// Identities returns the identities managed through the API. It is optional
+	Identities func() []store.Identity
+	Logger     *logrus.Logger
+}
+
+// AllowsRepository returns true if the indicators of the given repository should be collected
+func (f *Filter) AllowsRepository(owner, repository string) bool {
+	if f.Config().AllowsRepository(owner, repository) {
+		return true
+	}
+	f.Logger.
+		WithField("owner", owner).
+		WithField("repository", repository).
+		Debug("Ignoring not-allowed repository")
+	return false
+}
+
+// IsBot returns true if the given git user is a bot
+func (f *Filter) IsBot(login string) bool {
+	return f.Config().IsBot(login)
+}
+
+// Environment returns the canonical name of the given environment
+func (f *Filter) Environment(owner, repository, environment string) string {
+	return f.Config().Environment(owner, repository, environment)
+}
+
+// PipelineFromEvent returns the pipeline rebuilt from a pipeline event, with the current canonical person of its author - see store.Rebuilder
+func (f *Filter) PipelineFromEvent(p store.Pipeline) store.Pipeline {
+	p.Author = f.Person(p.AuthorLogin)
+	p.IsBot = f.IsBot(p.AuthorLogin)
+	p.Duration = p.EndTime.Sub(p.StartTime)
+	return p
+}
+
+// DeploymentFromEvent returns the deployment rebuilt from a deployment event, with the current alias of its environment - see store.Rebuilder
+func (f *Filter) DeploymentFromEvent(d store.Deployment) store.Deployment {
+	d.Environment = f.Environment(d.Owner, d.Repository, d.SourceEnvironment)
+	return d
+}
+
+// EnvironmentRepository returns the environment repository matching the given repository, if any
+func (f *Filter) EnvironmentRepository(owner, repository string) (config.EnvironmentRepository, bool) {
+	return f.Config().EnvironmentRepository(owner, repository)
+}
+
+// IsApprovalLabel returns true if the given pull request label is an approval signal
+func (f *Filter) IsApprovalLabel(label string) bool {
+	return f.Config().IsApprovalLabel(label)
+}
+
+// Identity returns the identity of the given git user login or commit email.
+// The identities of the config file take precedence over the ones managed through the API.
+func (f *Filter) Identity(login string) (store.Identity, bool) {
+	identities := f.Config().Identities
+	if f.Identities != nil {
+		identities = append(identities[:len(identities):len(identities)], f.Identities()...)
+	}
+	for _, identity := range identities {
+		if identity.Matches(login) {
+			return identity, true
+		}
+	}
+	return store.Identity{}, false
+}
+
+// Person returns the canonical person of the given git user login, or the login itself if it has no identity
+func (f *Filter) Person(login string) string {
+	if identity, ok := f.Identity(login); ok {
+		return identity.Person
+	}
+	return login
+}
+
+// Persons returns the canonical persons of the given git user logins, without duplicates
+func (f *Filter) Persons(logins []string) []string {
+	if logins == nil {
+		return nil
+	}
+	persons := strset.New()
+	for _, login := range logins {
+		persons.Add(f.Person(login))
+	}
+	return persons.List()
+}
+
+// ApprovalRule returns the rule for an approving review submitted at the given time, on a pull request changing the given files,
+// or nil if the reviews are not an approval signal
+func (f *Filter) ApprovalRule(owner, repository string, changedPaths []string, reviewTime time.Time) *store.ApprovalRule {
+	cfg := f.Config()
+	if !cfg.Approval.Reviews {
+		return nil
+	}
+	var requiredApprovers [][]string
+	for _, approvers := range cfg.RequiredApprovers(owner, repository, changedPaths) {
+		requiredApprovers = append(requiredApprovers, f.Persons(approvers))
+	}
+	return &store.ApprovalRule{
+		MinApprovals:      cfg.Approval.MinApprovals,
+		RequiredApprovers: requiredApprovers,
+		Time:              reviewTime,
+	}
+}
+
+// HasPathApprovers returns true if the approving reviews are an approval signal,
+// and some required approvers of the given repository depend on the changed files
+func (f *Filter) HasPathApprovers(owner, repository string) bool {
+	cfg := f.Config()
+	return cfg.Approval.Reviews && cfg.HasPathApprovers(owner, repository)
+}
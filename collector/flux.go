@@ -0,0 +1,120 @@
+package collector
+
+import (
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/cd-indicators/store"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// fluxProgressingReasons are the reasons of a Ready condition which is not true yet, while the reconciliation goes on
+var fluxProgressingReasons = map[string]bool{
+	"Progressing":        true,
+	"DependencyNotReady": true,
+}
+
+// fluxHelmReleaseSync returns the outcome of the last reconciliation of a Flux HelmRelease, from its Ready condition.
+// The revision is the version of the chart.
+func fluxHelmReleaseSync(u *unstructured.Unstructured) (gitOpsSync, bool) {
+	status, message, syncTime, ok := fluxReadyCondition(u)
+	if !ok {
+		return gitOpsSync{}, false
+	}
+
+	revision, _, _ := unstructured.NestedString(u.Object, "status", "lastAttemptedRevision")
+	if revision == "" {
+		if history, _, _ := unstructured.NestedSlice(u.Object, "status", "history"); len(history) > 0 {
+			if snapshot, ok := history[0].(map[string]interface{}); ok {
+				revision, _, _ = unstructured.NestedString(snapshot, "chartVersion")
+			}
+		}
+	}
+	chart, _, _ := unstructured.NestedString(u.Object, "spec", "chart", "spec", "chart")
+
+	applications := []string{u.GetName()}
+	if chart != "" && chart != u.GetName() {
+		applications = append(applications, chart)
+	}
+	return gitOpsSync{
+		Applications: applications,
+		Namespace:    fluxTargetNamespace(u),
+		Revision:     revision,
+		Version:      gitOpsVersion(u, revision),
+		Status:       status,
+		Message:      message,
+		Time:         syncTime,
+	}, true
+}
+
+// fluxKustomizationSync returns the outcome of the last reconciliation of a Flux Kustomization, from its Ready condition.
+// The revision is the one of its source, such as "main@sha1:<commit>": without a version label, the version is the commit.
+func fluxKustomizationSync(u *unstructured.Unstructured) (gitOpsSync, bool) {
+	status, message, syncTime, ok := fluxReadyCondition(u)
+	if !ok {
+		return gitOpsSync{}, false
+	}
+
+	field := "lastAttemptedRevision"
+	if status == store.GitOpsSyncStatusSucceeded {
+		field = "lastAppliedRevision"
+	}
+	revision, _, _ := unstructured.NestedString(u.Object, "status", field)
+	// the commit follows the algorithm of its digest, or the branch in the older revisions such as "main/<commit>"
+	commit := revision
+	if i := strings.LastIndexAny(commit, ":/"); i >= 0 {
+		commit = commit[i+1:]
+	}
+
+	return gitOpsSync{
+		Applications: []string{u.GetName()},
+		Namespace:    fluxTargetNamespace(u),
+		Revision:     revision,
+		Version:      gitOpsVersion(u, commit),
+		Status:       status,
+		Message:      message,
+		Time:         syncTime,
+	}, true
+}
+
+// fluxReadyCondition returns the outcome of the last reconciliation of a Flux resource from its Ready condition,
+// or false while the resource is reconciling a new generation - or still progressing
+func fluxReadyCondition(u *unstructured.Unstructured) (status, message string, syncTime time.Time, ok bool) {
+	observedGeneration, _, _ := unstructured.NestedInt64(u.Object, "status", "observedGeneration")
+	if observedGeneration < u.GetGeneration() {
+		return "", "", syncTime, false
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(u.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, isMap := c.(map[string]interface{})
+		if !isMap {
+			continue
+		}
+		if conditionType, _, _ := unstructured.NestedString(condition, "type"); conditionType != "Ready" {
+			continue
+		}
+		conditionStatus, _, _ := unstructured.NestedString(condition, "status")
+		reason, _, _ := unstructured.NestedString(condition, "reason")
+		message, _, _ = unstructured.NestedString(condition, "message")
+		lastTransitionTime, _, _ := unstructured.NestedString(condition, "lastTransitionTime")
+		syncTime = parseGitOpsTime(lastTransitionTime)
+
+		switch {
+		case conditionStatus == "True":
+			return store.GitOpsSyncStatusSucceeded, message, syncTime, true
+		case conditionStatus == "False" && !fluxProgressingReasons[reason]:
+			return store.GitOpsSyncStatusFailed, message, syncTime, true
+		}
+		return "", "", syncTime, false
+	}
+	return "", "", syncTime, false
+}
+
+// fluxTargetNamespace returns the namespace to which a Flux resource is applied
+func fluxTargetNamespace(u *unstructured.Unstructured) string {
+	if namespace, _, _ := unstructured.NestedString(u.Object, "spec", "targetNamespace"); namespace != "" {
+		return namespace
+	}
+	return u.GetNamespace()
+}
@@ -0,0 +1,269 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/cd-indicators/store"
+	jxclientset "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned"
+	jxinformers "github.com/jenkins-x/jx-api/v4/pkg/client/informers/externalversions"
+	listers "github.com/jenkins-x/jx-api/v4/pkg/client/listers/jenkins.io/v1"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+)
+
+// The GitOps tools from which the syncs can be collected
+const (
+	GitOpsSourceArgoCD = "argocd"
+	GitOpsSourceFlux   = "flux"
+)
+
+// gitOpsResource is a kind of resource of a GitOps tool, with the function reading the outcome of its last sync
+type gitOpsResource struct {
+	Tool     string
+	Kind     string
+	Resource schema.GroupVersionResource
+	// Sync returns the outcome of the last sync of the resource, or false if there is none yet - or it is still running
+	Sync func(u *unstructured.Unstructured) (gitOpsSync, bool)
+}
+
+// gitOpsResources are the watched resources of each GitOps tool
+var gitOpsResources = map[string][]gitOpsResource{
+	GitOpsSourceArgoCD: {
+		{
+			Tool:     GitOpsSourceArgoCD,
+			Kind:     "Application",
+			Resource: schema.GroupVersionResource{Group: "argoproj.io", Version: "v1alpha1", Resource: "applications"},
+			Sync:     argoCDApplicationSync,
+		},
+	},
+	GitOpsSourceFlux: {
+		{
+			Tool:     GitOpsSourceFlux,
+			Kind:     "HelmRelease",
+			Resource: schema.GroupVersionResource{Group: "helm.toolkit.fluxcd.io", Version: "v2", Resource: "helmreleases"},
+			Sync:     fluxHelmReleaseSync,
+		},
+		{
+			Tool:     GitOpsSourceFlux,
+			Kind:     "Kustomization",
+			Resource: schema.GroupVersionResource{Group: "kustomize.toolkit.fluxcd.io", Version: "v1", Resource: "kustomizations"},
+			Sync:     fluxKustomizationSync,
+		},
+	},
+}
+
+// GitOpsSources returns the names of the GitOps tools from which the syncs can be collected
+func GitOpsSources() []string {
+	return []string{GitOpsSourceArgoCD, GitOpsSourceFlux}
+}
+
+// gitOpsSourceResources returns the resources watched for the given GitOps tools
+func gitOpsSourceResources(sources []string) ([]gitOpsResource, error) {
+	var resources []gitOpsResource
+	for _, source := range sources {
+		r, ok := gitOpsResources[source]
+		if !ok {
+			return nil, fmt.Errorf("unknown GitOps source %q: must be one of %s", source, strings.Join(GitOpsSources(), ", "))
+		}
+		resources = append(resources, r...)
+	}
+	return resources, nil
+}
+
+// gitOpsSync is the outcome of the last sync of a resource of a GitOps tool
+type gitOpsSync struct {
+	// Applications are the candidate names of the synced application - such as the resource and chart names - in order,
+	// used to find its SourceRepository
+	Applications []string
+	// Namespace is the namespace to which the application is synced: the namespace of its environment
+	Namespace string
+	Revision  string
+	Version   string
+	Status    string
+	Message   string
+	Time      time.Time
+}
+
+// GitOpsCollector collects the syncs of the revisions by Argo CD and Flux, from the status of their resources:
+// the Argo CD Applications, and the Flux HelmReleases and Kustomizations.
+// The succeeded syncs are also stored as deployments, in the environment of their destination namespace.
+// The application is mapped to the SourceRepository of the same name - or of the name of its chart.
+type GitOpsCollector struct {
+	DynamicClient dynamic.Interface
+	JXClient      jxclientset.Interface
+	// Namespace is the namespace of the SourceRepositories
+	Namespace string
+	// Sources are the GitOps tools whose resources are watched, in all the namespaces
+	Sources         []string
+	ResyncInterval  time.Duration
+	Filter          *Filter
+	Store           store.GitOpsSyncStore
+	DeploymentStore store.DeploymentStore
+	Logger          *logrus.Logger
+
+	sourceRepositories listers.SourceRepositoryLister
+	informerStatus     informerStatus
+}
+
+// Start collects the syncs of the GitOps resources, until the given context is done
+func (c *GitOpsCollector) Start(ctx context.Context) error {
+	resources, err := gitOpsSourceResources(c.Sources)
+	if err != nil {
+		return err
+	}
+
+	jxInformerFactory := jxinformers.NewSharedInformerFactoryWithOptions(
+		c.JXClient,
+		c.ResyncInterval,
+		jxinformers.WithNamespace(c.Namespace),
+	)
+	sourceRepositoryInformer := jxInformerFactory.Jenkins().V1().SourceRepositories()
+	c.sourceRepositories = sourceRepositoryInformer.Lister()
+	// register the informer before starting the factory
+	sourceRepositoryInformer.Informer()
+	jxInformerFactory.Start(ctx.Done())
+
+	dynamicInformerFactory := dynamicinformer.NewDynamicSharedInformerFactory(c.DynamicClient, c.ResyncInterval)
+	for _, resource := range resources {
+		resource := resource
+		_, err := dynamicInformerFactory.ForResource(resource.Resource).Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				c.storeSync(ctx, resource, obj)
+			},
+			UpdateFunc: func(old, new interface{}) {
+				c.storeSync(ctx, resource, new)
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to add the event handler of the %s %ss: %w", resource.Tool, resource.Kind, err)
+		}
+	}
+	dynamicInformerFactory.Start(ctx.Done())
+	c.informerStatus.track(ctx, waitForCacheSyncs(jxInformerFactory.WaitForCacheSync, func(stopCh <-chan struct{}) map[reflect.Type]bool {
+		// the dynamic informers are all of the same type: they are synced together
+		synced := true
+		for _, ok := range dynamicInformerFactory.WaitForCacheSync(stopCh) {
+			synced = synced && ok
+		}
+		return map[reflect.Type]bool{reflect.TypeOf(&unstructured.Unstructured{}): synced}
+	}))
+
+	return nil
+}
+
+// Ready returns an error while the informer caches are not synced
+func (c *GitOpsCollector) Ready(ctx context.Context) error {
+	return c.informerStatus.Check(ctx)
+}
+
+func (c *GitOpsCollector) storeSync(ctx context.Context, resource gitOpsResource, obj interface{}) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return
+	}
+
+	log := c.Logger.WithField("tool", resource.Tool).WithField("kind", resource.Kind).
+		WithField("namespace", u.GetNamespace()).WithField("name", u.GetName())
+	s, ok := resource.Sync(u)
+	if !ok {
+		log.Trace("Ignoring GitOps resource with no finished sync")
+		return
+	}
+	if s.Revision == "" || s.Namespace == "" || s.Time.IsZero() {
+		log.Trace("Ignoring GitOps sync with no revision, destination namespace or time")
+		return
+	}
+	log = log.WithField("revision", s.Revision)
+
+	var owner, repository string
+	for _, application := range s.Applications {
+		if o, ok := c.owner(application); ok {
+			owner, repository = o, application
+			break
+		}
+	}
+	if owner == "" {
+		log.Trace("Ignoring GitOps sync with no single SourceRepository of the same name")
+		return
+	}
+	if !c.Filter.AllowsRepository(owner, repository) {
+		return
+	}
+
+	sync := store.GitOpsSync{
+		Tool:        resource.Tool,
+		Kind:        resource.Kind,
+		Namespace:   u.GetNamespace(),
+		Name:        u.GetName(),
+		Owner:       owner,
+		Repository:  repository,
+		Environment: c.Filter.Environment(owner, repository, s.Namespace),
+		Revision:    s.Revision,
+		Version:     s.Version,
+		Status:      s.Status,
+		Message:     s.Message,
+		SyncTime:    s.Time.In(time.UTC),
+	}
+	log.WithField("status", sync.Status).Debug("Storing GitOps sync")
+	if err := c.Store.Add(ctx, sync); err != nil {
+		log.WithError(err).Error("Failed to store GitOps sync")
+		return
+	}
+
+	if !sync.Succeeded() {
+		return
+	}
+	d := store.Deployment{
+		Owner:             sync.Owner,
+		Repository:        sync.Repository,
+		Version:           sync.Version,
+		Environment:       sync.Environment,
+		DeploymentTime:    sync.SyncTime,
+		SourceEnvironment: s.Namespace,
+	}
+	log.WithField("deployment", d.String()).Debug("Storing deployment")
+	if err := c.DeploymentStore.Add(ctx, d); err != nil {
+		log.WithError(err).Error("Failed to store deployment")
+	}
+}
+
+// owner returns the owner of the SourceRepository of the given name, if there is exactly one
+func (c *GitOpsCollector) owner(repository string) (string, bool) {
+	if repository == "" {
+		return "", false
+	}
+	sourceRepositories, err := c.sourceRepositories.SourceRepositories(c.Namespace).List(labels.Everything())
+	if err != nil {
+		c.Logger.WithError(err).Error("Failed to list the SourceRepositories")
+		return "", false
+	}
+	owner := sourceRepositoryOwner(sourceRepositories, repository)
+	return owner, owner != ""
+}
+
+// gitOpsVersion returns the version of a synced revision: the version label of the resource if any,
+// else the revision itself - a chart version, or a git commit
+func gitOpsVersion(u *unstructured.Unstructured, revision string) string {
+	if version := labelValue(u.GetLabels(), workloadVersionLabels); version != "" {
+		return strings.TrimPrefix(version, "v")
+	}
+	return strings.TrimPrefix(revision, "v")
+}
+
+// parseGitOpsTime parses a time of the status of a GitOps resource, returning the zero time if it is invalid
+func parseGitOpsTime(value string) time.Time {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
@@ -0,0 +1,263 @@
+package collector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/cd-indicators/internal/config"
+	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/jenkins-x/cd-indicators/store/memory"
+	jxfake "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func testGitOpsResource(apiVersion, kind, namespace, name string, object map[string]interface{}) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: object}
+	u.SetAPIVersion(apiVersion)
+	u.SetKind(kind)
+	u.SetNamespace(namespace)
+	u.SetName(name)
+	return u
+}
+
+func testArgoCDApplication(name, phase, health, revision, finishedAt string) *unstructured.Unstructured {
+	return testGitOpsResource("argoproj.io/v1alpha1", "Application", "argocd", name, map[string]interface{}{
+		"spec": map[string]interface{}{
+			"source":      map[string]interface{}{"repoURL": "https://charts.example.com", "chart": "app"},
+			"destination": map[string]interface{}{"namespace": "jx-staging"},
+		},
+		"status": map[string]interface{}{
+			"health": map[string]interface{}{"status": health, "message": "Deployment app exceeded its progress deadline"},
+			"operationState": map[string]interface{}{
+				"phase":      phase,
+				"message":    "successfully synced (all tasks run)",
+				"finishedAt": finishedAt,
+				"syncResult": map[string]interface{}{"revision": revision},
+			},
+		},
+	})
+}
+
+func testFluxReadyCondition(status, reason, message, lastTransitionTime string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":               "Ready",
+		"status":             status,
+		"reason":             reason,
+		"message":            message,
+		"lastTransitionTime": lastTransitionTime,
+	}
+}
+
+func TestGitOpsCollectorStoresTheSyncs(t *testing.T) {
+	var (
+		syncTime      = time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+		syncTimeValue = syncTime.Format(time.RFC3339)
+	)
+	resources := []runtime.Object{
+		// named after its chart: the SourceRepository of the chart is used
+		testArgoCDApplication("app-staging", "Succeeded", "Healthy", "v1.2.3", syncTimeValue),
+		// the sync succeeded, but the application is degraded
+		testArgoCDApplication("api", "Succeeded", "Degraded", "2.0.0", syncTimeValue),
+		// the sync operation failed
+		testArgoCDApplication("batch", "Failed", "Healthy", "1.1.0", syncTimeValue),
+		// ignored: still progressing
+		testArgoCDApplication("web", "Succeeded", "Progressing", "3.0.0", syncTimeValue),
+		testGitOpsResource("helm.toolkit.fluxcd.io/v2", "HelmRelease", "flux-system", "worker", map[string]interface{}{
+			"spec": map[string]interface{}{
+				"targetNamespace": "jx-production",
+				"chart":           map[string]interface{}{"spec": map[string]interface{}{"chart": "worker"}},
+			},
+			"status": map[string]interface{}{
+				"lastAttemptedRevision": "4.5.6",
+				"conditions": []interface{}{
+					testFluxReadyCondition("True", "InstallSucceeded", "Helm install succeeded", syncTimeValue),
+				},
+			},
+		}),
+		testGitOpsResource("kustomize.toolkit.fluxcd.io/v1", "Kustomization", "jx-production", "infra", map[string]interface{}{
+			"status": map[string]interface{}{
+				"lastAppliedRevision":   "main@sha1:1111111",
+				"lastAttemptedRevision": "main@sha1:2222222",
+				"conditions": []interface{}{
+					testFluxReadyCondition("False", "ReconciliationFailed", "kustomize build failed", syncTimeValue),
+				},
+			},
+		}),
+		// ignored: still progressing
+		testGitOpsResource("kustomize.toolkit.fluxcd.io/v1", "Kustomization", "jx-production", "docs", map[string]interface{}{
+			"status": map[string]interface{}{
+				"lastAttemptedRevision": "main@sha1:3333333",
+				"conditions": []interface{}{
+					testFluxReadyCondition("False", "Progressing", "Reconciliation in progress", syncTimeValue),
+				},
+			},
+		}),
+	}
+	scheme := runtime.NewScheme()
+	listKinds := map[schema.GroupVersionResource]string{}
+	for _, resource := range append(gitOpsResources[GitOpsSourceArgoCD], gitOpsResources[GitOpsSourceFlux]...) {
+		listKinds[resource.Resource] = resource.Kind + "List"
+	}
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(scheme, listKinds, resources...)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := memory.New()
+	c := &GitOpsCollector{
+		DynamicClient: dynamicClient,
+		JXClient: jxfake.NewSimpleClientset(
+			testSourceRepository("org", "app"),
+			testSourceRepository("org", "api"),
+			testSourceRepository("org", "batch"),
+			testSourceRepository("org", "web"),
+			testSourceRepository("org", "worker"),
+			testSourceRepository("org", "infra"),
+			testSourceRepository("org", "docs"),
+		),
+		Namespace: "jx",
+		Sources:   GitOpsSources(),
+		Filter: newTestFilter(&config.Config{
+			Environments: []config.EnvironmentAliases{{Aliases: map[string][]string{
+				"staging":    {"jx-staging"},
+				"production": {"jx-production"},
+			}}},
+		}),
+		Store:           s.GitOpsSyncs,
+		DeploymentStore: s.Deployments,
+		Logger:          logrus.New(),
+	}
+	if err := c.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the informer caches", func() bool { return c.Ready(ctx) == nil })
+
+	// the failed sync of the Kustomization is retried successfully
+	kustomizations := dynamicClient.Resource(gitOpsResources[GitOpsSourceFlux][1].Resource).Namespace("jx-production")
+	kustomization, err := kustomizations.Get(ctx, "infra", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	retryTime := syncTime.Add(time.Hour)
+	if err := unstructured.SetNestedField(kustomization.Object, "main@sha1:2222222", "status", "lastAppliedRevision"); err != nil {
+		t.Fatal(err)
+	}
+	if err := unstructured.SetNestedSlice(kustomization.Object, []interface{}{
+		testFluxReadyCondition("True", "ReconciliationSucceeded", "Applied revision: main@sha1:2222222", retryTime.Format(time.RFC3339)),
+	}, "status", "conditions"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kustomizations.Update(ctx, kustomization, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	gitOpsSyncStore := s.GitOpsSyncs.(*memory.GitOpsSyncStore)
+	syncs := map[string]store.GitOpsSync{}
+	waitFor(t, "the GitOps syncs", func() bool {
+		for _, sync := range gitOpsSyncStore.List() {
+			syncs[sync.Name] = sync
+		}
+		return len(syncs) == 5 && syncs["infra"].Succeeded()
+	})
+
+	app := syncs["app-staging"]
+	if app.Tool != GitOpsSourceArgoCD || app.Kind != "Application" || app.Owner != "org" || app.Repository != "app" {
+		t.Errorf("expected the Argo CD Application of org/app, got %+v", app)
+	}
+	if !app.Succeeded() || app.Version != "1.2.3" || app.Environment != "staging" || !app.SyncTime.Equal(syncTime) {
+		t.Errorf("expected a succeeded sync of 1.2.3 to staging at %s, got %+v", syncTime, app)
+	}
+	api := syncs["api"]
+	if api.Status != store.GitOpsSyncStatusFailed || api.Message != "Deployment app exceeded its progress deadline" {
+		t.Errorf("expected the sync of the degraded Application to fail with its health message, got %s %q", api.Status, api.Message)
+	}
+	if batch := syncs["batch"]; batch.Status != store.GitOpsSyncStatusFailed || batch.Version != "1.1.0" {
+		t.Errorf("expected the failed sync operation of batch 1.1.0 to fail, got %s %q", batch.Status, batch.Version)
+	}
+	worker := syncs["worker"]
+	if worker.Tool != GitOpsSourceFlux || worker.Kind != "HelmRelease" || !worker.Succeeded() || worker.Version != "4.5.6" || worker.Environment != "production" {
+		t.Errorf("expected a succeeded sync of the HelmRelease worker 4.5.6 to production, got %+v", worker)
+	}
+	infra := syncs["infra"]
+	if infra.Kind != "Kustomization" || infra.Revision != "main@sha1:2222222" || infra.Version != "2222222" || !infra.SyncTime.Equal(retryTime) {
+		t.Errorf("expected the retried sync of the Kustomization infra at %s, got %+v", retryTime, infra)
+	}
+
+	deployments := map[string]store.Deployment{}
+	waitFor(t, "the deployments of the succeeded syncs", func() bool {
+		for _, d := range s.Deployments.(*memory.DeploymentStore).List() {
+			deployments[d.Repository] = d
+		}
+		return len(deployments) == 3
+	})
+	if d := deployments["app"]; d.Version != "1.2.3" || d.Environment != "staging" {
+		t.Errorf("expected app 1.2.3 to be deployed to staging, got %+v", d)
+	}
+	for _, failed := range []string{"api", "batch"} {
+		if _, found := deployments[failed]; found {
+			t.Errorf("expected the failed sync of %s not to be a deployment", failed)
+		}
+	}
+}
+
+func TestFluxReadyCondition(t *testing.T) {
+	tests := []struct {
+		name           string
+		generation     int64
+		observed       int64
+		condition      map[string]interface{}
+		expectedStatus string
+		expectedOK     bool
+	}{
+		{
+			name:           "ready",
+			condition:      testFluxReadyCondition("True", "ReconciliationSucceeded", "", ""),
+			expectedStatus: store.GitOpsSyncStatusSucceeded,
+			expectedOK:     true,
+		},
+		{
+			name:           "failed",
+			condition:      testFluxReadyCondition("False", "UpgradeFailed", "", ""),
+			expectedStatus: store.GitOpsSyncStatusFailed,
+			expectedOK:     true,
+		},
+		{
+			name:      "progressing",
+			condition: testFluxReadyCondition("False", "Progressing", "", ""),
+		},
+		{
+			name:      "waiting for a dependency",
+			condition: testFluxReadyCondition("False", "DependencyNotReady", "", ""),
+		},
+		{
+			name:      "unknown",
+			condition: testFluxReadyCondition("Unknown", "Progressing", "", ""),
+		},
+		{
+			name:       "new generation not reconciled yet",
+			generation: 2,
+			observed:   1,
+			condition:  testFluxReadyCondition("True", "ReconciliationSucceeded", "", ""),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			u := testGitOpsResource("kustomize.toolkit.fluxcd.io/v1", "Kustomization", "flux-system", "app", map[string]interface{}{
+				"status": map[string]interface{}{
+					"observedGeneration": test.observed,
+					"conditions":         []interface{}{test.condition},
+				},
+			})
+			u.SetGeneration(test.generation)
+			status, _, _, ok := fluxReadyCondition(u)
+			if status != test.expectedStatus || ok != test.expectedOK {
+				t.Errorf("expected %q (%v), got %q (%v)", test.expectedStatus, test.expectedOK, status, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package collector
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/cd-indicators/store"
+	jxclientset "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned"
+	jxinformers "github.com/jenkins-x/jx-api/v4/pkg/client/informers/externalversions"
+	listers "github.com/jenkins-x/jx-api/v4/pkg/client/listers/jenkins.io/v1"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	kubeinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// helmReleaseSecretType is the type of the Secrets in which Helm 3 stores the revisions of the releases
+	helmReleaseSecretType = "helm.sh/release.v1"
+	// helmReleaseSecretSelector selects the Secrets of Helm, which are labelled with their owner
+	helmReleaseSecretSelector = "owner=helm"
+	// helmRollbackDescription is the description prefix of the revisions created by "helm rollback"
+	helmRollbackDescription = "Rollback to "
+)
+
+// helmRelease is the part of a revision stored by Helm which is collected
+type helmRelease struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Version   int    `json:"version"`
+	Info      struct {
+		FirstDeployed string `json:"first_deployed"`
+		LastDeployed  string `json:"last_deployed"`
+		Description   string `json:"description"`
+		Status        string `json:"status"`
+	} `json:"info"`
+	Chart struct {
+		Metadata struct {
+			Name       string `json:"name"`
+			Version    string `json:"version"`
+			AppVersion string `json:"appVersion"`
+		} `json:"metadata"`
+	} `json:"chart"`
+}
+
+// HelmReleaseCollector collects the revisions of the Helm releases from the Secrets in which Helm stores them,
+// in the namespaces of the environments - such as the ones deployed by helmfile in jx3.
+// The succeeded revisions are also stored as deployments.
+// The release is mapped to the SourceRepository of the same name - or of the name of its chart.
+type HelmReleaseCollector struct {
+	KubeClient kubernetes.Interface
+	JXClient   *jxclientset.Clientset
+	// Namespace is the namespace of the SourceRepositories
+	Namespace string
+	// Namespaces maps the watched namespaces to their environment
+	Namespaces      map[string]string
+	ResyncInterval  time.Duration
+	Filter          *Filter
+	Store           store.HelmReleaseStore
+	DeploymentStore store.DeploymentStore
+	Logger          *logrus.Logger
+
+	sourceRepositories listers.SourceRepositoryLister
+	informerStatus     informerStatus
+}
+
+// Start collects the Helm release Secrets, until the given context is done
+func (c *HelmReleaseCollector) Start(ctx context.Context) error { // nolint: unparam
+	jxInformerFactory := jxinformers.NewSharedInformerFactoryWithOptions(
+		c.JXClient,
+		c.ResyncInterval,
+		jxinformers.WithNamespace(c.Namespace),
+	)
+	sourceRepositoryInformer := jxInformerFactory.Jenkins().V1().SourceRepositories()
+	c.sourceRepositories = sourceRepositoryInformer.Lister()
+	// register the informer before starting the factory
+	sourceRepositoryInformer.Informer()
+	jxInformerFactory.Start(ctx.Done())
+	syncFuncs := []func(stopCh <-chan struct{}) map[reflect.Type]bool{jxInformerFactory.WaitForCacheSync}
+
+	for namespace, environment := range c.Namespaces {
+		informerFactory := kubeinformers.NewSharedInformerFactoryWithOptions(
+			c.KubeClient,
+			c.ResyncInterval,
+			kubeinformers.WithNamespace(namespace),
+			kubeinformers.WithTweakListOptions(func(options *metav1.ListOptions) {
+				options.LabelSelector = helmReleaseSecretSelector
+			}),
+		)
+		informerFactory.Core().V1().Secrets().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				secret := obj.(*corev1.Secret)
+				c.storeRelease(ctx, secret, environment)
+			},
+			UpdateFunc: func(old, new interface{}) {
+				secret := new.(*corev1.Secret)
+				c.storeRelease(ctx, secret, environment)
+			},
+		})
+		informerFactory.Start(ctx.Done())
+		syncFuncs = append(syncFuncs, informerFactory.WaitForCacheSync)
+	}
+	c.informerStatus.track(ctx, waitForCacheSyncs(syncFuncs...))
+
+	return nil
+}
+
+// Ready returns an error while the informer caches are not synced
+func (c *HelmReleaseCollector) Ready(ctx context.Context) error {
+	return c.informerStatus.Check(ctx)
+}
+
+func (c *HelmReleaseCollector) storeRelease(ctx context.Context, secret *corev1.Secret, environment string) {
+	if secret == nil || secret.Type != helmReleaseSecretType {
+		return
+	}
+
+	log := c.Logger.WithField("namespace", secret.Namespace).WithField("secret", secret.Name)
+	hr, err := decodeHelmRelease(secret.Data["release"])
+	if err != nil {
+		log.WithError(err).Error("Can't collect a Helm release Secret which can't be decoded")
+		return
+	}
+	log = log.WithField("release", hr.Name).WithField("revision", hr.Version)
+
+	switch hr.Info.Status {
+	case store.HelmReleaseStatusDeployed, store.HelmReleaseStatusSuperseded, store.HelmReleaseStatusFailed:
+	default:
+		log.WithField("status", hr.Info.Status).Trace("Ignoring Helm release revision which is not done")
+		return
+	}
+	deploymentTime, err := helmReleaseTime(hr)
+	if err != nil {
+		log.WithError(err).Error("Can't collect a Helm release revision with an invalid deployment time")
+		return
+	}
+
+	repository := hr.Name
+	owner, ok := c.owner(repository)
+	if !ok && hr.Chart.Metadata.Name != hr.Name {
+		repository = hr.Chart.Metadata.Name
+		owner, ok = c.owner(repository)
+	}
+	if !ok {
+		log.Trace("Ignoring Helm release with no single SourceRepository of the same name")
+		return
+	}
+	if !c.Filter.AllowsRepository(owner, repository) {
+		return
+	}
+
+	namespace := hr.Namespace
+	if namespace == "" {
+		namespace = secret.Namespace
+	}
+	release := store.HelmRelease{
+		Namespace:      namespace,
+		Name:           hr.Name,
+		Revision:       hr.Version,
+		Owner:          owner,
+		Repository:     repository,
+		Environment:    c.Filter.Environment(owner, repository, environment),
+		Chart:          hr.Chart.Metadata.Name,
+		Version:        strings.TrimPrefix(hr.Chart.Metadata.Version, "v"),
+		AppVersion:     strings.TrimPrefix(hr.Chart.Metadata.AppVersion, "v"),
+		Status:         hr.Info.Status,
+		Description:    hr.Info.Description,
+		DeploymentTime: deploymentTime,
+		IsRollback:     strings.HasPrefix(hr.Info.Description, helmRollbackDescription),
+	}
+	log.WithField("status", release.Status).Debug("Storing Helm release revision")
+	if err := c.Store.Add(ctx, release); err != nil {
+		log.WithError(err).Error("Failed to store Helm release revision")
+		return
+	}
+
+	if !release.Succeeded() {
+		return
+	}
+	d := store.Deployment{
+		Owner:             release.Owner,
+		Repository:        release.Repository,
+		Version:           release.Version,
+		Environment:       release.Environment,
+		DeploymentTime:    release.DeploymentTime,
+		SourceEnvironment: environment,
+	}
+	log.WithField("deployment", d.String()).Debug("Storing deployment")
+	if err := c.DeploymentStore.Add(ctx, d); err != nil {
+		log.WithError(err).Error("Failed to store deployment")
+	}
+}
+
+// owner returns the owner of the SourceRepository of the given name, if there is exactly one
+func (c *HelmReleaseCollector) owner(repository string) (string, bool) {
+	sourceRepositories, err := c.sourceRepositories.SourceRepositories(c.Namespace).List(labels.Everything())
+	if err != nil {
+		c.Logger.WithError(err).Error("Failed to list the SourceRepositories")
+		return "", false
+	}
+	owner := sourceRepositoryOwner(sourceRepositories, repository)
+	return owner, owner != ""
+}
+
+// decodeHelmRelease decodes the release of a Helm Secret: a base64 encoded JSON document, usually gzipped
+func decodeHelmRelease(data []byte) (*helmRelease, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("no release in the Secret")
+	}
+	b, err := base64.StdEncoding.DecodeString(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the release: %w", err)
+	}
+	if bytes.HasPrefix(b, []byte{0x1f, 0x8b, 0x08}) {
+		r, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, fmt.Errorf("failed to uncompress the release: %w", err)
+		}
+		defer r.Close()
+		if b, err = io.ReadAll(r); err != nil {
+			return nil, fmt.Errorf("failed to uncompress the release: %w", err)
+		}
+	}
+	var hr helmRelease
+	if err := json.Unmarshal(b, &hr); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the release: %w", err)
+	}
+	return &hr, nil
+}
+
+// helmReleaseTime returns the time of the install, upgrade or rollback which created the revision
+func helmReleaseTime(hr *helmRelease) (time.Time, error) {
+	value := hr.Info.LastDeployed
+	if value == "" {
+		value = hr.Info.FirstDeployed
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.In(time.UTC), nil
+}
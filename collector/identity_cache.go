@@ -0,0 +1,65 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/sirupsen/logrus"
+)
+
+// IdentityCache keeps the identities managed through the API in memory, so that resolving a login doesn't query the store.
+// It is refreshed periodically, to get the changes made through the other replicas.
+type IdentityCache struct {
+	Store    store.IdentityStore
+	Interval time.Duration
+	Logger   *logrus.Logger
+
+	identities atomic.Pointer[[]store.Identity]
+}
+
+// Start loads the identities, and then refreshes them until the given context is done
+func (c *IdentityCache) Start(ctx context.Context) error {
+	if err := c.Refresh(ctx); err != nil {
+		return err
+	}
+
+	go func() {
+		ticker := time.NewTicker(c.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if err := c.Refresh(ctx); err != nil {
+				c.Logger.WithError(err).Error("Failed to refresh the identities")
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Refresh reloads the identities from the store
+func (c *IdentityCache) Refresh(ctx context.Context) error {
+	identities, err := c.Store.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load the identities: %w", err)
+	}
+	c.identities.Store(&identities)
+	return nil
+}
+
+// Identities returns the cached identities
+func (c *IdentityCache) Identities() []store.Identity {
+	identities := c.identities.Load()
+	if identities == nil {
+		return nil
+	}
+	return *identities
+}
@@ -0,0 +1,73 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+)
+
+// informerStatus tracks the cache sync state of an informer, for the readiness checks
+type informerStatus struct {
+	mu         sync.Mutex
+	generation int
+	running    bool
+	synced     bool
+}
+
+// track marks the informer as running until the given context is done,
+// and as synced once waitForCacheSync reports that all its caches are synced
+func (s *informerStatus) track(ctx context.Context, waitForCacheSync func(stopCh <-chan struct{}) map[reflect.Type]bool) {
+	s.mu.Lock()
+	s.generation++
+	generation := s.generation
+	s.running, s.synced = true, false
+	s.mu.Unlock()
+
+	go func() {
+		synced := true
+		for _, ok := range waitForCacheSync(ctx.Done()) {
+			synced = synced && ok
+		}
+		s.update(generation, true, synced && ctx.Err() == nil)
+
+		<-ctx.Done()
+		s.update(generation, false, false)
+	}()
+}
+
+func (s *informerStatus) update(generation int, running, synced bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if generation != s.generation {
+		return // the informer has been restarted since
+	}
+	s.running, s.synced = running, synced
+}
+
+// Check returns an error while the informer is running but its cache is not synced yet.
+// An informer which is not running - because this replica is not the leader - is ready.
+func (s *informerStatus) Check(_ context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running && !s.synced {
+		return errors.New("informer cache is not synced yet")
+	}
+	return nil
+}
+
+// waitForCacheSyncs combines the WaitForCacheSync functions of multiple informer factories, so that they can be tracked together
+func waitForCacheSyncs(funcs ...func(stopCh <-chan struct{}) map[reflect.Type]bool) func(stopCh <-chan struct{}) map[reflect.Type]bool {
+	return func(stopCh <-chan struct{}) map[reflect.Type]bool {
+		synced := map[reflect.Type]bool{}
+		for _, waitForCacheSync := range funcs {
+			for t, ok := range waitForCacheSync(stopCh) {
+				if previous, found := synced[t]; found {
+					ok = ok && previous
+				}
+				synced[t] = ok
+			}
+		}
+		return synced
+	}
+}
@@ -0,0 +1,65 @@
+package collector
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// LeaderElection elects a single leader between the collector replicas, using a Kubernetes Lease
+type LeaderElection struct {
+	KubeClient    kubernetes.Interface
+	Namespace     string
+	LeaseName     string
+	Identity      string
+	LeaseDuration time.Duration
+	RenewDeadline time.Duration
+	RetryPeriod   time.Duration
+	Logger        *logrus.Logger
+}
+
+// Run blocks until the given context is done, and calls onStartedLeading each time this replica becomes the leader.
+// The context given to onStartedLeading is cancelled when the leadership is lost.
+func (le *LeaderElection) Run(ctx context.Context, onStartedLeading func(ctx context.Context) error) {
+	log := le.Logger.WithField("lease", le.LeaseName).WithField("namespace", le.Namespace).WithField("identity", le.Identity)
+
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      le.LeaseName,
+			Namespace: le.Namespace,
+		},
+		Client: le.KubeClient.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: le.Identity,
+		},
+	}
+
+	for ctx.Err() == nil {
+		leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+			Lock:            lock,
+			LeaseDuration:   le.LeaseDuration,
+			RenewDeadline:   le.RenewDeadline,
+			RetryPeriod:     le.RetryPeriod,
+			ReleaseOnCancel: true,
+			Callbacks: leaderelection.LeaderCallbacks{
+				OnStartedLeading: func(ctx context.Context) {
+					log.Info("Started leading")
+					if err := onStartedLeading(ctx); err != nil {
+						log.WithError(err).Error("Failed to start leading")
+					}
+				},
+				OnStoppedLeading: func() {
+					log.Info("Stopped leading")
+				},
+				OnNewLeader: func(identity string) {
+					log.WithField("leader", identity).Debug("New leader elected")
+				},
+			},
+		})
+	}
+}
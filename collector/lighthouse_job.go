@@ -0,0 +1,135 @@
+package collector
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/jenkins-x/cd-indicators/store"
+	lhv1alpha1 "github.com/jenkins-x/lighthouse-client/pkg/apis/lighthouse/v1alpha1"
+	lhclientset "github.com/jenkins-x/lighthouse-client/pkg/clientset/versioned"
+	lhinformers "github.com/jenkins-x/lighthouse-client/pkg/informers/externalversions"
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/tools/cache"
+)
+
+// LighthouseJobCollector collects the LighthouseJob resources: their trigger time gives the queue latency of the pipelines,
+// and their type and commit tell the periodic jobs and the pipelines triggered again by a ChatOps command such as /retest
+type LighthouseJobCollector struct {
+	LighthouseClient lhclientset.Interface
+	Namespace        string
+	ResyncInterval   time.Duration
+	Filter           *Filter
+	Store            store.LighthouseJobStore
+	Logger           *logrus.Logger
+
+	informerStatus informerStatus
+}
+
+// Start collects the LighthouseJob resources, until the given context is done
+func (c *LighthouseJobCollector) Start(ctx context.Context) error { // nolint: unparam
+	informerFactory := lhinformers.NewSharedInformerFactoryWithOptions(
+		c.LighthouseClient,
+		c.ResyncInterval,
+		lhinformers.WithNamespace(c.Namespace),
+	)
+	informerFactory.Lighthouse().V1alpha1().LighthouseJobs().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			j := obj.(*lhv1alpha1.LighthouseJob)
+			c.storeJob(ctx, j)
+		},
+		UpdateFunc: func(old, new interface{}) {
+			j := new.(*lhv1alpha1.LighthouseJob)
+			c.storeJob(ctx, j)
+		},
+	})
+	informerFactory.Start(ctx.Done())
+	c.informerStatus.track(ctx, informerFactory.WaitForCacheSync)
+
+	return nil
+}
+
+// Ready returns an error while the informer cache is not synced
+func (c *LighthouseJobCollector) Ready(ctx context.Context) error {
+	return c.informerStatus.Check(ctx)
+}
+
+func (c *LighthouseJobCollector) storeJob(ctx context.Context, lj *lhv1alpha1.LighthouseJob) {
+	if lj == nil {
+		return
+	}
+
+	log := c.Logger.WithField("lighthousejob", lj.Name)
+	job := store.LighthouseJob{
+		Name:        lj.Name,
+		Type:        string(lj.Spec.Type),
+		Job:         lj.Spec.Job,
+		Context:     lj.Spec.Context,
+		State:       string(lj.Status.State),
+		TriggerTime: lj.Status.StartTime.Time,
+	}
+	if job.TriggerTime.IsZero() {
+		job.TriggerTime = lj.CreationTimestamp.Time
+	}
+	job.TriggerTime = job.TriggerTime.In(time.UTC)
+
+	// the periodic jobs may have no repository: they are always collected
+	refs := lj.Spec.Refs
+	if refs == nil && len(lj.Spec.ExtraRefs) > 0 {
+		refs = &lj.Spec.ExtraRefs[0]
+	}
+	if refs != nil {
+		job.Owner, job.Repository, job.SHA = refs.Org, refs.Repo, refs.BaseSHA
+		if len(refs.Pulls) > 0 {
+			pull := refs.Pulls[0]
+			job.PullRequest, job.Author = pull.Number, c.Filter.Person(pull.Author)
+			job.IsBot = c.Filter.IsBot(pull.Author)
+			if pull.SHA != "" {
+				job.SHA = pull.SHA
+			}
+		}
+	}
+	if job.Owner != "" && !c.Filter.AllowsRepository(job.Owner, job.Repository) {
+		return
+	}
+
+	buildID := lj.Status.BuildID
+	if activity := lj.Status.Activity; activity != nil {
+		if buildID == "" {
+			buildID = activity.BuildIdentifier
+		}
+		if activity.StartTime != nil {
+			startTime := activity.StartTime.Time.In(time.UTC)
+			job.StartTime = &startTime
+		}
+	}
+	if job.StartTime == nil && lj.Status.PendingTime != nil {
+		startTime := lj.Status.PendingTime.Time.In(time.UTC)
+		job.StartTime = &startTime
+	}
+	if lj.Status.CompletionTime != nil {
+		endTime := lj.Status.CompletionTime.Time.In(time.UTC)
+		job.EndTime = &endTime
+	}
+	if buildID != "" {
+		build, err := strconv.Atoi(buildID)
+		if err != nil {
+			log.WithField("build", buildID).WithError(err).Error("Can't collect a LighthouseJob with an invalid build ID")
+			return
+		}
+		job.Build = build
+	}
+
+	// a job aborted while queued has an end time, but no start time nor duration
+	if job.StartTime != nil {
+		job.QueueDuration = job.StartTime.Sub(job.TriggerTime)
+		if job.EndTime != nil {
+			job.Duration = job.EndTime.Sub(*job.StartTime)
+		}
+	}
+
+	log.WithField("state", job.State).Debug("Storing lighthouse job")
+	if err := c.Store.Add(ctx, job); err != nil {
+		log.WithError(err).Error("Failed to store lighthouse job")
+	}
+}
@@ -10,7 +10,6 @@ import (
 	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
 	jxclientset "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned"
 	informers "github.com/jenkins-x/jx-api/v4/pkg/client/informers/externalversions"
-	"github.com/scylladb/go-set/strset"
 	"github.com/sirupsen/logrus"
 	"k8s.io/client-go/tools/cache"
 )
@@ -19,9 +18,11 @@ type PipelineActivityCollector struct {
 	JXClient       *jxclientset.Clientset
 	Namespace      string
 	ResyncInterval time.Duration
-	GitOwners      *strset.Set
-	Store          *store.PipelineStore
+	Filter         *Filter
+	Store          store.PipelineStore
 	Logger         *logrus.Logger
+
+	informerStatus informerStatus
 }
 
 func (c *PipelineActivityCollector) Start(ctx context.Context) error { // nolint: unparam
@@ -45,9 +46,15 @@ func (c *PipelineActivityCollector) Start(ctx context.Context) error { // nolint
 		},
 	})
 	informerFactory.Start(ctx.Done())
+	c.informerStatus.track(ctx, informerFactory.WaitForCacheSync)
 
 	return nil
 }
+
+// Ready returns an error while the informer cache is not synced
+func (c *PipelineActivityCollector) Ready(ctx context.Context) error {
+	return c.informerStatus.Check(ctx)
+}
 func SimplifyStep(coreStep jenkinsv1.CoreActivityStep) store.SimplifiedActivityStep {
 
 	if coreStep.Status == "" || coreStep.StartedTimestamp == nil || coreStep.CompletedTimestamp == nil {
@@ -84,14 +91,9 @@ func (c *PipelineActivityCollector) storePipeline(pa *jenkinsv1.PipelineActivity
 		log.Trace("Ignoring PipelineActivity with no repository")
 		return
 	}
-	if !c.GitOwners.IsEmpty() && !c.GitOwners.Has(pa.Spec.GitOwner) {
-		log.
-			WithField("owner", pa.Spec.GitOwner).
-			WithField("allowed-owners", c.GitOwners.String()).
-			Debug("Ignoring PipelineActivity with not-allowed git owner")
+	if !c.Filter.AllowsRepository(pa.Spec.GitOwner, pa.Spec.GitRepository) {
 		return
 	}
-
 	var simplifiedSteps []store.SimplifiedActivityStep
 	for _, step := range pa.Spec.Steps {
 		log.WithField("step", step.Kind).Trace("Simplifying step")
@@ -125,14 +127,16 @@ func (c *PipelineActivityCollector) storePipeline(pa *jenkinsv1.PipelineActivity
 	}
 	log.WithField("steps", len(simplifiedSteps)).Trace("Simplified steps")
 	pipeline := store.Pipeline{
-		Owner:      pa.Spec.GitOwner,
-		Repository: pa.Spec.GitRepository,
-		Context:    pa.Spec.Context,
-		Status:     string(pa.Spec.Status),
-		Author:     pa.Spec.Author,
-		StartTime:  pa.Spec.StartedTimestamp.Time.In(time.UTC),
-		EndTime:    pa.Spec.CompletedTimestamp.Time.In(time.UTC),
-		Steps:      simplifiedSteps,
+		Owner:       pa.Spec.GitOwner,
+		Repository:  pa.Spec.GitRepository,
+		Context:     pa.Spec.Context,
+		Status:      string(pa.Spec.Status),
+		Author:      c.Filter.Person(pa.Spec.Author),
+		StartTime:   pa.Spec.StartedTimestamp.Time.In(time.UTC),
+		EndTime:     pa.Spec.CompletedTimestamp.Time.In(time.UTC),
+		Steps:       simplifiedSteps,
+		IsBot:       c.Filter.IsBot(pa.Spec.Author),
+		AuthorLogin: pa.Spec.Author,
 	}
 	pipeline.Duration = pipeline.EndTime.Sub(pipeline.StartTime)
 
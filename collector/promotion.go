@@ -0,0 +1,104 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/jenkins-x/go-scm/scm"
+)
+
+var (
+	// promotionRegexp matches the promotions in the title and body of the pull requests created by "jx promote",
+	// such as "chore: promote myapp to version 1.2.3" - with an optional markdown emphasis in the body
+	promotionRegexp = regexp.MustCompile(`(?i)\bpromote\s+[*_]*([\w.-]+)[*_]*\s+to\s+version\s+[*_]*v?([\w.+-]+)`)
+	// environmentLabelPrefix is the prefix of the label of the environment of the promotion pull requests
+	environmentLabelPrefix = "env/"
+)
+
+// promotion is an application promoted to a version
+type promotion struct {
+	Application string
+	Version     string
+}
+
+// parsePromotions returns the promotions of the title and body of a pull request, without duplicates
+func parsePromotions(texts ...string) []promotion {
+	var (
+		promotions []promotion
+		seen       = map[promotion]bool{}
+	)
+	for _, text := range texts {
+		for _, match := range promotionRegexp.FindAllStringSubmatch(text, -1) {
+			p := promotion{Application: match[1], Version: strings.TrimRight(match[2], ".-")}
+			if p.Version == "" || seen[p] {
+				continue
+			}
+			seen[p] = true
+			promotions = append(promotions, p)
+		}
+	}
+	return promotions
+}
+
+// storePromotions stores the deployments of the applications promoted by a pull request merged into an environment repository
+func (c *PullRequestCollector) storePromotions(pullRequest scm.PullRequest) error {
+	repo := pullRequest.Repository()
+	envRepository, ok := c.Filter.EnvironmentRepository(repo.Namespace, repo.Name)
+	if !ok {
+		return nil
+	}
+
+	log := c.Logger.WithField("repo", repo.FullName).WithField("pr", pullRequest.Number)
+	environment := envRepository.Environment
+	if environment == "" {
+		for _, label := range pullRequest.Labels {
+			if label != nil && strings.HasPrefix(label.Name, environmentLabelPrefix) {
+				environment = strings.TrimPrefix(label.Name, environmentLabelPrefix)
+				break
+			}
+		}
+	}
+	if environment == "" {
+		log.Debug("Ignoring pullrequest of an environment repository with no environment label")
+		return nil
+	}
+	promotions := parsePromotions(pullRequest.Title, pullRequest.Body)
+	if len(promotions) == 0 {
+		log.Debug("Ignoring pullrequest of an environment repository which is not a promotion")
+		return nil
+	}
+
+	owner := envRepository.Owner
+	if owner == "" {
+		owner = repo.Namespace
+	}
+	// the merge time is the last update of the merged pull request
+	deploymentTime := pullRequest.Updated
+	if deploymentTime.IsZero() {
+		deploymentTime = time.Now()
+	}
+
+	ctx := context.Background()
+	for _, p := range promotions {
+		if !c.Filter.AllowsRepository(owner, p.Application) {
+			continue
+		}
+		d := store.Deployment{
+			Owner:             owner,
+			Repository:        p.Application,
+			Version:           p.Version,
+			Environment:       c.Filter.Environment(owner, p.Application, environment),
+			DeploymentTime:    deploymentTime.In(time.UTC),
+			SourceEnvironment: environment,
+		}
+		log.WithField("deployment", d.String()).Debug("Storing deployment of a promotion")
+		if err := c.DeploymentStore.Add(ctx, d); err != nil {
+			return fmt.Errorf("failed to store the deployment of promotion %s: %w", d, err)
+		}
+	}
+	return nil
+}
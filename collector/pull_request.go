@@ -2,20 +2,27 @@ package collector
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/jenkins-x/cd-indicators/internal/lighthouse"
 	"github.com/jenkins-x/cd-indicators/store"
 	"github.com/jenkins-x/go-scm/scm"
-	"github.com/scylladb/go-set/strset"
 	"github.com/sirupsen/logrus"
 )
 
 type PullRequestCollector struct {
-	GitOwners         *strset.Set
-	Store             *store.PullRequestStore
+	Filter *Filter
+	Store  store.PullRequestStore
+	// EventStore is optional: it keeps the handled events, so that the pull requests can be rebuilt
+	EventStore store.PullRequestEventStore
+	// DeploymentStore is optional: it stores the deployments of the promotion pull requests merged into the environment repositories
+	DeploymentStore   store.DeploymentStore
 	LighthouseHandler *lighthouse.Handler
-	Logger            *logrus.Logger
+	// GitClient is optional: it is used to retrieve the size of the pull requests, which is not part of the webhooks
+	GitClient *scm.Client
+	Logger    *logrus.Logger
 }
 
 func (c *PullRequestCollector) Start(_ context.Context) error { // nolint: unparam
@@ -32,7 +39,7 @@ func (c *PullRequestCollector) handleWebhook(webhook scm.Webhook) error {
 	case *scm.PullRequestHook:
 		log := log.WithField("pr", event.PullRequest.Number).WithField("action", event.Action)
 		switch event.Action {
-		case scm.ActionReadyForReview, scm.ActionConvertedToDraft, scm.ActionMerge:
+		case scm.ActionReadyForReview, scm.ActionConvertedToDraft, scm.ActionMerge, scm.ActionSync, scm.ActionReopen:
 		case scm.ActionOpen:
 			log = log.WithField("draft", event.PullRequest.Draft)
 		case scm.ActionClose:
@@ -44,19 +51,62 @@ func (c *PullRequestCollector) handleWebhook(webhook scm.Webhook) error {
 			return nil
 		}
 		log.Debug("Handling pullrequest hook event")
-		return c.storePullRequest(event.PullRequest, event.Action, scm.Review{}, event.Label)
+		if c.DeploymentStore != nil && (event.Action == scm.ActionMerge || event.Action == scm.ActionClose && event.PullRequest.Merged) {
+			if err := c.storePromotions(event.PullRequest); err != nil {
+				log.WithError(err).Error("Failed to store the deployments of the promotion")
+			}
+		}
+		e := newPullRequestEvent(event.PullRequest, event.Action, event.Sender.Login, time.Time{})
+		e.Label = event.Label.Name
+		return c.handleEvent(e)
 
 	// https://docs.github.com/en/developers/webhooks-and-events/webhook-events-and-payloads#pull_request_review
 	case *scm.ReviewHook:
 		log := log.WithField("pr", event.PullRequest.Number).WithField("action", event.Action).WithField("reviewer", event.Review.Author.Login)
+		reviewTime := event.Review.Created
 		switch event.Action {
 		case scm.ActionSubmitted:
+		case scm.ActionDismissed:
+			// the time of the review is when it has been submitted, not dismissed
+			reviewTime = time.Time{}
 		default:
 			log.Debug("Ignoring pullrequest review hook event for this action")
 			return nil
 		}
 		log.Debug("Handling pullrequest review hook event")
-		return c.storePullRequest(event.PullRequest, event.Action, event.Review, scm.Label{})
+		e := newPullRequestEvent(event.PullRequest, event.Action, event.Review.Author.Login, reviewTime)
+		e.ReviewState = event.Review.State
+		return c.handleEvent(e)
+
+	// https://docs.github.com/en/webhooks/webhook-events-and-payloads#issue_comment
+	case *scm.IssueCommentHook:
+		if event.Issue.PullRequest == nil {
+			log.Trace("Ignoring issue comment hook event which is not for a pullrequest")
+			return nil
+		}
+		log := log.WithField("pr", event.Issue.Number).WithField("action", event.Action).WithField("commenter", event.Comment.Author.Login)
+		if event.Action != scm.ActionCreate {
+			log.Debug("Ignoring pullrequest comment hook event for this action")
+			return nil
+		}
+		log.Debug("Handling pullrequest comment hook event")
+		pullRequest := scm.PullRequest{
+			Number:  event.Issue.Number,
+			State:   event.Issue.State,
+			Author:  event.Issue.Author,
+			Created: event.Issue.Created,
+			Base:    scm.PullRequestBranch{Repo: event.Repo},
+		}
+		return c.handleEvent(newPullRequestEvent(pullRequest, event.Action, event.Comment.Author.Login, event.Comment.Created))
+
+	case *scm.PullRequestCommentHook:
+		log := log.WithField("pr", event.PullRequest.Number).WithField("action", event.Action).WithField("commenter", event.Comment.Author.Login)
+		if event.Action != scm.ActionCreate {
+			log.Debug("Ignoring pullrequest comment hook event for this action")
+			return nil
+		}
+		log.Debug("Handling pullrequest comment hook event")
+		return c.handleEvent(newPullRequestEvent(event.PullRequest, event.Action, event.Comment.Author.Login, event.Comment.Created))
 
 	default:
 		log.Trace("Ignoring non pullrequest hook event")
@@ -65,58 +115,198 @@ func (c *PullRequestCollector) handleWebhook(webhook scm.Webhook) error {
 	return nil
 }
 
-func (c *PullRequestCollector) storePullRequest(pullRequest scm.PullRequest, action scm.Action, review scm.Review, label scm.Label) error {
-	if !c.GitOwners.IsEmpty() && !c.GitOwners.Has(pullRequest.Repository().Namespace) {
-		c.Logger.
-			WithField("owner", pullRequest.Repository().Namespace).
-			WithField("allowed-owners", c.GitOwners.String()).
-			Debug("Ignoring PullRequest with not-allowed git owner")
+// newPullRequestEvent creates an event for the given pull request.
+// The actor defaults to the pull request author, and the time to now.
+func newPullRequestEvent(pullRequest scm.PullRequest, action scm.Action, actor string, eventTime time.Time) store.PullRequestEvent {
+	if actor == "" {
+		actor = pullRequest.Author.Login
+	}
+	if eventTime.IsZero() {
+		eventTime = time.Now()
+	}
+	return store.PullRequestEvent{
+		Owner:        pullRequest.Repository().Namespace,
+		Repository:   pullRequest.Repository().Name,
+		PullRequest:  pullRequest.Number,
+		Action:       action.String(),
+		Actor:        actor,
+		Time:         eventTime,
+		Author:       pullRequest.Author.Login,
+		State:        pullRequest.State,
+		Draft:        pullRequest.Draft,
+		Merged:       pullRequest.Merged,
+		CreationTime: pullRequest.Created,
+	}
+}
+
+// handleEvent appends the event to the event log, and then updates the pull request summary
+func (c *PullRequestCollector) handleEvent(event store.PullRequestEvent) error {
+	if !c.Filter.AllowsRepository(event.Owner, event.Repository) {
 		return nil
 	}
+	ctx := context.Background()
+
+	switch event.Action {
+	case scm.ActionOpen.String(), scm.ActionSync.String(), scm.ActionReadyForReview.String(), scm.ActionClose.String(), scm.ActionMerge.String():
+		if err := c.setSize(ctx, &event); err != nil {
+			c.Logger.WithField("event", event.String()).WithError(err).Warning("Failed to retrieve the size of the pullrequest")
+		}
+	case scm.ActionSubmitted.String():
+		// the required approvers of some paths depend on the changed files
+		if strings.EqualFold(event.ReviewState, scm.ReviewStateApproved) && c.Filter.HasPathApprovers(event.Owner, event.Repository) {
+			if err := c.setSize(ctx, &event); err != nil {
+				c.Logger.WithField("event", event.String()).WithError(err).Warning("Failed to retrieve the changed files of the pullrequest")
+			}
+		}
+	}
+
+	if c.EventStore != nil {
+		event.ActorPerson = c.Filter.Person(event.Actor)
+		c.Logger.WithField("event", event.String()).Debug("Storing pullrequest event")
+		if err := c.EventStore.Add(ctx, event); err != nil {
+			return err
+		}
+	}
+
+	pr, ok := c.PullRequestFromEvent(event)
+	if !ok {
+		return nil
+	}
+	c.Logger.WithField("pullrequest", pr.String()).Debug("Storing pullrequest")
+	return c.Store.Add(ctx, pr)
+}
+
+// PullRequestFromEvent returns the changes to merge into the pull request summary for the given event,
+// using the current configuration. It returns false if the event should be ignored.
+// The pull requests of bots are kept, flagged as automated, but the reviews and comments of bots are ignored.
+func (c *PullRequestCollector) PullRequestFromEvent(event store.PullRequestEvent) (store.PullRequest, bool) {
 	var (
-		ctx = context.Background()
-		now = time.Now()
-		pr  = store.PullRequest{
-			Owner:       pullRequest.Repository().Namespace,
-			Repository:  pullRequest.Repository().Name,
-			PullRequest: pullRequest.Number,
-			Author:      pullRequest.Author.Login,
-			State:       pullRequest.State,
+		eventTime = event.Time
+		pr        = store.PullRequest{
+			Owner:        event.Owner,
+			Repository:   event.Repository,
+			PullRequest:  event.PullRequest,
+			Author:       c.Filter.Person(event.Author),
+			State:        event.State,
+			Additions:    event.Additions,
+			Deletions:    event.Deletions,
+			ChangedFiles: event.ChangedFiles,
+			IsBot:        c.Filter.IsBot(event.Author),
 		}
 	)
 
-	switch action {
-	case scm.ActionOpen:
-		pr.CreationTime = &pullRequest.Created
-		if !pullRequest.Draft {
-			pr.ReadyForReviewTime = &pullRequest.Created
+	switch event.Action {
+	case scm.ActionOpen.String():
+		creationTime := event.CreationTime
+		pr.CreationTime = &creationTime
+		if !event.Draft {
+			pr.ReadyForReviewTime = &creationTime
 		}
-	case scm.ActionReadyForReview:
-		pr.ReadyForReviewTime = &now
-	case scm.ActionConvertedToDraft:
+	case scm.ActionReadyForReview.String():
+		pr.ReadyForReviewTime = &eventTime
+	case scm.ActionSync.String():
+		// only counted if the pull request has already been reviewed, see PullRequest.MergeWith
+		pr.PushesAfterFirstReview = 1
+	case scm.ActionReopen.String():
+		// use a "zero" time to reset it
+		pr.ClosedTime = new(time.Time)
+	case scm.ActionCreate.String():
+		if c.Filter.Person(event.Actor) == pr.Author || c.Filter.IsBot(event.Actor) {
+			c.Logger.WithField("commenter", event.Actor).Debug("Ignoring comment from the author or a bot")
+			return store.PullRequest{}, false
+		}
+		pr.FirstCommentTime = &eventTime
+	case scm.ActionConvertedToDraft.String():
 		// use a "zero" time to reset it
 		pr.ReadyForReviewTime = new(time.Time)
-	case scm.ActionLabel:
-		if label.Name == "approved" {
-			pr.ApprovedTime = &now
+	case scm.ActionLabel.String():
+		if c.Filter.IsApprovalLabel(event.Label) {
+			pr.ApprovedTime = &eventTime
 		}
-	case scm.ActionUnlabel:
-		if label.Name == "approved" {
+	case scm.ActionUnlabel.String():
+		if c.Filter.IsApprovalLabel(event.Label) {
 			// use a "zero" time to reset it
 			pr.ApprovedTime = new(time.Time)
 		}
-	case scm.ActionSubmitted:
+	case scm.ActionSubmitted.String():
+		if c.Filter.IsBot(event.Actor) {
+			c.Logger.WithField("reviewer", event.Actor).Debug("Ignoring review from a bot")
+			return store.PullRequest{}, false
+		}
 		pr.Reviews++
-		pr.Reviewers = append(pr.Reviewers, review.Author.Login)
-	case scm.ActionMerge:
-		pr.MergedTime = &now
-	case scm.ActionClose:
-		if pullRequest.Merged {
-			pr.MergedTime = &now
+		pr.Reviewers = append(pr.Reviewers, c.Filter.Person(event.Actor))
+		pr.FirstReviewTime = &eventTime
+		if strings.EqualFold(event.ReviewState, scm.ReviewStateChangesRequested) {
+			pr.ChangeRequests++
+			// the reviewer doesn't approve anymore
+			pr.RevokedApprovers = append(pr.RevokedApprovers, c.Filter.Person(event.Actor))
+		}
+		if strings.EqualFold(event.ReviewState, scm.ReviewStateApproved) {
+			pr.ApprovalRule = c.Filter.ApprovalRule(pr.Owner, pr.Repository, event.ChangedPaths, eventTime)
+			if pr.ApprovalRule != nil {
+				pr.Approvers = append(pr.Approvers, c.Filter.Person(event.Actor))
+			}
+		}
+	case scm.ActionDismissed.String():
+		if c.Filter.IsBot(event.Actor) {
+			c.Logger.WithField("reviewer", event.Actor).Debug("Ignoring dismissed review from a bot")
+			return store.PullRequest{}, false
+		}
+		pr.RevokedApprovers = append(pr.RevokedApprovers, c.Filter.Person(event.Actor))
+	case scm.ActionMerge.String():
+		pr.MergedTime = &eventTime
+	case scm.ActionClose.String():
+		if event.Merged {
+			pr.MergedTime = &eventTime
+		} else {
+			pr.ClosedTime = &eventTime
 		}
 	}
+
 	pr.CalculateDurations()
+	return pr, true
+}
 
-	c.Logger.WithField("pullrequest", pr.String()).Debug("Storing pullrequest")
-	return c.Store.Add(ctx, pr)
+// setSize sets the lines added/removed and the files changed, if a git client is configured -
+// and the paths of the changed files of the reviews, for their required approvers
+func (c *PullRequestCollector) setSize(ctx context.Context, event *store.PullRequestEvent) error {
+	if c.GitClient == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	var (
+		fullName = scm.Join(event.Owner, event.Repository)
+		opts     = &scm.ListOptions{Page: 1, Size: 100}
+		size     store.PullRequestEvent
+		paths    []string
+	)
+	for {
+		changes, res, err := c.GitClient.PullRequests.ListChanges(ctx, fullName, event.PullRequest, opts)
+		if err != nil {
+			return fmt.Errorf("failed to list the changes of pullrequest %s: %w", event, err)
+		}
+		for _, change := range changes {
+			size.Additions += change.Additions
+			size.Deletions += change.Deletions
+			size.ChangedFiles++
+			paths = append(paths, change.Path)
+			if change.PreviousPath != "" && change.PreviousPath != change.Path {
+				// a renamed file is also removed from its previous path
+				paths = append(paths, change.PreviousPath)
+			}
+		}
+		if res == nil || res.Page.Next == 0 || len(changes) == 0 {
+			break
+		}
+		opts.Page = res.Page.Next
+	}
+
+	event.Additions, event.Deletions, event.ChangedFiles = size.Additions, size.Deletions, size.ChangedFiles
+	if event.Action == scm.ActionSubmitted.String() {
+		event.ChangedPaths = paths
+	}
+	return nil
 }
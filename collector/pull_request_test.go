@@ -0,0 +1,214 @@
+package collector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/cd-indicators/internal/config"
+	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/jenkins-x/cd-indicators/store/memory"
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/scylladb/go-set/strset"
+	"github.com/sirupsen/logrus"
+)
+
+func newTestFilter(cfg *config.Config) *Filter {
+	return &Filter{
+		Config: func() *config.Config { return cfg },
+		Logger: logrus.New(),
+	}
+}
+
+func TestPullRequestCollectorStoresTheWebhooks(t *testing.T) {
+	s := memory.New()
+	c := &PullRequestCollector{
+		Filter: newTestFilter(&config.Config{
+			Approval: config.Approval{Reviews: true},
+			Identities: []store.Identity{
+				{Person: "bob", Aliases: []string{"bob-work"}},
+			},
+		}),
+		Store:      s.PullRequests,
+		EventStore: s.PullRequestEvents,
+		Logger:     logrus.New(),
+	}
+
+	var (
+		repo     = scm.Repository{Namespace: "org", Name: "app", FullName: "org/app"}
+		created  = time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+		reviewed = created.Add(1 * time.Hour)
+		approved = created.Add(2 * time.Hour)
+		pr       = scm.PullRequest{
+			Number:  1,
+			State:   "open",
+			Author:  scm.User{Login: "alice"},
+			Created: created,
+			Base:    scm.PullRequestBranch{Repo: repo},
+		}
+	)
+	webhooks := []scm.Webhook{
+		&scm.PullRequestHook{Action: scm.ActionOpen, Repo: repo, PullRequest: pr, Sender: scm.User{Login: "alice"}},
+		&scm.ReviewHook{Action: scm.ActionSubmitted, Repo: repo, PullRequest: pr, Review: scm.Review{
+			Author: scm.User{Login: "bob-work"}, State: scm.ReviewStateChangesRequested, Created: reviewed,
+		}},
+		// ignored: the reviews of the bots
+		&scm.ReviewHook{Action: scm.ActionSubmitted, Repo: repo, PullRequest: pr, Review: scm.Review{
+			Author: scm.User{Login: "renovate[bot]"}, State: scm.ReviewStateApproved, Created: reviewed,
+		}},
+		&scm.ReviewHook{Action: scm.ActionSubmitted, Repo: repo, PullRequest: pr, Review: scm.Review{
+			Author: scm.User{Login: "bob"}, State: scm.ReviewStateApproved, Created: approved,
+		}},
+		// ignored: the comments of the author
+		&scm.PullRequestCommentHook{Action: scm.ActionCreate, Repo: repo, PullRequest: pr, Comment: scm.Comment{
+			Author: scm.User{Login: "alice"}, Created: reviewed,
+		}},
+	}
+	for _, webhook := range webhooks {
+		if err := c.handleWebhook(webhook); err != nil {
+			t.Fatalf("failed to handle %T: %v", webhook, err)
+		}
+	}
+	merged := pr
+	merged.Merged = true
+	if err := c.handleWebhook(&scm.PullRequestHook{Action: scm.ActionClose, Repo: repo, PullRequest: merged, Sender: scm.User{Login: "bob"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	pullRequests := s.PullRequests.(*memory.PullRequestStore).List()
+	if len(pullRequests) != 1 {
+		t.Fatalf("expected 1 pull request, got %d", len(pullRequests))
+	}
+	stored := pullRequests[0]
+	if stored.Author != "alice" || stored.IsBot {
+		t.Errorf("expected the pull request of alice, got %q (bot: %v)", stored.Author, stored.IsBot)
+	}
+	if stored.Reviews != 2 || stored.ChangeRequests != 1 {
+		t.Errorf("expected 2 reviews with 1 change request, got %d and %d", stored.Reviews, stored.ChangeRequests)
+	}
+	if len(stored.Reviewers) != 1 || stored.Reviewers[0] != "bob" {
+		t.Errorf("expected the reviewers to be the canonical person, got %v", stored.Reviewers)
+	}
+	if stored.CreationTime == nil || !stored.CreationTime.Equal(created) || stored.ReadyForReviewTime == nil {
+		t.Errorf("expected the pull request to be ready for review since its creation, got %v and %v", stored.CreationTime, stored.ReadyForReviewTime)
+	}
+	if stored.ApprovedTime == nil || !stored.ApprovedTime.Equal(approved) || stored.TimeToReview != 2*time.Hour {
+		t.Errorf("expected the pull request to be approved by the approving review, got %v after %s", stored.ApprovedTime, stored.TimeToReview)
+	}
+	if stored.FirstCommentTime != nil {
+		t.Errorf("expected the comment of the author to be ignored, got %v", stored.FirstCommentTime)
+	}
+	if stored.MergedTime == nil || stored.ClosedTime != nil {
+		t.Errorf("expected the pull request to be merged, got merged at %v and closed at %v", stored.MergedTime, stored.ClosedTime)
+	}
+
+	var events int
+	err := s.PullRequestEvents.List(context.Background(), store.PullRequestEventFilter{}, func(store.PullRequestEvent) error {
+		events++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if events != len(webhooks)+1 {
+		t.Errorf("expected all the %d events to be kept, got %d", len(webhooks)+1, events)
+	}
+}
+
+// testPullRequestService lists the changed files of the pull requests
+type testPullRequestService struct {
+	scm.PullRequestService
+	changes []*scm.Change
+}
+
+func (s *testPullRequestService) ListChanges(context.Context, string, int, *scm.ListOptions) ([]*scm.Change, *scm.Response, error) {
+	return s.changes, &scm.Response{}, nil
+}
+
+func TestPullRequestCollectorRequiresTheApproversOfTheChangedPaths(t *testing.T) {
+	s := memory.New()
+	c := &PullRequestCollector{
+		Filter: newTestFilter(&config.Config{
+			Approval: config.Approval{
+				Reviews: true,
+				RequiredApprovers: []config.RequiredApprovers{
+					{Approvers: []string{"lead"}},
+					{Paths: []string{"docs/"}, Approvers: []string{"writer"}},
+					// not changed: not required
+					{Paths: []string{"*.sql"}, Approvers: []string{"dba"}},
+				},
+			},
+		}),
+		Store:      s.PullRequests,
+		EventStore: s.PullRequestEvents,
+		GitClient: &scm.Client{PullRequests: &testPullRequestService{changes: []*scm.Change{
+			{Path: "main.go", Additions: 10},
+			{Path: "docs/guide/README.md", PreviousPath: "README.md", Additions: 5},
+		}}},
+		Logger: logrus.New(),
+	}
+
+	var (
+		repo    = scm.Repository{Namespace: "org", Name: "app", FullName: "org/app"}
+		created = time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+		pr      = scm.PullRequest{Number: 1, State: "open", Author: scm.User{Login: "alice"}, Created: created, Base: scm.PullRequestBranch{Repo: repo}}
+	)
+	review := func(action scm.Action, reviewer, state string, hours int) *scm.ReviewHook {
+		return &scm.ReviewHook{Action: action, Repo: repo, PullRequest: pr, Review: scm.Review{
+			Author: scm.User{Login: reviewer}, State: state, Created: created.Add(time.Duration(hours) * time.Hour),
+		}}
+	}
+	stored := func() store.PullRequest {
+		t.Helper()
+		pullRequests := s.PullRequests.(*memory.PullRequestStore).List()
+		if len(pullRequests) != 1 {
+			t.Fatalf("expected 1 pull request, got %d", len(pullRequests))
+		}
+		return pullRequests[0]
+	}
+
+	steps := []struct {
+		webhook   scm.Webhook
+		approvers []string
+		approved  bool
+	}{
+		{webhook: &scm.PullRequestHook{Action: scm.ActionOpen, Repo: repo, PullRequest: pr, Sender: scm.User{Login: "alice"}}},
+		// the owner of the docs must approve too
+		{webhook: review(scm.ActionSubmitted, "lead", scm.ReviewStateApproved, 1), approvers: []string{"lead"}},
+		{webhook: review(scm.ActionSubmitted, "lead", scm.ReviewStateChangesRequested, 2)},
+		{webhook: review(scm.ActionSubmitted, "writer", scm.ReviewStateApproved, 3), approvers: []string{"writer"}},
+		{webhook: review(scm.ActionDismissed, "writer", scm.ReviewStateDismissed, 3)},
+		{webhook: review(scm.ActionSubmitted, "writer", scm.ReviewStateApproved, 4), approvers: []string{"writer"}},
+		{webhook: review(scm.ActionSubmitted, "lead", scm.ReviewStateApproved, 5), approvers: []string{"lead", "writer"}, approved: true},
+	}
+	for i, step := range steps {
+		if err := c.handleWebhook(step.webhook); err != nil {
+			t.Fatalf("failed to handle step %d: %v", i, err)
+		}
+		pullRequest := stored()
+		if !strset.New(pullRequest.Approvers...).IsEqual(strset.New(step.approvers...)) {
+			t.Errorf("expected the approvers after step %d to be %v, got %v", i, step.approvers, pullRequest.Approvers)
+		}
+		if approved := pullRequest.ApprovedTime != nil; approved != step.approved {
+			t.Errorf("expected the pull request to be approved after step %d: %v, got %v", i, step.approved, pullRequest.ApprovedTime)
+		}
+	}
+	if approved := stored().ApprovedTime; approved == nil || !approved.Equal(created.Add(5*time.Hour)) {
+		t.Errorf("expected the pull request to be approved by the last required approver, got %v", approved)
+	}
+
+	// the changed files are kept in the events of the approving reviews, for the rebuilds
+	err := s.PullRequestEvents.List(context.Background(), store.PullRequestEventFilter{}, func(e store.PullRequestEvent) error {
+		approving := e.Action == scm.ActionSubmitted.String() && e.ReviewState == scm.ReviewStateApproved
+		if approving && len(e.ChangedPaths) != 3 {
+			t.Errorf("expected the changed and renamed paths in the approving review event, got %v", e.ChangedPaths)
+		}
+		if !approving && len(e.ChangedPaths) != 0 {
+			t.Errorf("expected no changed paths in the %s event, got %v", e.Action, e.ChangedPaths)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
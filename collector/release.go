@@ -22,15 +22,22 @@ type ReleaseCollector struct {
 	JXClient          *jxclientset.Clientset
 	Namespace         string
 	ResyncInterval    time.Duration
-	GitOwners         *strset.Set
-	Store             *store.ReleaseStore
+	Filter            *Filter
+	Store             store.ReleaseStore
 	LighthouseHandler *lighthouse.Handler
 	Logger            *logrus.Logger
+
+	informerStatus informerStatus
 }
 
-func (c *ReleaseCollector) Start(ctx context.Context) error {
+// Start collects the releases from the Lighthouse webhooks
+func (c *ReleaseCollector) Start(_ context.Context) error { // nolint: unparam
 	c.LighthouseHandler.RegisterWebhookHandler(c.handleWebhook)
+	return nil
+}
 
+// StartInformer collects the Release resources, until the given context is done
+func (c *ReleaseCollector) StartInformer(ctx context.Context) error { // nolint: unparam
 	informerFactory := informers.NewSharedInformerFactoryWithOptions(
 		c.JXClient,
 		c.ResyncInterval,
@@ -51,10 +58,16 @@ func (c *ReleaseCollector) Start(ctx context.Context) error {
 		},
 	})
 	informerFactory.Start(ctx.Done())
+	c.informerStatus.track(ctx, informerFactory.WaitForCacheSync)
 
 	return nil
 }
 
+// Ready returns an error while the informer cache is not synced
+func (c *ReleaseCollector) Ready(ctx context.Context) error {
+	return c.informerStatus.Check(ctx)
+}
+
 func (c *ReleaseCollector) handleWebhook(webhook scm.Webhook) error {
 	log := c.Logger.WithField("repo", webhook.Repository().FullName)
 
@@ -89,30 +102,19 @@ func (c *ReleaseCollector) storeRelease(r *jenkinsv1.Release) {
 		log.Trace("Ignoring Release with no Git owner and/or repository")
 		return
 	}
-	if !c.GitOwners.IsEmpty() && !c.GitOwners.Has(r.Spec.GitOwner) {
-		log.
-			WithField("owner", r.Spec.GitOwner).
-			WithField("allowed-owners", c.GitOwners.String()).
-			Debug("Ignoring Release with not-allowed git owner")
+	if !c.Filter.AllowsRepository(r.Spec.GitOwner, r.Spec.GitRepository) {
 		return
 	}
 
-	contributors := strset.New()
+	var (
+		contributors = strset.New()
+		bots         = strset.New()
+	)
 	for _, commit := range r.Spec.Commits {
-		if login := extractUserLogin(commit.Author); login != "" {
-			contributors.Add(login)
-		}
-		if login := extractUserLogin(commit.Committer); login != "" {
-			contributors.Add(login)
-		}
+		c.addContributors(contributors, bots, commit.Author, commit.Committer)
 	}
 	for _, pr := range r.Spec.PullRequests {
-		if login := extractUserLogin(pr.User); login != "" {
-			contributors.Add(login)
-		}
-		if login := extractUserLogin(pr.ClosedBy); login != "" {
-			contributors.Add(login)
-		}
+		c.addContributors(contributors, bots, pr.User, pr.ClosedBy)
 	}
 
 	release := store.Release{
@@ -121,6 +123,8 @@ func (c *ReleaseCollector) storeRelease(r *jenkinsv1.Release) {
 		Version:      strings.TrimPrefix(r.Spec.Version, "v"),
 		Contributors: contributors.List(),
 		ReleaseTime:  r.CreationTimestamp.Time.In(time.UTC),
+		// a release with only bot contributors - such as a dependency upgrade - is automated
+		IsBot: !bots.IsEmpty() && bots.Size() == contributors.Size(),
 	}
 
 	log.Debug("Storing release")
@@ -132,7 +136,25 @@ func (c *ReleaseCollector) storeRelease(r *jenkinsv1.Release) {
 	}
 }
 
-func extractUserLogin(user *jenkinsv1.UserDetails) string {
+// addContributors adds the canonical persons of the given users to the contributors - and the bots to the bots too,
+// so that the automated releases are flagged, while their bot contributors are kept
+func (c *ReleaseCollector) addContributors(contributors, bots *strset.Set, users ...*jenkinsv1.UserDetails) {
+	for _, user := range users {
+		login := c.extractUserLogin(user)
+		switch {
+		case login == "":
+			continue
+		case c.Filter.IsBot(login):
+			contributors.Add(login)
+			bots.Add(login)
+		default:
+			contributors.Add(c.Filter.Person(login))
+		}
+	}
+}
+
+// extractUserLogin returns the login of the user - or its email if it has an identity, because commits may have no login
+func (c *ReleaseCollector) extractUserLogin(user *jenkinsv1.UserDetails) string {
 	if user == nil {
 		return ""
 	}
@@ -140,6 +162,11 @@ func extractUserLogin(user *jenkinsv1.UserDetails) string {
 	if user.Login != "" {
 		return user.Login
 	}
+	if user.Email != "" {
+		if identity, ok := c.Filter.Identity(user.Email); ok {
+			return identity.Person
+		}
+	}
 
 	return ""
 }
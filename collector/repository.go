@@ -0,0 +1,165 @@
+package collector
+
+import (
+	"context"
+	"time"
+
+	"github.com/jenkins-x/cd-indicators/store"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	jxclientset "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned"
+	informers "github.com/jenkins-x/jx-api/v4/pkg/client/informers/externalversions"
+	"github.com/scylladb/go-set/strset"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// RepositoryCollector keeps the inventory of the repositories in sync with the SourceRepository resources,
+// so that the repositories without any activity are known too
+type RepositoryCollector struct {
+	JXClient       *jxclientset.Clientset
+	Namespace      string
+	ResyncInterval time.Duration
+	Filter         *Filter
+	Store          store.RepositoryStore
+	Logger         *logrus.Logger
+
+	informerStatus informerStatus
+}
+
+// Start collects the SourceRepository resources, until the given context is done
+func (c *RepositoryCollector) Start(ctx context.Context) error { // nolint: unparam
+	informerFactory := informers.NewSharedInformerFactoryWithOptions(
+		c.JXClient,
+		c.ResyncInterval,
+		informers.WithNamespace(c.Namespace),
+	)
+	informer := informerFactory.Jenkins().V1().SourceRepositories()
+	informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			c.storeRepository(ctx, obj.(*jenkinsv1.SourceRepository))
+		},
+		UpdateFunc: func(old, new interface{}) {
+			c.storeRepository(ctx, new.(*jenkinsv1.SourceRepository))
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if sr, ok := obj.(*jenkinsv1.SourceRepository); ok {
+				c.deleteRepository(ctx, sr.Spec.Org, sr.Spec.Repo)
+			}
+		},
+	})
+	informerFactory.Start(ctx.Done())
+	c.informerStatus.track(ctx, informerFactory.WaitForCacheSync)
+
+	go func() {
+		informerFactory.WaitForCacheSync(ctx.Done())
+		if ctx.Err() != nil {
+			return
+		}
+		c.deleteStaleRepositories(ctx, informer.Lister().SourceRepositories(c.Namespace).List)
+	}()
+
+	return nil
+}
+
+// Ready returns an error while the informer cache is not synced
+func (c *RepositoryCollector) Ready(ctx context.Context) error {
+	return c.informerStatus.Check(ctx)
+}
+
+func (c *RepositoryCollector) storeRepository(ctx context.Context, sr *jenkinsv1.SourceRepository) {
+	if sr == nil {
+		return
+	}
+
+	log := c.Logger.WithField("sourcerepository", sr.Name)
+	if sr.Spec.Org == "" || sr.Spec.Repo == "" {
+		log.Trace("Ignoring SourceRepository with no Git owner and/or repository")
+		return
+	}
+	if !c.Filter.AllowsRepository(sr.Spec.Org, sr.Spec.Repo) {
+		return
+	}
+
+	repository := store.Repository{
+		Provider:     sourceRepositoryProvider(sr),
+		Owner:        sr.Spec.Org,
+		Name:         sr.Spec.Repo,
+		URL:          sr.Spec.URL,
+		Labels:       sr.Labels,
+		CreationTime: sr.CreationTimestamp.Time,
+	}
+	if repository.URL == "" {
+		repository.URL = sr.Spec.HTTPCloneURL
+	}
+	if err := c.Store.Add(ctx, repository); err != nil {
+		log.WithError(err).Error("Failed to store repository")
+		return
+	}
+	log.WithField("repository", repository.String()).Debug("Repository stored")
+}
+
+func (c *RepositoryCollector) deleteRepository(ctx context.Context, owner, name string) {
+	if owner == "" || name == "" {
+		return
+	}
+	if err := c.Store.Delete(ctx, owner, name); err != nil {
+		c.Logger.WithField("repository", owner+"/"+name).WithError(err).Error("Failed to delete repository")
+	}
+}
+
+// deleteStaleRepositories deletes the repositories whose SourceRepository has been deleted - or filtered out -
+// while the collector was not running
+func (c *RepositoryCollector) deleteStaleRepositories(ctx context.Context, list func(labels.Selector) ([]*jenkinsv1.SourceRepository, error)) {
+	sourceRepositories, err := list(labels.Everything())
+	if err != nil {
+		c.Logger.WithError(err).Error("Failed to list the SourceRepositories")
+		return
+	}
+	existing := strset.New()
+	for _, sr := range sourceRepositories {
+		if c.Filter.AllowsRepository(sr.Spec.Org, sr.Spec.Repo) {
+			existing.Add(sr.Spec.Org + "/" + sr.Spec.Repo)
+		}
+	}
+
+	activities, err := c.Store.ListActivity(ctx, "")
+	if err != nil {
+		c.Logger.WithError(err).Error("Failed to list the stored repositories")
+		return
+	}
+	for _, a := range activities {
+		if !existing.Has(a.Repository.String()) {
+			c.deleteRepository(ctx, a.Owner, a.Name)
+		}
+	}
+}
+
+// sourceRepositoryProvider returns the kind of git provider - such as "github" - or its URL if the kind is unknown
+func sourceRepositoryProvider(sr *jenkinsv1.SourceRepository) string {
+	for _, provider := range []string{sr.Spec.ProviderKind, sr.Spec.ProviderName, sr.Spec.Provider} {
+		if provider != "" {
+			return provider
+		}
+	}
+	return ""
+}
+
+// sourceRepositoryOwner returns the owner of the single SourceRepository of the given repository name,
+// or an empty owner if there is none - or more than one
+func sourceRepositoryOwner(sourceRepositories []*jenkinsv1.SourceRepository, repository string) string {
+	var owner string
+	for _, sr := range sourceRepositories {
+		if sr.Spec.Repo != repository || sr.Spec.Org == "" || sr.Spec.Org == owner {
+			continue
+		}
+		if owner != "" {
+			return ""
+		}
+		owner = sr.Spec.Org
+	}
+	return owner
+}
@@ -0,0 +1,270 @@
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/jenkins-x/go-scm/scm"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	jxclientset "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned"
+	informers "github.com/jenkins-x/jx-api/v4/pkg/client/informers/externalversions"
+	listers "github.com/jenkins-x/jx-api/v4/pkg/client/listers/jenkins.io/v1"
+	"github.com/scylladb/go-set/strset"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// codeOwnersPaths are the locations of the CODEOWNERS file, in the order used by GitHub
+var codeOwnersPaths = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// configCheckInterval is the interval between checks of the config file teams, which are cheap
+const configCheckInterval = time.Minute
+
+// TeamCollector maps the repositories to teams, from 3 sources replacing their own mappings:
+// the teams of the config file, the SourceRepository labels, and the default owners of the CODEOWNERS files
+// of the SourceRepositories - re-read every resync interval
+type TeamCollector struct {
+	JXClient       *jxclientset.Clientset
+	Namespace      string
+	ResyncInterval time.Duration
+	// TeamLabel is the SourceRepository label holding the team of the repository. Empty disables this source
+	TeamLabel string
+	Filter    *Filter
+	Store     store.TeamStore
+	// GitClient is optional: it is used to read the CODEOWNERS files
+	GitClient *scm.Client
+	Logger    *logrus.Logger
+
+	informerStatus informerStatus
+}
+
+// Start syncs the teams until the given context is done
+func (c *TeamCollector) Start(ctx context.Context) error { // nolint: unparam
+	informerFactory := informers.NewSharedInformerFactoryWithOptions(
+		c.JXClient,
+		c.ResyncInterval,
+		informers.WithNamespace(c.Namespace),
+	)
+	informer := informerFactory.Jenkins().V1().SourceRepositories()
+
+	// the SourceRepositories are synced all together, so coalesce the bursts of changes
+	changed := make(chan struct{}, 1)
+	notify := func(interface{}) {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}
+	informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: notify,
+		UpdateFunc: func(old, new interface{}) {
+			notify(new)
+		},
+		DeleteFunc: notify,
+	})
+	informerFactory.Start(ctx.Done())
+	c.informerStatus.track(ctx, informerFactory.WaitForCacheSync)
+
+	go func() {
+		informerFactory.WaitForCacheSync(ctx.Done())
+		if ctx.Err() != nil {
+			return
+		}
+		lister := informer.Lister()
+
+		codeOwnersTicker := time.NewTicker(c.ResyncInterval)
+		defer codeOwnersTicker.Stop()
+		configTicker := time.NewTicker(configCheckInterval)
+		defer configTicker.Stop()
+
+		var (
+			configSynced bool
+			lastConfig   []store.TeamRepository
+		)
+		syncConfig := func() {
+			mappings := c.Filter.Config().TeamRepositories()
+			if configSynced && equalTeamRepositories(mappings, lastConfig) {
+				return
+			}
+			configSynced = c.replace(ctx, store.TeamSourceConfig, mappings)
+			lastConfig = mappings
+		}
+
+		syncConfig()
+		c.syncSourceRepositories(ctx, lister)
+		c.syncCodeOwners(ctx, lister)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-configTicker.C:
+				syncConfig()
+			case <-changed:
+				c.syncSourceRepositories(ctx, lister)
+			case <-codeOwnersTicker.C:
+				c.syncCodeOwners(ctx, lister)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Ready returns an error while the informer cache is not synced
+func (c *TeamCollector) Ready(ctx context.Context) error {
+	return c.informerStatus.Check(ctx)
+}
+
+// syncSourceRepositories maps the SourceRepositories to the team of their label
+func (c *TeamCollector) syncSourceRepositories(ctx context.Context, lister listers.SourceRepositoryLister) {
+	if c.TeamLabel == "" {
+		return
+	}
+	var mappings []store.TeamRepository
+	for _, sr := range c.sourceRepositories(lister) {
+		if team := sr.Labels[c.TeamLabel]; team != "" {
+			mappings = append(mappings, store.TeamRepository{
+				Team:       team,
+				Owner:      sr.Spec.Org,
+				Repository: sr.Spec.Repo,
+			})
+		}
+	}
+	c.replace(ctx, store.TeamSourceSourceRepository, mappings)
+}
+
+// syncCodeOwners maps the SourceRepositories to the default owners of their CODEOWNERS file, if a git client is configured.
+// The repositories whose CODEOWNERS file can't be read - such as when rate limited - keep their previous mappings.
+func (c *TeamCollector) syncCodeOwners(ctx context.Context, lister listers.SourceRepositoryLister) {
+	if c.GitClient == nil {
+		return
+	}
+	var (
+		mappings []store.TeamRepository
+		previous []store.TeamRepository
+		loaded   bool
+	)
+	for _, sr := range c.sourceRepositories(lister) {
+		owners, err := c.codeOwners(ctx, sr.Spec.Org, sr.Spec.Repo)
+		if err != nil {
+			c.Logger.WithField("repository", sr.Spec.Org+"/"+sr.Spec.Repo).WithError(err).Warning("Failed to read the CODEOWNERS file: keeping its previous teams")
+			if !loaded {
+				if previous, err = c.Store.List(ctx); err != nil {
+					// keep all the previous mappings, rather than losing the teams of this repository
+					c.Logger.WithError(err).Error("Failed to list the team repositories")
+					return
+				}
+				loaded = true
+			}
+			for _, mapping := range previous {
+				if mapping.Source == store.TeamSourceCodeOwners && mapping.Owner == sr.Spec.Org && mapping.Repository == sr.Spec.Repo {
+					mappings = append(mappings, mapping)
+				}
+			}
+			continue
+		}
+		for _, team := range c.teamsOfCodeOwners(owners) {
+			mappings = append(mappings, store.TeamRepository{
+				Team:       team,
+				Owner:      sr.Spec.Org,
+				Repository: sr.Spec.Repo,
+			})
+		}
+	}
+	c.replace(ctx, store.TeamSourceCodeOwners, mappings)
+}
+
+// sourceRepositories returns the allowed SourceRepositories
+func (c *TeamCollector) sourceRepositories(lister listers.SourceRepositoryLister) []*jenkinsv1.SourceRepository {
+	all, err := lister.SourceRepositories(c.Namespace).List(labels.Everything())
+	if err != nil {
+		c.Logger.WithError(err).Error("Failed to list the SourceRepositories")
+		return nil
+	}
+	var sourceRepositories []*jenkinsv1.SourceRepository
+	for _, sr := range all {
+		if sr.Spec.Org == "" || sr.Spec.Repo == "" || !c.Filter.AllowsRepository(sr.Spec.Org, sr.Spec.Repo) {
+			continue
+		}
+		sourceRepositories = append(sourceRepositories, sr)
+	}
+	return sourceRepositories
+}
+
+// codeOwners returns the default owners of the CODEOWNERS file of the repository, or nil if it has none
+func (c *TeamCollector) codeOwners(ctx context.Context, owner, repository string) ([]string, error) {
+	for _, path := range codeOwnersPaths {
+		content, _, err := c.GitClient.Contents.Find(ctx, scm.Join(owner, repository), path, "")
+		if scm.IsScmNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		return parseCodeOwners(content.Data), nil
+	}
+	return nil, nil
+}
+
+// teamsOfCodeOwners returns the teams of the CODEOWNERS owners: the "@org/team" owners are teams,
+// and the users and emails are replaced by the team of their identity, if any
+func (c *TeamCollector) teamsOfCodeOwners(owners []string) []string {
+	teams := strset.New()
+	for _, owner := range owners {
+		login := strings.TrimPrefix(owner, "@")
+		if _, team, ok := strings.Cut(login, "/"); ok {
+			teams.Add(team)
+			continue
+		}
+		if identity, ok := c.Filter.Identity(login); ok && identity.Team != "" {
+			teams.Add(identity.Team)
+		}
+	}
+	return teams.List()
+}
+
+func (c *TeamCollector) replace(ctx context.Context, source string, mappings []store.TeamRepository) bool {
+	log := c.Logger.WithField("source", source).WithField("mappings", len(mappings))
+	if err := c.Store.Replace(ctx, source, mappings); err != nil {
+		log.WithError(err).Error("Failed to store the team repositories")
+		return false
+	}
+	log.Debug("Stored the team repositories")
+	return true
+}
+
+// parseCodeOwners returns the default owners of a CODEOWNERS file: the owners of the last rule matching all the files
+func parseCodeOwners(data []byte) []string {
+	var owners []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "*", "/*", "**", "/**":
+			owners = fields[1:]
+		}
+	}
+	return owners
+}
+
+func equalTeamRepositories(a, b []store.TeamRepository) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,90 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jenkins-x/cd-indicators/internal/config"
+	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/jenkins-x/cd-indicators/store/memory"
+	"github.com/jenkins-x/go-scm/scm"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	listers "github.com/jenkins-x/jx-api/v4/pkg/client/listers/jenkins.io/v1"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// testContentService serves the CODEOWNERS files of the repositories, or their error
+type testContentService struct {
+	scm.ContentService
+	files  map[string]string
+	errors map[string]error
+}
+
+func (s *testContentService) Find(_ context.Context, repo, path, _ string) (*scm.Content, *scm.Response, error) {
+	if err := s.errors[repo]; err != nil {
+		return nil, nil, err
+	}
+	if data, found := s.files[repo+"/"+path]; found {
+		return &scm.Content{Path: path, Data: []byte(data)}, nil, nil
+	}
+	return nil, nil, scm.ErrNotFound
+}
+
+func TestTeamCollectorKeepsTheCodeOwnersOfTheUnreadableRepositories(t *testing.T) {
+	ctx := context.Background()
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, repo := range []string{"app", "web", "api"} {
+		err := indexer.Add(&jenkinsv1.SourceRepository{
+			ObjectMeta: metav1.ObjectMeta{Name: "org-" + repo, Namespace: "jx"},
+			Spec:       jenkinsv1.SourceRepositorySpec{Org: "org", Repo: repo},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	lister := listers.NewSourceRepositoryLister(indexer)
+
+	contents := &testContentService{files: map[string]string{
+		"org/app/.github/CODEOWNERS": "* @org/platform",
+		"org/web/CODEOWNERS":         "* @org/frontend",
+		"org/api/CODEOWNERS":         "* @org/backend",
+	}}
+	s := memory.New()
+	c := &TeamCollector{
+		Namespace: "jx",
+		Filter:    newTestFilter(&config.Config{}),
+		Store:     s.Teams,
+		GitClient: &scm.Client{Contents: contents},
+		Logger:    logrus.New(),
+	}
+	c.syncCodeOwners(ctx, lister)
+
+	// the CODEOWNERS of web can't be read anymore, and the one of api changed
+	contents.errors = map[string]error{"org/web": errors.New("403 Forbidden")}
+	contents.files["org/api/CODEOWNERS"] = "* @org/payments"
+	c.syncCodeOwners(ctx, lister)
+
+	mappings, err := s.Teams.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	teams := map[string]string{}
+	for _, mapping := range mappings {
+		if mapping.Source != store.TeamSourceCodeOwners {
+			t.Errorf("expected only CODEOWNERS mappings, got %+v", mapping)
+		}
+		teams[mapping.Repository] = mapping.Team
+	}
+	expected := map[string]string{"app": "platform", "web": "frontend", "api": "payments"}
+	if len(teams) != len(expected) {
+		t.Errorf("expected the teams %v, got %v", expected, teams)
+	}
+	for repo, team := range expected {
+		if teams[repo] != team {
+			t.Errorf("expected org/%s to belong to %s, got %q", repo, team, teams[repo])
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package collector
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/cd-indicators/store"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/sirupsen/logrus"
+	tekton "github.com/tektoncd/pipeline/pkg/apis/pipeline"
+	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	tektonclientset "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	tektoninformers "github.com/tektoncd/pipeline/pkg/client/informers/externalversions"
+	tektonlisters "github.com/tektoncd/pipeline/pkg/client/listers/pipeline/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/apis"
+)
+
+// The labels of the PipelineRuns created by Lighthouse, with a fallback on the labels of the Jenkins X pipelines
+var (
+	tektonOwnerLabels   = []string{"lighthouse.jenkins-x.io/refs.org", "owner"}
+	tektonRepoLabels    = []string{"lighthouse.jenkins-x.io/refs.repo", "repository"}
+	tektonPullLabels    = []string{"lighthouse.jenkins-x.io/refs.pull"}
+	tektonBranchLabels  = []string{"lighthouse.jenkins-x.io/branch", "branch"}
+	tektonContextLabels = []string{"lighthouse.jenkins-x.io/context", "context", tekton.PipelineLabelKey}
+	tektonBuildLabels   = []string{"lighthouse.jenkins-x.io/buildNum", "build"}
+	// tektonAuthorKeys are read from the annotations first, then from the labels - which can't hold the "[bot]" suffix of the GitHub Apps
+	tektonAuthorKeys = []string{"lighthouse.jenkins-x.io/author", "author"}
+)
+
+// TektonCollector collects the pipelines from the Tekton PipelineRuns - and their steps from the TaskRuns -
+// for the clusters running Tekton without the Jenkins X PipelineActivities
+type TektonCollector struct {
+	TektonClient   tektonclientset.Interface
+	Namespace      string
+	ResyncInterval time.Duration
+	Filter         *Filter
+	Store          store.PipelineStore
+	Logger         *logrus.Logger
+
+	pipelineRuns   tektonlisters.PipelineRunLister
+	taskRuns       tektonlisters.TaskRunLister
+	informerStatus informerStatus
+}
+
+// Start collects the PipelineRun and TaskRun resources, until the given context is done
+func (c *TektonCollector) Start(ctx context.Context) error { // nolint: unparam
+	informerFactory := tektoninformers.NewSharedInformerFactoryWithOptions(
+		c.TektonClient,
+		c.ResyncInterval,
+		tektoninformers.WithNamespace(c.Namespace),
+	)
+	pipelineRunInformer := informerFactory.Tekton().V1().PipelineRuns()
+	taskRunInformer := informerFactory.Tekton().V1().TaskRuns()
+	c.pipelineRuns = pipelineRunInformer.Lister()
+	c.taskRuns = taskRunInformer.Lister()
+
+	pipelineRunInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			pr := obj.(*pipelinev1.PipelineRun)
+			c.storePipelineRun(ctx, pr)
+		},
+		UpdateFunc: func(old, new interface{}) {
+			pr := new.(*pipelinev1.PipelineRun)
+			c.storePipelineRun(ctx, pr)
+		},
+	})
+	// a TaskRun may be synced after its PipelineRun: its steps are then added to the stored pipeline
+	taskRunInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			tr := obj.(*pipelinev1.TaskRun)
+			c.storeTaskRun(ctx, tr)
+		},
+		UpdateFunc: func(old, new interface{}) {
+			tr := new.(*pipelinev1.TaskRun)
+			c.storeTaskRun(ctx, tr)
+		},
+	})
+	informerFactory.Start(ctx.Done())
+	c.informerStatus.track(ctx, informerFactory.WaitForCacheSync)
+
+	return nil
+}
+
+// Ready returns an error while the informer caches are not synced
+func (c *TektonCollector) Ready(ctx context.Context) error {
+	return c.informerStatus.Check(ctx)
+}
+
+func (c *TektonCollector) storeTaskRun(ctx context.Context, tr *pipelinev1.TaskRun) {
+	if tr == nil || tektonStatus(tr.Status.GetCondition(apis.ConditionSucceeded)) == "" {
+		return
+	}
+	name := tr.Labels[tekton.PipelineRunLabelKey]
+	if name == "" {
+		c.Logger.WithField("taskrun", tr.Name).Trace("Ignoring TaskRun which is not part of a PipelineRun")
+		return
+	}
+	pr, err := c.pipelineRuns.PipelineRuns(tr.Namespace).Get(name)
+	if err != nil {
+		c.Logger.WithField("taskrun", tr.Name).WithField("pipelinerun", name).Trace("Ignoring TaskRun whose PipelineRun is not synced")
+		return
+	}
+	c.storePipelineRun(ctx, pr)
+}
+
+func (c *TektonCollector) storePipelineRun(ctx context.Context, pr *pipelinev1.PipelineRun) {
+	if pr == nil {
+		return
+	}
+
+	log := c.Logger.WithField("pipelinerun", pr.Name)
+	status := tektonStatus(pr.Status.GetCondition(apis.ConditionSucceeded))
+	if status == "" {
+		log.Trace("Ignoring PipelineRun which is not done")
+		return
+	}
+	if pr.Status.StartTime == nil || pr.Status.CompletionTime == nil {
+		log.Trace("Ignoring PipelineRun which has no start or end time")
+		return
+	}
+	var (
+		owner           = labelValue(pr.Labels, tektonOwnerLabels)
+		repository      = labelValue(pr.Labels, tektonRepoLabels)
+		pipelineContext = labelValue(pr.Labels, tektonContextLabels)
+	)
+	if owner == "" || repository == "" {
+		log.Trace("Ignoring PipelineRun with no Git owner and/or repository labels")
+		return
+	}
+	if pipelineContext == "" {
+		log.Trace("Ignoring PipelineRun with no context")
+		return
+	}
+	if !c.Filter.AllowsRepository(owner, repository) {
+		return
+	}
+
+	author := labelValue(pr.Annotations, tektonAuthorKeys)
+	if author == "" {
+		author = labelValue(pr.Labels, tektonAuthorKeys)
+	}
+	pipeline := store.Pipeline{
+		Owner:       owner,
+		Repository:  repository,
+		Context:     pipelineContext,
+		Status:      string(status),
+		Author:      c.Filter.Person(author),
+		IsBot:       c.Filter.IsBot(author),
+		StartTime:   pr.Status.StartTime.Time.In(time.UTC),
+		EndTime:     pr.Status.CompletionTime.Time.In(time.UTC),
+		Steps:       c.steps(pr),
+		AuthorLogin: author,
+	}
+	pipeline.Duration = pipeline.EndTime.Sub(pipeline.StartTime)
+
+	var err error
+	pull := labelValue(pr.Labels, tektonPullLabels)
+	if branch := labelValue(pr.Labels, tektonBranchLabels); pull == "" && strings.HasPrefix(branch, "PR-") {
+		pull = strings.TrimPrefix(branch, "PR-")
+	}
+	if pull != "" {
+		pipeline.Type = store.PipelineTypePullRequest
+		pipeline.PullRequest, err = strconv.Atoi(pull)
+		if err != nil {
+			log.WithField("pullRequest", pull).WithError(err).Error("Can't collect a PipelineRun with an invalid pull request label")
+			return
+		}
+	} else {
+		pipeline.Type = store.PipelineTypeRelease
+	}
+
+	build := labelValue(pr.Labels, tektonBuildLabels)
+	pipeline.Build, err = strconv.Atoi(build)
+	if err != nil {
+		log.WithField("build", build).WithError(err).Error("Can't collect a PipelineRun with an invalid build label")
+		return
+	}
+
+	log.WithField("steps", len(pipeline.Steps)).Debug("Storing pipeline")
+	err = c.Store.Add(ctx, pipeline)
+	if err != nil {
+		log.WithError(err).Error("Failed to store pipeline")
+		return
+	}
+}
+
+// steps returns the steps of the done TaskRuns of the PipelineRun - the steps of a TaskRun first, then the TaskRun itself,
+// like the stages of the PipelineActivities. The TaskRuns which are not synced yet are skipped.
+func (c *TektonCollector) steps(pr *pipelinev1.PipelineRun) []store.SimplifiedActivityStep {
+	var steps []store.SimplifiedActivityStep
+	for _, child := range pr.Status.ChildReferences {
+		if child.Kind != "" && child.Kind != tekton.TaskRunControllerName {
+			continue
+		}
+		tr, err := c.taskRuns.TaskRuns(pr.Namespace).Get(child.Name)
+		if err != nil {
+			continue
+		}
+		status := tektonStatus(tr.Status.GetCondition(apis.ConditionSucceeded))
+		if status == "" || tr.Status.StartTime == nil || tr.Status.CompletionTime == nil {
+			continue
+		}
+		taskName := child.PipelineTaskName
+		if taskName == "" {
+			taskName = tr.Name
+		}
+		for _, step := range tr.Status.Steps {
+			if simplifiedStep := simplifyTektonStep(taskName, step); simplifiedStep.Name != "" {
+				steps = append(steps, simplifiedStep)
+			}
+		}
+		steps = append(steps, store.SimplifiedActivityStep{
+			Name:               taskName,
+			Status:             string(status),
+			StartedTimestamp:   tr.Status.StartTime.Time.In(time.UTC),
+			CompletedTimestamp: tr.Status.CompletionTime.Time.In(time.UTC),
+			Duration:           tr.Status.CompletionTime.Time.Sub(tr.Status.StartTime.Time),
+		})
+	}
+	return steps
+}
+
+// simplifyTektonStep returns the terminated step of a task, named "task/step" - or an empty step if it has not terminated
+func simplifyTektonStep(taskName string, step pipelinev1.StepState) store.SimplifiedActivityStep {
+	terminated := step.Terminated
+	if terminated == nil || terminated.StartedAt.IsZero() || terminated.FinishedAt.IsZero() {
+		return store.SimplifiedActivityStep{}
+	}
+	return store.SimplifiedActivityStep{
+		Name:               taskName + "/" + step.Name,
+		Status:             string(containerStatus(terminated)),
+		StartedTimestamp:   terminated.StartedAt.Time.In(time.UTC),
+		CompletedTimestamp: terminated.FinishedAt.Time.In(time.UTC),
+		Duration:           terminated.FinishedAt.Time.Sub(terminated.StartedAt.Time),
+	}
+}
+
+// tektonStatus maps the Succeeded condition of a PipelineRun or TaskRun to the status of the PipelineActivities,
+// or returns an empty status if the run is not done
+func tektonStatus(condition *apis.Condition) jenkinsv1.ActivityStatusType {
+	switch {
+	case condition == nil || condition.IsUnknown():
+		return jenkinsv1.ActivityStatusTypeNone
+	case condition.IsTrue():
+		return jenkinsv1.ActivityStatusTypeSucceeded
+	}
+	switch condition.Reason {
+	case pipelinev1.PipelineRunReasonCancelled.String(), pipelinev1.PipelineRunReasonCancelledRunningFinally.String(),
+		pipelinev1.PipelineRunReasonStoppedRunningFinally.String(), pipelinev1.TaskRunReasonCancelled.String():
+		return jenkinsv1.ActivityStatusTypeCancelled
+	case pipelinev1.PipelineRunReasonTimedOut.String(), pipelinev1.TaskRunReasonTimedOut.String():
+		return jenkinsv1.ActivityStatusTypeTimedOut
+	default:
+		return jenkinsv1.ActivityStatusTypeFailed
+	}
+}
+
+func containerStatus(terminated *corev1.ContainerStateTerminated) jenkinsv1.ActivityStatusType {
+	if terminated.ExitCode == 0 {
+		return jenkinsv1.ActivityStatusTypeSucceeded
+	}
+	return jenkinsv1.ActivityStatusTypeFailed
+}
+
+// labelValue returns the value of the first label found
+func labelValue(labels map[string]string, keys []string) string {
+	for _, key := range keys {
+		if value := labels[key]; value != "" {
+			return value
+		}
+	}
+	return ""
+}
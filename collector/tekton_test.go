@@ -0,0 +1,190 @@
+package collector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/cd-indicators/internal/config"
+	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/jenkins-x/cd-indicators/store/memory"
+	"github.com/sirupsen/logrus"
+	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	tektonfake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+)
+
+func succeededCondition(status corev1.ConditionStatus, reason string) duckv1.Status {
+	return duckv1.Status{Conditions: duckv1.Conditions{{
+		Type:   apis.ConditionSucceeded,
+		Status: status,
+		Reason: reason,
+	}}}
+}
+
+func TestTektonCollectorStoresThePipelineRuns(t *testing.T) {
+	var (
+		start = time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+		end   = start.Add(5 * time.Minute)
+	)
+	objects := []runtime.Object{
+		// a pull request pipeline created by Lighthouse, with a TaskRun
+		&pipelinev1.PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "app-pr-12-3",
+				Namespace: "jx",
+				Labels: map[string]string{
+					"lighthouse.jenkins-x.io/refs.org":  "org",
+					"lighthouse.jenkins-x.io/refs.repo": "app",
+					"lighthouse.jenkins-x.io/refs.pull": "12",
+					"lighthouse.jenkins-x.io/context":   "pr-build",
+					"lighthouse.jenkins-x.io/buildNum":  "3",
+				},
+				Annotations: map[string]string{
+					"lighthouse.jenkins-x.io/author": "renovate[bot]",
+				},
+			},
+			Status: pipelinev1.PipelineRunStatus{
+				Status: succeededCondition(corev1.ConditionTrue, "Succeeded"),
+				PipelineRunStatusFields: pipelinev1.PipelineRunStatusFields{
+					StartTime:      &metav1.Time{Time: start},
+					CompletionTime: &metav1.Time{Time: end},
+					ChildReferences: []pipelinev1.ChildStatusReference{{
+						TypeMeta:         runtime.TypeMeta{Kind: "TaskRun"},
+						Name:             "app-pr-12-3-build",
+						PipelineTaskName: "build",
+					}},
+				},
+			},
+		},
+		&pipelinev1.TaskRun{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "app-pr-12-3-build",
+				Namespace: "jx",
+				Labels: map[string]string{
+					"tekton.dev/pipelineRun": "app-pr-12-3",
+				},
+			},
+			Status: pipelinev1.TaskRunStatus{
+				Status: succeededCondition(corev1.ConditionTrue, "Succeeded"),
+				TaskRunStatusFields: pipelinev1.TaskRunStatusFields{
+					StartTime:      &metav1.Time{Time: start.Add(time.Minute)},
+					CompletionTime: &metav1.Time{Time: end},
+					Steps: []pipelinev1.StepState{
+						{Name: "compile", ContainerState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+							StartedAt:  metav1.Time{Time: start.Add(time.Minute)},
+							FinishedAt: metav1.Time{Time: start.Add(3 * time.Minute)},
+						}}},
+						{Name: "test", ContainerState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+							ExitCode:   1,
+							StartedAt:  metav1.Time{Time: start.Add(3 * time.Minute)},
+							FinishedAt: metav1.Time{Time: end},
+						}}},
+						// not terminated: skipped
+						{Name: "report"},
+					},
+				},
+			},
+		},
+		// a release pipeline of the Jenkins X labels, which timed out
+		&pipelinev1.PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "app-release-7",
+				Namespace: "jx",
+				Labels: map[string]string{
+					"owner":               "org",
+					"repository":          "app",
+					"branch":              "main",
+					"build":               "7",
+					"tekton.dev/pipeline": "release",
+					"author":              "alice-gh",
+				},
+			},
+			Status: pipelinev1.PipelineRunStatus{
+				Status: succeededCondition(corev1.ConditionFalse, pipelinev1.PipelineRunReasonTimedOut.String()),
+				PipelineRunStatusFields: pipelinev1.PipelineRunStatusFields{
+					StartTime:      &metav1.Time{Time: start},
+					CompletionTime: &metav1.Time{Time: end},
+				},
+			},
+		},
+		// still running: ignored
+		&pipelinev1.PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "app-release-8",
+				Namespace: "jx",
+				Labels: map[string]string{
+					"owner":               "org",
+					"repository":          "app",
+					"build":               "8",
+					"tekton.dev/pipeline": "release",
+				},
+			},
+			Status: pipelinev1.PipelineRunStatus{
+				Status: succeededCondition(corev1.ConditionUnknown, "Running"),
+				PipelineRunStatusFields: pipelinev1.PipelineRunStatusFields{
+					StartTime: &metav1.Time{Time: start},
+				},
+			},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := memory.New()
+	c := &TektonCollector{
+		TektonClient: tektonfake.NewSimpleClientset(objects...),
+		Namespace:    "jx",
+		Filter: newTestFilter(&config.Config{
+			Identities: []store.Identity{{Person: "alice", Aliases: []string{"alice-gh"}}},
+		}),
+		Store:  s.Pipelines,
+		Logger: logrus.New(),
+	}
+	if err := c.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	pipelineStore := s.Pipelines.(*memory.PipelineStore)
+	pipelines := map[int]store.Pipeline{}
+	waitFor(t, "the pipelines and their steps", func() bool {
+		for _, p := range pipelineStore.List() {
+			pipelines[p.Build] = p
+		}
+		return len(pipelines) == 2 && len(pipelines[3].Steps) == 3
+	})
+
+	pr := pipelines[3]
+	if pr.Type != store.PipelineTypePullRequest || pr.Owner != "org" || pr.Repository != "app" || pr.PullRequest != 12 || pr.Context != "pr-build" {
+		t.Errorf("expected the pull request pipeline of org/app#12 pr-build, got %+v", pr)
+	}
+	if pr.Status != "Succeeded" || !pr.StartTime.Equal(start) || !pr.EndTime.Equal(end) || pr.Duration != 5*time.Minute {
+		t.Errorf("expected a succeeded pipeline of 5 minutes, got %s from %s to %s (%s)", pr.Status, pr.StartTime, pr.EndTime, pr.Duration)
+	}
+	if pr.Author != "renovate[bot]" || !pr.IsBot {
+		t.Errorf("expected the pipeline to be triggered by a bot, got %q (bot: %v)", pr.Author, pr.IsBot)
+	}
+	expectedSteps := []store.SimplifiedActivityStep{
+		{Name: "build/compile", Status: "Succeeded", Duration: 2 * time.Minute},
+		{Name: "build/test", Status: "Failed", Duration: 2 * time.Minute},
+		{Name: "build", Status: "Succeeded", Duration: 4 * time.Minute},
+	}
+	for i, expected := range expectedSteps {
+		step := pr.Steps[i]
+		if step.Name != expected.Name || step.Status != expected.Status || step.Duration != expected.Duration {
+			t.Errorf("expected step %d to be %s %s in %s, got %s %s in %s", i, expected.Name, expected.Status, expected.Duration, step.Name, step.Status, step.Duration)
+		}
+	}
+
+	release := pipelines[7]
+	if release.Type != store.PipelineTypeRelease || release.Context != "release" || release.Status != "TimedOut" {
+		t.Errorf("expected a timed out release pipeline, got %s %s %s", release.Type, release.Context, release.Status)
+	}
+	if release.Author != "alice" || release.IsBot {
+		t.Errorf("expected the release pipeline to be triggered by the canonical person alice, got %q (bot: %v)", release.Author, release.IsBot)
+	}
+}
@@ -0,0 +1,235 @@
+package collector
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/cd-indicators/store"
+	jxclientset "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned"
+	jxinformers "github.com/jenkins-x/jx-api/v4/pkg/client/informers/externalversions"
+	listers "github.com/jenkins-x/jx-api/v4/pkg/client/listers/jenkins.io/v1"
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	kubeinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// The labels - and annotations - of the jx and helm workloads
+var (
+	workloadNameLabels    = []string{"app.kubernetes.io/name", "app"}
+	workloadVersionLabels = []string{"app.kubernetes.io/version", "version"}
+	workloadChartLabels   = []string{"helm.sh/chart", "chart"}
+	// helmReleaseAnnotation is the name of the helm release of the workload, used when it has no name label
+	helmReleaseAnnotation = "meta.helm.sh/release-name"
+)
+
+// WorkloadCollector collects the deployments from the rollouts of the apps/v1 Deployments and StatefulSets
+// of the environment namespaces, for the environments whose git provider doesn't emit deployment events.
+// The app of a workload is mapped to the SourceRepository of the same name.
+type WorkloadCollector struct {
+	KubeClient kubernetes.Interface
+	JXClient   jxclientset.Interface
+	// Namespace is the namespace of the SourceRepositories
+	Namespace string
+	// Namespaces maps the watched namespaces to their environment
+	Namespaces     map[string]string
+	ResyncInterval time.Duration
+	Filter         *Filter
+	Store          store.DeploymentStore
+	Logger         *logrus.Logger
+
+	sourceRepositories listers.SourceRepositoryLister
+	informerStatus     informerStatus
+}
+
+// Start collects the rollouts of the workloads, until the given context is done
+func (c *WorkloadCollector) Start(ctx context.Context) error { // nolint: unparam
+	jxInformerFactory := jxinformers.NewSharedInformerFactoryWithOptions(
+		c.JXClient,
+		c.ResyncInterval,
+		jxinformers.WithNamespace(c.Namespace),
+	)
+	sourceRepositoryInformer := jxInformerFactory.Jenkins().V1().SourceRepositories()
+	c.sourceRepositories = sourceRepositoryInformer.Lister()
+	// register the informer before starting the factory
+	sourceRepositoryInformer.Informer()
+	jxInformerFactory.Start(ctx.Done())
+	syncFuncs := []func(stopCh <-chan struct{}) map[reflect.Type]bool{jxInformerFactory.WaitForCacheSync}
+
+	for namespace, environment := range c.Namespaces {
+		informerFactory := kubeinformers.NewSharedInformerFactoryWithOptions(
+			c.KubeClient,
+			c.ResyncInterval,
+			kubeinformers.WithNamespace(namespace),
+		)
+		informerFactory.Apps().V1().Deployments().Informer().AddEventHandler(c.eventHandler(ctx, environment))
+		informerFactory.Apps().V1().StatefulSets().Informer().AddEventHandler(c.eventHandler(ctx, environment))
+		informerFactory.Start(ctx.Done())
+		syncFuncs = append(syncFuncs, informerFactory.WaitForCacheSync)
+	}
+	c.informerStatus.track(ctx, waitForCacheSyncs(syncFuncs...))
+
+	return nil
+}
+
+// Ready returns an error while the informer caches are not synced
+func (c *WorkloadCollector) Ready(ctx context.Context) error {
+	return c.informerStatus.Check(ctx)
+}
+
+// eventHandler stores a deployment when the rollout of a workload completes.
+// The rollouts of the StatefulSets have no completion time, so they are only stored when they are seen completing.
+func (c *WorkloadCollector) eventHandler(ctx context.Context, environment string) cache.ResourceEventHandlerFuncs {
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if rolloutTime, complete := rolloutStatus(obj); complete && !rolloutTime.IsZero() {
+				c.storeDeployment(ctx, obj, environment, rolloutTime)
+			}
+		},
+		UpdateFunc: func(old, new interface{}) {
+			rolloutTime, complete := rolloutStatus(new)
+			if !complete {
+				return
+			}
+			if rolloutTime.IsZero() {
+				if _, wasComplete := rolloutStatus(old); wasComplete {
+					return
+				}
+				rolloutTime = time.Now()
+			}
+			c.storeDeployment(ctx, new, environment, rolloutTime)
+		},
+	}
+}
+
+func (c *WorkloadCollector) storeDeployment(ctx context.Context, obj interface{}, environment string, rolloutTime time.Time) {
+	meta, podSpec := workloadMeta(obj)
+	if meta == nil {
+		return
+	}
+
+	log := c.Logger.WithField("namespace", meta.Namespace).WithField("workload", meta.Name)
+	name := labelValue(meta.Labels, workloadNameLabels)
+	if name == "" {
+		name = meta.Annotations[helmReleaseAnnotation]
+	}
+	if name == "" {
+		log.Trace("Ignoring workload with no app label")
+		return
+	}
+	owner, ok := c.owner(name)
+	if !ok {
+		log.WithField("app", name).Trace("Ignoring workload with no single SourceRepository of the same name")
+		return
+	}
+	if !c.Filter.AllowsRepository(owner, name) {
+		return
+	}
+	version := workloadVersion(name, meta.Labels, podSpec)
+	if version == "" {
+		log.Trace("Ignoring workload with no version label nor image tag")
+		return
+	}
+
+	d := store.Deployment{
+		Owner:             owner,
+		Repository:        name,
+		Version:           strings.TrimPrefix(version, "v"),
+		Environment:       c.Filter.Environment(owner, name, environment),
+		DeploymentTime:    rolloutTime.In(time.UTC),
+		SourceEnvironment: environment,
+	}
+	log.WithField("deployment", d.String()).Debug("Storing deployment")
+	if err := c.Store.Add(ctx, d); err != nil {
+		log.WithError(err).Error("Failed to store deployment")
+	}
+}
+
+// owner returns the owner of the SourceRepository of the given name, if there is exactly one
+func (c *WorkloadCollector) owner(repository string) (string, bool) {
+	sourceRepositories, err := c.sourceRepositories.SourceRepositories(c.Namespace).List(labels.Everything())
+	if err != nil {
+		c.Logger.WithError(err).Error("Failed to list the SourceRepositories")
+		return "", false
+	}
+	owner := sourceRepositoryOwner(sourceRepositories, repository)
+	return owner, owner != ""
+}
+
+func workloadMeta(obj interface{}) (*metav1.ObjectMeta, *corev1.PodSpec) {
+	switch w := obj.(type) {
+	case *appsv1.Deployment:
+		return &w.ObjectMeta, &w.Spec.Template.Spec
+	case *appsv1.StatefulSet:
+		return &w.ObjectMeta, &w.Spec.Template.Spec
+	default:
+		return nil, nil
+	}
+}
+
+// rolloutStatus returns whether the rollout of the workload is complete - the same way as "kubectl rollout status" -
+// and its completion time if known
+func rolloutStatus(obj interface{}) (time.Time, bool) {
+	switch w := obj.(type) {
+	case *appsv1.Deployment:
+		replicas := int32(1)
+		if w.Spec.Replicas != nil {
+			replicas = *w.Spec.Replicas
+		}
+		status := w.Status
+		if w.Generation > status.ObservedGeneration || status.UpdatedReplicas < replicas ||
+			status.Replicas > status.UpdatedReplicas || status.AvailableReplicas < status.UpdatedReplicas {
+			return time.Time{}, false
+		}
+		for _, condition := range status.Conditions {
+			if condition.Type == appsv1.DeploymentProgressing && condition.Reason == "NewReplicaSetAvailable" {
+				return condition.LastUpdateTime.Time, true
+			}
+		}
+		return time.Time{}, true
+	case *appsv1.StatefulSet:
+		replicas := int32(1)
+		if w.Spec.Replicas != nil {
+			replicas = *w.Spec.Replicas
+		}
+		status := w.Status
+		complete := w.Generation <= status.ObservedGeneration && status.UpdatedReplicas >= replicas &&
+			status.ReadyReplicas >= replicas && status.CurrentRevision == status.UpdateRevision
+		return time.Time{}, complete
+	default:
+		return time.Time{}, false
+	}
+}
+
+// workloadVersion returns the version of the workload: from its version label, its chart label, or the tag of its first image
+func workloadVersion(name string, labels map[string]string, podSpec *corev1.PodSpec) string {
+	if version := labelValue(labels, workloadVersionLabels); version != "" {
+		return version
+	}
+	if chart := labelValue(labels, workloadChartLabels); strings.HasPrefix(chart, name+"-") {
+		return strings.TrimPrefix(chart, name+"-")
+	}
+	if len(podSpec.Containers) > 0 {
+		return imageTag(podSpec.Containers[0].Image)
+	}
+	return ""
+}
+
+// imageTag returns the tag of the image, ignoring the "latest" tag which is not a version
+func imageTag(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+	if i := strings.LastIndex(image, "/"); i >= 0 {
+		image = image[i+1:]
+	}
+	_, tag, _ := strings.Cut(image, ":")
+	if tag == "latest" {
+		return ""
+	}
+	return tag
+}
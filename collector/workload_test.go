@@ -0,0 +1,227 @@
+package collector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/cd-indicators/internal/config"
+	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/jenkins-x/cd-indicators/store/memory"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func testSourceRepository(owner, repository string) *jenkinsv1.SourceRepository {
+	return &jenkinsv1.SourceRepository{
+		ObjectMeta: metav1.ObjectMeta{Name: owner + "-" + repository, Namespace: "jx"},
+		Spec:       jenkinsv1.SourceRepositorySpec{Org: owner, Repo: repository},
+	}
+}
+
+func testPodSpec(image string) corev1.PodTemplateSpec {
+	return corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "main", Image: image}}}}
+}
+
+func TestWorkloadCollectorStoresTheCompleteRollouts(t *testing.T) {
+	rolloutTime := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	completeDeploymentStatus := appsv1.DeploymentStatus{
+		ObservedGeneration: 2,
+		Replicas:           1,
+		UpdatedReplicas:    1,
+		AvailableReplicas:  1,
+		Conditions: []appsv1.DeploymentCondition{{
+			Type:           appsv1.DeploymentProgressing,
+			Status:         corev1.ConditionTrue,
+			Reason:         "NewReplicaSetAvailable",
+			LastUpdateTime: metav1.Time{Time: rolloutTime},
+		}},
+	}
+	kubeObjects := []runtime.Object{
+		// versioned by its labels, even though its image has another tag
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "app",
+				Namespace:  "jx-staging",
+				Generation: 2,
+				Labels:     map[string]string{"app.kubernetes.io/name": "app", "app.kubernetes.io/version": "v1.2.3"},
+			},
+			Spec:   appsv1.DeploymentSpec{Template: testPodSpec("ghcr.io/org/app:sha-abc")},
+			Status: completeDeploymentStatus,
+		},
+		// versioned by its chart label
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "api",
+				Namespace:  "jx-production",
+				Generation: 2,
+				Labels:     map[string]string{"app": "api", "helm.sh/chart": "api-0.3.1"},
+			},
+			Spec:   appsv1.DeploymentSpec{Template: testPodSpec("ghcr.io/org/api:latest")},
+			Status: completeDeploymentStatus,
+		},
+		// ignored: its rollout is still in progress
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "web",
+				Namespace:  "jx-staging",
+				Generation: 3,
+				Labels:     map[string]string{"app": "web", "version": "2.0.0"},
+			},
+			Spec: appsv1.DeploymentSpec{Template: testPodSpec("ghcr.io/org/web:2.0.0")},
+			Status: appsv1.DeploymentStatus{
+				ObservedGeneration: 3,
+				Replicas:           2,
+				UpdatedReplicas:    1,
+				AvailableReplicas:  1,
+			},
+		},
+		// ignored: the "latest" tag is not a version
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "docs",
+				Namespace:  "jx-staging",
+				Generation: 2,
+				Labels:     map[string]string{"app": "docs"},
+			},
+			Spec:   appsv1.DeploymentSpec{Template: testPodSpec("ghcr.io/org/docs:latest")},
+			Status: completeDeploymentStatus,
+		},
+		// ignored: not in a watched namespace
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "app",
+				Namespace:  "default",
+				Generation: 2,
+				Labels:     map[string]string{"app": "app", "version": "9.9.9"},
+			},
+			Spec:   appsv1.DeploymentSpec{Template: testPodSpec("ghcr.io/org/app:9.9.9")},
+			Status: completeDeploymentStatus,
+		},
+		// versioned by its image tag, once its rollout completes
+		&appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "worker",
+				Namespace:  "jx-production",
+				Generation: 1,
+				Labels:     map[string]string{"app": "worker"},
+			},
+			Spec: appsv1.StatefulSetSpec{Template: testPodSpec("ghcr.io/org/worker:v4.5.6@sha256:0123")},
+			Status: appsv1.StatefulSetStatus{
+				ObservedGeneration: 1,
+				UpdatedReplicas:    0,
+				ReadyReplicas:      1,
+				CurrentRevision:    "worker-1",
+				UpdateRevision:     "worker-2",
+			},
+		},
+	}
+	jxObjects := []runtime.Object{
+		testSourceRepository("org", "app"),
+		testSourceRepository("org", "api"),
+		testSourceRepository("org", "web"),
+		testSourceRepository("org", "docs"),
+		testSourceRepository("org", "worker"),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	kubeClient := kubefake.NewSimpleClientset(kubeObjects...)
+	s := memory.New()
+	c := &WorkloadCollector{
+		KubeClient: kubeClient,
+		JXClient:   jxfake.NewSimpleClientset(jxObjects...),
+		Namespace:  "jx",
+		Namespaces: map[string]string{"jx-staging": "staging", "jx-production": "production"},
+		Filter:     newTestFilter(&config.Config{}),
+		Store:      s.Deployments,
+		Logger:     logrus.New(),
+	}
+	if err := c.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the informer caches", func() bool { return c.Ready(ctx) == nil })
+
+	// complete the rollout of the StatefulSet
+	statefulSet, err := kubeClient.AppsV1().StatefulSets("jx-production").Get(ctx, "worker", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	statefulSet.Status.UpdatedReplicas = 1
+	statefulSet.Status.CurrentRevision = "worker-2"
+	beforeUpdate := time.Now()
+	if _, err := kubeClient.AppsV1().StatefulSets("jx-production").UpdateStatus(ctx, statefulSet, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	deploymentStore := s.Deployments.(*memory.DeploymentStore)
+	deployments := map[string]store.Deployment{}
+	waitFor(t, "the deployments", func() bool {
+		for _, d := range deploymentStore.List() {
+			deployments[d.Repository] = d
+		}
+		return len(deployments) == 3
+	})
+
+	app := deployments["app"]
+	if app.Owner != "org" || app.Version != "1.2.3" || app.Environment != "staging" || !app.DeploymentTime.Equal(rolloutTime) {
+		t.Errorf("expected org/app 1.2.3 to be deployed to staging at %s, got %+v", rolloutTime, app)
+	}
+	api := deployments["api"]
+	if api.Version != "0.3.1" || api.Environment != "production" || !api.DeploymentTime.Equal(rolloutTime) {
+		t.Errorf("expected api 0.3.1 to be deployed to production at %s, got %+v", rolloutTime, api)
+	}
+	worker := deployments["worker"]
+	if worker.Version != "4.5.6" || worker.Environment != "production" || worker.DeploymentTime.Before(beforeUpdate.Truncate(time.Second)) {
+		t.Errorf("expected worker 4.5.6 to be deployed to production when its rollout completed, got %+v", worker)
+	}
+}
+
+func TestRolloutStatus(t *testing.T) {
+	replicas := int32(3)
+	tests := []struct {
+		name     string
+		workload interface{}
+		complete bool
+	}{
+		{
+			name:     "deployment with an unobserved generation",
+			workload: &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Generation: 2}, Status: appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 1, UpdatedReplicas: 1, AvailableReplicas: 1}},
+		},
+		{
+			name:     "deployment with old replicas",
+			workload: &appsv1.Deployment{Spec: appsv1.DeploymentSpec{Replicas: &replicas}, Status: appsv1.DeploymentStatus{Replicas: 4, UpdatedReplicas: 3, AvailableReplicas: 3}},
+		},
+		{
+			name:     "deployment with unavailable replicas",
+			workload: &appsv1.Deployment{Spec: appsv1.DeploymentSpec{Replicas: &replicas}, Status: appsv1.DeploymentStatus{Replicas: 3, UpdatedReplicas: 3, AvailableReplicas: 2}},
+		},
+		{
+			name:     "complete deployment",
+			workload: &appsv1.Deployment{Spec: appsv1.DeploymentSpec{Replicas: &replicas}, Status: appsv1.DeploymentStatus{Replicas: 3, UpdatedReplicas: 3, AvailableReplicas: 3}},
+			complete: true,
+		},
+		{
+			name:     "statefulset with an old revision",
+			workload: &appsv1.StatefulSet{Status: appsv1.StatefulSetStatus{UpdatedReplicas: 1, ReadyReplicas: 1, CurrentRevision: "1", UpdateRevision: "2"}},
+		},
+		{
+			name:     "complete statefulset",
+			workload: &appsv1.StatefulSet{Status: appsv1.StatefulSetStatus{UpdatedReplicas: 1, ReadyReplicas: 1, CurrentRevision: "2", UpdateRevision: "2"}},
+			complete: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, complete := rolloutStatus(test.workload); complete != test.complete {
+				t.Errorf("expected the rollout to be complete: %v, got %v", test.complete, complete)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jenkins-x/cd-indicators/internal/export"
+	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/sirupsen/logrus"
+)
+
+// Handler serves the API, under /api/.
+// The write requests must be authenticated with the token, as a bearer token: they are forbidden if no token is set.
+// The exports must also be authenticated with it when it is set, as they contain the raw entities.
+type Handler struct {
+	Store *store.Store
+	Token string
+	// IdentitiesChanged is called after a change of the identities. It is optional
+	IdentitiesChanged func(ctx context.Context) error
+	Logger            *logrus.Logger
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/api/export":
+		if r.Method != http.MethodGet {
+			http.Error(w, "only GET requests are supported", http.StatusMethodNotAllowed)
+			return
+		}
+		if h.Token != "" && !h.authorized(w, r) {
+			return
+		}
+		h.handleExport(w, r)
+	case "/api/identities":
+		h.handleIdentities(w, r)
+	case "/api/teams":
+		if r.Method != http.MethodGet {
+			http.Error(w, "only GET requests are supported", http.StatusMethodNotAllowed)
+			return
+		}
+		h.handleTeams(w, r)
+	case "/api/repositories":
+		if r.Method != http.MethodGet {
+			http.Error(w, "only GET requests are supported", http.StatusMethodNotAllowed)
+			return
+		}
+		h.handleRepositories(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// authorized checks the bearer token of a request - a write request, or an export when a token is set - and replies with an error if it is missing or invalid
+func (h *Handler) authorized(w http.ResponseWriter, r *http.Request) bool {
+	if h.Token == "" {
+		http.Error(w, "the API is read-only, because no API token is set", http.StatusForbidden)
+		return false
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(h.Token)) != 1 {
+		http.Error(w, "invalid API token", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// handleExport streams a dataset - see store.Datasets - in the requested format.
+// Query parameters: dataset, format (defaults to csv), owner, from, to and exclude_bots.
+func (h *Handler) handleExport(w http.ResponseWriter, r *http.Request) {
+	if h.Store.Export == nil {
+		http.Error(w, "the storage doesn't support exports", http.StatusNotImplemented)
+		return
+	}
+
+	var (
+		query   = r.URL.Query()
+		dataset = query.Get("dataset")
+	)
+	format, err := export.ParseFormat(query.Get("format"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	log := h.Logger.WithField("dataset", dataset).WithField("format", format)
+
+	filter, err := export.NewFilter(query.Get("owner"), query.Get("from"), query.Get("to"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if excludeBots := query.Get("exclude_bots"); excludeBots != "" {
+		if filter.ExcludeBots, err = strconv.ParseBool(excludeBots); err != nil {
+			http.Error(w, fmt.Sprintf("invalid exclude_bots parameter: %s", err), http.StatusBadRequest)
+			return
+		}
+	}
+	if _, err = store.DatasetColumns(dataset); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("%s.%s", dataset, format)))
+	log.Debug("Exporting dataset")
+	err = export.Export(r.Context(), h.Store.Export, dataset, format, filter, w)
+	if err != nil {
+		// the headers have already been sent, so we can't reply with an error status
+		log.WithError(err).Error("Failed to export dataset")
+	}
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jenkins-x/cd-indicators/store/memory"
+	"github.com/sirupsen/logrus"
+)
+
+func TestExportAuthentication(t *testing.T) {
+	tests := []struct {
+		name          string
+		token         string
+		authorization string
+		expected      int
+	}{
+		{name: "no token set", expected: http.StatusOK},
+		{name: "missing token", token: "secret", expected: http.StatusUnauthorized},
+		{name: "invalid token", token: "secret", authorization: "Bearer other", expected: http.StatusUnauthorized},
+		{name: "valid token", token: "secret", authorization: "Bearer secret", expected: http.StatusOK},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h := &Handler{
+				Store:  memory.New(),
+				Token:  test.token,
+				Logger: logrus.New(),
+			}
+			req := httptest.NewRequest(http.MethodGet, "/api/export?dataset=pipelines", nil)
+			if test.authorization != "" {
+				req.Header.Set("Authorization", test.authorization)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != test.expected {
+				t.Errorf("expected status %d, got %d: %s", test.expected, rec.Code, rec.Body)
+			}
+		})
+	}
+}
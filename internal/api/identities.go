@@ -0,0 +1,86 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/jenkins-x/cd-indicators/store"
+)
+
+// handleIdentities manages the identities mapping the git user logins to canonical persons and teams:
+//   - GET lists the identities, as a JSON array
+//   - PUT adds or replaces the identity of a person, given as a JSON object
+//   - DELETE deletes the identity of the person given as query parameter
+//
+// The identities of the config file are not listed: they can only be changed in the config file.
+func (h *Handler) handleIdentities(w http.ResponseWriter, r *http.Request) {
+	if h.Store.Identities == nil {
+		http.Error(w, "the storage doesn't support identities", http.StatusNotImplemented)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		identities, err := h.Store.Identities.List(r.Context())
+		if err != nil {
+			h.Logger.WithError(err).Error("Failed to list the identities")
+			http.Error(w, "failed to list the identities", http.StatusInternalServerError)
+			return
+		}
+		if identities == nil {
+			identities = []store.Identity{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err = json.NewEncoder(w).Encode(identities); err != nil {
+			h.Logger.WithError(err).Error("Failed to write the identities")
+		}
+		return
+
+	case http.MethodPut:
+		if !h.authorized(w, r) {
+			return
+		}
+		var identity store.Identity
+		if err := json.NewDecoder(r.Body).Decode(&identity); err != nil {
+			http.Error(w, "invalid identity: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := identity.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := h.Store.Identities.Put(r.Context(), identity); err != nil {
+			h.Logger.WithField("person", identity.Person).WithError(err).Error("Failed to put the identity")
+			http.Error(w, "failed to put the identity", http.StatusInternalServerError)
+			return
+		}
+		h.Logger.WithField("person", identity.Person).WithField("aliases", identity.Aliases).Info("Identity updated")
+
+	case http.MethodDelete:
+		if !h.authorized(w, r) {
+			return
+		}
+		person := r.URL.Query().Get("person")
+		if person == "" {
+			http.Error(w, "the person query parameter is required", http.StatusBadRequest)
+			return
+		}
+		if err := h.Store.Identities.Delete(r.Context(), person); err != nil {
+			h.Logger.WithField("person", person).WithError(err).Error("Failed to delete the identity")
+			http.Error(w, "failed to delete the identity", http.StatusInternalServerError)
+			return
+		}
+		h.Logger.WithField("person", person).Info("Identity deleted")
+
+	default:
+		http.Error(w, "only GET, PUT and DELETE requests are supported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.IdentitiesChanged != nil {
+		if err := h.IdentitiesChanged(r.Context()); err != nil {
+			h.Logger.WithError(err).Error("Failed to reload the identities")
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/jenkins-x/cd-indicators/internal/export"
+	"github.com/jenkins-x/cd-indicators/store"
+)
+
+// The values of the "status" query parameter of the repositories
+const (
+	repositoryStatusInactive      = "inactive"
+	repositoryStatusNeverDeployed = "never_deployed"
+)
+
+// handleRepositories lists the repositories of the inventory with the time of their latest activity, as a JSON array.
+// Query parameters: owner, and status - "inactive" for the repositories without activity since the "since" time
+// (defaults to 90 days ago), or "never_deployed" for the repositories without any deployment.
+func (h *Handler) handleRepositories(w http.ResponseWriter, r *http.Request) {
+	if h.Store.Repositories == nil {
+		http.Error(w, "the storage doesn't support repositories", http.StatusNotImplemented)
+		return
+	}
+
+	query := r.URL.Query()
+	filter, err := export.NewFilter(query.Get("owner"), query.Get("since"), "")
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid since parameter: %s", err), http.StatusBadRequest)
+		return
+	}
+	var matches func(a store.RepositoryActivity) bool
+	switch status := query.Get("status"); status {
+	case "":
+		matches = func(store.RepositoryActivity) bool { return true }
+	case repositoryStatusInactive:
+		matches = func(a store.RepositoryActivity) bool { return a.InactiveSince(filter.From) }
+	case repositoryStatusNeverDeployed:
+		matches = store.RepositoryActivity.NeverDeployed
+	default:
+		http.Error(w, fmt.Sprintf("invalid status %q: must be %s or %s", status, repositoryStatusInactive, repositoryStatusNeverDeployed), http.StatusBadRequest)
+		return
+	}
+
+	activities, err := h.Store.Repositories.ListActivity(r.Context(), filter.Owner)
+	if err != nil {
+		h.Logger.WithError(err).Error("Failed to list the repositories")
+		http.Error(w, "failed to list the repositories", http.StatusInternalServerError)
+		return
+	}
+	filtered := []store.RepositoryActivity{}
+	for _, a := range activities {
+		if matches(a) {
+			filtered = append(filtered, a)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(filtered); err != nil {
+		h.Logger.WithError(err).Error("Failed to write the repositories")
+	}
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/jenkins-x/cd-indicators/store"
+)
+
+// handleTeams lists the repositories of the teams, as found by all the sources, as a JSON array.
+// The "team" query parameter restricts them to a single team.
+func (h *Handler) handleTeams(w http.ResponseWriter, r *http.Request) {
+	if h.Store.Teams == nil {
+		http.Error(w, "the storage doesn't support teams", http.StatusNotImplemented)
+		return
+	}
+
+	mappings, err := h.Store.Teams.List(r.Context())
+	if err != nil {
+		h.Logger.WithError(err).Error("Failed to list the team repositories")
+		http.Error(w, "failed to list the team repositories", http.StatusInternalServerError)
+		return
+	}
+	team := r.URL.Query().Get("team")
+	filtered := []store.TeamRepository{}
+	for _, m := range mappings {
+		if team == "" || m.Team == team {
+			filtered = append(filtered, m)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(filtered); err != nil {
+		h.Logger.WithError(err).Error("Failed to write the team repositories")
+	}
+}
@@ -0,0 +1,276 @@
+package cdevents
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// typePrefix is the prefix of the types of the CDEvents: dev.cdevents.<subject>.<predicate>.<version>
+const typePrefix = "dev.cdevents."
+
+// The subjects and predicates of the CDEvents which are collected - or published, for the merged changes
+const (
+	SubjectPipelineRun = "pipelinerun"
+	SubjectArtifact    = "artifact"
+	SubjectService     = "service"
+	SubjectIncident    = "incident"
+	SubjectChange      = "change"
+
+	PredicateQueued     = "queued"
+	PredicateStarted    = "started"
+	PredicateFinished   = "finished"
+	PredicatePublished  = "published"
+	PredicateDeployed   = "deployed"
+	PredicateUpgraded   = "upgraded"
+	PredicateRolledBack = "rolledback"
+	PredicateDetected   = "detected"
+	PredicateReported   = "reported"
+	PredicateResolved   = "resolved"
+	PredicateMerged     = "merged"
+)
+
+// The outcomes of the finished pipeline runs
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+	OutcomeError   = "error"
+	OutcomeCancel  = "cancel"
+)
+
+// Event is a CDEvent, see https://cdevents.dev
+type Event struct {
+	Context Context `json:"context"`
+	Subject struct {
+		ID      string          `json:"id"`
+		Source  string          `json:"source,omitempty"`
+		Type    string          `json:"type,omitempty"`
+		Content json.RawMessage `json:"content,omitempty"`
+	} `json:"subject"`
+	// CustomData holds the data of the producer which is not part of the CDEvents specification,
+	// such as the git repository of a pipeline run - see CustomData
+	CustomData            json.RawMessage `json:"customData,omitempty"`
+	CustomDataContentType string          `json:"customDataContentType,omitempty"`
+}
+
+// Context is the context of a CDEvent
+type Context struct {
+	Version   string    `json:"specversion"`
+	ID        string    `json:"id"`
+	Source    string    `json:"source"`
+	Type      string    `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// UnmarshalJSON also accepts the version field of the events of the specification before 0.4, renamed to specversion
+func (c *Context) UnmarshalJSON(data []byte) error {
+	type context Context
+	var legacy struct {
+		context
+		LegacyVersion string `json:"version"`
+	}
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return err
+	}
+	*c = Context(legacy.context)
+	if c.Version == "" {
+		c.Version = legacy.LegacyVersion
+	}
+	return nil
+}
+
+// Kind returns the subject and predicate of the type of the event, such as "pipelinerun" and "finished" -
+// or empty strings if it is not a CDEvent type
+func (e Event) Kind() (subject, predicate string) {
+	if !strings.HasPrefix(e.Context.Type, typePrefix) {
+		return "", ""
+	}
+	parts := strings.SplitN(strings.TrimPrefix(e.Context.Type, typePrefix), ".", 3)
+	if len(parts) < 2 {
+		return "", ""
+	}
+	return parts[0], parts[1]
+}
+
+// SubjectSource returns the source of the subject, which defaults to the source of the event
+func (e Event) SubjectSource() string {
+	if e.Subject.Source != "" {
+		return e.Subject.Source
+	}
+	return e.Context.Source
+}
+
+func (e Event) String() string {
+	return fmt.Sprintf("%s %s from %s", e.Context.Type, e.Context.ID, e.Context.Source)
+}
+
+// PipelineRunContent is the subject content of the pipelinerun events
+type PipelineRunContent struct {
+	PipelineName string `json:"pipelineName"`
+	URL          string `json:"url,omitempty"`
+	Outcome      string `json:"outcome"`
+	Errors       string `json:"errors,omitempty"`
+}
+
+// ServiceContent is the subject content of the service events
+type ServiceContent struct {
+	Environment Reference `json:"environment"`
+	ArtifactID  string    `json:"artifactId"`
+}
+
+// IncidentContent is the subject content of the incident events
+type IncidentContent struct {
+	Description string    `json:"description,omitempty"`
+	Environment Reference `json:"environment"`
+	Service     Reference `json:"service,omitempty"`
+	ArtifactID  string    `json:"artifactId,omitempty"`
+}
+
+// ChangeContent is the subject content of the change events
+type ChangeContent struct {
+	Repository Reference `json:"repository"`
+}
+
+// Reference is a reference to the subject of another event, such as the environment of a service
+type Reference struct {
+	ID     string `json:"id"`
+	Source string `json:"source,omitempty"`
+}
+
+// CustomData are the fields of the custom data of the events which complete the CDEvents specification:
+// the CDEvents don't link the pipeline runs and artifacts to a git repository and its contributors
+type CustomData struct {
+	// Repository is the git repository, as "owner/repository" or a git URL
+	Repository  string `json:"repository,omitempty"`
+	PullRequest int    `json:"pullRequest,omitempty"`
+	// Context is the name of the pipeline in the repository. Default: the name of the pipeline
+	Context string `json:"context,omitempty"`
+	// Build is the build number of a pipeline run. Default: the number ending its subject id, or else a hash of it
+	Build  int    `json:"build,omitempty"`
+	Author string `json:"author,omitempty"`
+	// StartTime is the start time of a pipeline run: the finished event has only its end time.
+	// Default: the time of its started - or else queued - event
+	StartTime    *time.Time `json:"startTime,omitempty"`
+	Contributors []string   `json:"contributors,omitempty"`
+}
+
+// Content unmarshals the content of the subject
+func (e Event) Content(content interface{}) error {
+	if len(e.Subject.Content) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(e.Subject.Content, content); err != nil {
+		return fmt.Errorf("failed to unmarshal the subject content: %w", err)
+	}
+	return nil
+}
+
+// Custom returns the custom data of the event - empty if it has none, or if it is not a JSON object
+func (e Event) Custom() CustomData {
+	var data CustomData
+	if !strings.HasPrefix(strings.TrimSpace(string(e.CustomData)), "{") {
+		return data
+	}
+	// the custom data is free-form: the fields of another type are ignored
+	_ = json.Unmarshal(e.CustomData, &data)
+	return data
+}
+
+// Repository splits an "owner/repository" or a git URL - such as https://github.com/owner/repository.git - into its owner and name
+func Repository(value string) (owner, repository string) {
+	value = strings.TrimSuffix(strings.TrimSuffix(value, "/"), ".git")
+	if u, err := url.Parse(value); err == nil && u.Host != "" {
+		value = strings.Trim(u.Path, "/")
+	} else if i := strings.Index(value, ":"); i >= 0 && strings.Contains(value[:i], "@") {
+		// scp-like URL: git@github.com:owner/repository
+		value = value[i+1:]
+	}
+	i := strings.LastIndex(value, "/")
+	if i <= 0 || i == len(value)-1 {
+		return "", ""
+	}
+	owner = value[:i]
+	if j := strings.LastIndex(owner, "/"); j >= 0 {
+		owner = owner[j+1:]
+	}
+	return owner, value[i+1:]
+}
+
+// PackageURL is a parsed package URL - such as pkg:oci/myapp@sha256:1234?repository_url=ghcr.io/acme/myapp&tag=1.2.3 -
+// which identifies the artifacts in the CDEvents, see https://github.com/package-url/purl-spec
+type PackageURL struct {
+	Type       string
+	Namespace  string
+	Name       string
+	Version    string
+	Qualifiers url.Values
+}
+
+// ParsePackageURL parses a package URL
+func ParsePackageURL(value string) (PackageURL, error) {
+	var p PackageURL
+	rest, found := strings.CutPrefix(value, "pkg:")
+	if !found {
+		return p, fmt.Errorf("invalid package URL %q: no pkg scheme", value)
+	}
+	rest, _, _ = strings.Cut(rest, "#")
+	rest, query, _ := strings.Cut(rest, "?")
+	qualifiers, err := url.ParseQuery(query)
+	if err != nil {
+		return p, fmt.Errorf("invalid package URL %q qualifiers: %w", value, err)
+	}
+	p.Qualifiers = qualifiers
+	if i := strings.LastIndex(rest, "@"); i >= 0 {
+		if p.Version, err = url.PathUnescape(rest[i+1:]); err != nil {
+			return p, fmt.Errorf("invalid package URL %q version: %w", value, err)
+		}
+		rest = rest[:i]
+	}
+	segments := strings.Split(strings.Trim(rest, "/"), "/")
+	if len(segments) < 2 || segments[0] == "" || segments[len(segments)-1] == "" {
+		return p, fmt.Errorf("invalid package URL %q: no type and name", value)
+	}
+	p.Type = strings.ToLower(segments[0])
+	if p.Name, err = url.PathUnescape(segments[len(segments)-1]); err != nil {
+		return p, fmt.Errorf("invalid package URL %q name: %w", value, err)
+	}
+	if len(segments) > 2 {
+		if p.Namespace, err = url.PathUnescape(strings.Join(segments[1:len(segments)-1], "/")); err != nil {
+			return p, fmt.Errorf("invalid package URL %q namespace: %w", value, err)
+		}
+	}
+	return p, nil
+}
+
+// Owner returns the owner of the package: the last segment of its namespace, or else of the path of its repository_url qualifier
+func (p PackageURL) Owner() string {
+	namespace := p.Namespace
+	if namespace == "" {
+		repositoryURL := p.Qualifiers.Get("repository_url")
+		if i := strings.Index(repositoryURL, "://"); i >= 0 {
+			repositoryURL = repositoryURL[i+3:]
+		}
+		segments := strings.Split(strings.Trim(repositoryURL, "/"), "/")
+		// the first segment is the registry, and the last one is usually the name
+		if len(segments) >= 3 && segments[len(segments)-1] == p.Name {
+			namespace = segments[len(segments)-2]
+		} else if len(segments) >= 2 && segments[len(segments)-1] != p.Name {
+			namespace = segments[len(segments)-1]
+		}
+	}
+	if i := strings.LastIndex(namespace, "/"); i >= 0 {
+		namespace = namespace[i+1:]
+	}
+	return namespace
+}
+
+// PackageVersion returns the version of the package: its tag qualifier, or else its version unless it is a digest
+func (p PackageURL) PackageVersion() string {
+	version := p.Qualifiers.Get("tag")
+	if version == "" && !strings.Contains(p.Version, ":") {
+		version = p.Version
+	}
+	return strings.TrimPrefix(version, "v")
+}
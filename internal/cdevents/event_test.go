@@ -0,0 +1,34 @@
+package cdevents
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestContextVersion(t *testing.T) {
+	for _, data := range []string{
+		`{"context": {"specversion": "0.4.1", "id": "1", "type": "dev.cdevents.service.deployed.0.2.0"}}`,
+		// before 0.4 of the specification
+		`{"context": {"version": "0.4.1", "id": "1", "type": "dev.cdevents.service.deployed.0.2.0"}}`,
+	} {
+		var event Event
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			t.Fatal(err)
+		}
+		if event.Context.Version != "0.4.1" || event.Context.ID != "1" || event.Context.Type != "dev.cdevents.service.deployed.0.2.0" {
+			t.Errorf("expected the context of %s, got %+v", data, event.Context)
+		}
+	}
+
+	data, err := json.Marshal(Event{Context: Context{Version: specVersion}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var published map[string]map[string]any
+	if err := json.Unmarshal(data, &published); err != nil {
+		t.Fatal(err)
+	}
+	if published["context"]["specversion"] != specVersion || published["context"]["version"] != nil {
+		t.Errorf("expected the events to be published with a specversion, got %s", data)
+	}
+}
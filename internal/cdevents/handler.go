@@ -0,0 +1,184 @@
+package cdevents
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// maxBodySize is the maximum size of a request, which may be a batch of events
+	maxBodySize = 10 << 20
+
+	contentTypeStructured = "application/cloudevents+json"
+	contentTypeBatch      = "application/cloudevents-batch+json"
+)
+
+// HandlerFunc processes a CDEvent. It is retried by the sender if it fails, so it must be idempotent
+type HandlerFunc func(ctx context.Context, event Event) error
+
+// CloudEvent is a CloudEvent in the structured JSON format, carrying a CDEvent as its data
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Time            string          `json:"time,omitempty"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+	DataBase64      string          `json:"data_base64,omitempty"`
+}
+
+// Handler receives the CloudEvents carrying CDEvents, with the HTTP protocol binding of CloudEvents:
+// in the binary, structured or batched content modes, see https://github.com/cloudevents/spec/blob/main/cloudevents/bindings/http-protocol-binding.md
+type Handler struct {
+	// Token is optional: when set, the requests must be authenticated with it, as a bearer token
+	Token  string
+	Logger *logrus.Logger
+
+	handlers []HandlerFunc
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST requests are supported", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.Token != "" {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(h.Token)) != 1 {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	events, err := parseRequest(w, r)
+	if err != nil {
+		h.Logger.WithField("UA", r.Header.Get("User-Agent")).WithError(err).Error("Failed to parse CDEvents")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var failed int
+	for _, event := range events {
+		log := h.Logger.WithField("type", event.Context.Type).WithField("id", event.Context.ID).WithField("source", event.Context.Source)
+		log.Trace("Handling CDEvent")
+		for _, handler := range h.handlers {
+			if err := handler(r.Context(), event); err != nil {
+				log.WithError(err).Error("Failed to process CDEvent")
+				failed++
+			}
+		}
+	}
+	if failed > 0 {
+		// the events are redelivered by the sender, and the handlers are idempotent
+		http.Error(w, fmt.Sprintf("failed to process %d CDEvents", failed), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// RegisterHandler registers a function processing each received CDEvent
+func (h *Handler) RegisterHandler(handler HandlerFunc) {
+	h.handlers = append(h.handlers, handler)
+}
+
+// parseRequest returns the CDEvents of a request, whatever its content mode
+func parseRequest(w http.ResponseWriter, r *http.Request) ([]Event, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the request body: %w", err)
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	switch {
+	case mediaType == contentTypeStructured:
+		var ce CloudEvent
+		if err := json.Unmarshal(body, &ce); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal the CloudEvent: %w", err)
+		}
+		event, err := ce.event()
+		if err != nil {
+			return nil, err
+		}
+		return []Event{event}, nil
+	case mediaType == contentTypeBatch:
+		var ces []CloudEvent
+		if err := json.Unmarshal(body, &ces); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal the CloudEvents batch: %w", err)
+		}
+		events := make([]Event, 0, len(ces))
+		for _, ce := range ces {
+			event, err := ce.event()
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, event)
+		}
+		return events, nil
+	case r.Header.Get("Ce-Specversion") != "":
+		// binary mode: the attributes are headers, and the body is the data
+		ce := CloudEvent{
+			SpecVersion: r.Header.Get("Ce-Specversion"),
+			ID:          r.Header.Get("Ce-Id"),
+			Source:      r.Header.Get("Ce-Source"),
+			Type:        r.Header.Get("Ce-Type"),
+			Time:        r.Header.Get("Ce-Time"),
+			Data:        body,
+		}
+		event, err := ce.event()
+		if err != nil {
+			return nil, err
+		}
+		return []Event{event}, nil
+	default:
+		return nil, errors.New("the request is not a CloudEvent: no ce-specversion header, and no CloudEvents content type")
+	}
+}
+
+// event returns the CDEvent carried by the CloudEvent, completing its context with the CloudEvent attributes
+func (ce CloudEvent) event() (Event, error) {
+	var event Event
+	if ce.SpecVersion == "" || ce.Type == "" {
+		return event, errors.New("invalid CloudEvent: no specversion or type")
+	}
+	data := []byte(ce.Data)
+	if ce.DataBase64 != "" {
+		var err error
+		if data, err = base64.StdEncoding.DecodeString(ce.DataBase64); err != nil {
+			return event, fmt.Errorf("failed to decode the data of CloudEvent %s: %w", ce.ID, err)
+		}
+	}
+	if len(data) == 0 {
+		return event, fmt.Errorf("CloudEvent %s has no data", ce.ID)
+	}
+	if err := json.Unmarshal(data, &event); err != nil {
+		return event, fmt.Errorf("failed to unmarshal the CDEvent of CloudEvent %s: %w", ce.ID, err)
+	}
+
+	if event.Context.Type == "" {
+		event.Context.Type = ce.Type
+	}
+	if event.Context.ID == "" {
+		event.Context.ID = ce.ID
+	}
+	if event.Context.Source == "" {
+		event.Context.Source = ce.Source
+	}
+	if event.Context.Timestamp.IsZero() {
+		if t, err := time.Parse(time.RFC3339Nano, ce.Time); err == nil {
+			event.Context.Timestamp = t
+		}
+	}
+	return event, nil
+}
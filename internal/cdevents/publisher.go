@@ -0,0 +1,264 @@
+package cdevents
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/cd-indicators/store"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// specVersion is the version of the CDEvents specification of the published events
+	specVersion = "0.4.1"
+
+	typePipelineRunFinished = typePrefix + SubjectPipelineRun + "." + PredicateFinished + ".0.2.0"
+	typeArtifactPublished   = typePrefix + SubjectArtifact + "." + PredicatePublished + ".0.2.0"
+	typeServiceDeployed     = typePrefix + SubjectService + "." + PredicateDeployed + ".0.2.0"
+	typeChangeMerged        = typePrefix + SubjectChange + "." + PredicateMerged + ".0.2.0"
+
+	// queueSize is the number of events waiting to be sent, above which the new events are dropped
+	queueSize = 1000
+	// maxAttempts is the number of attempts to send an event before dropping it
+	maxAttempts = 5
+	// retryDelay is the delay before the second attempt to send an event, doubled at each attempt
+	retryDelay = 1 * time.Second
+)
+
+// Publisher publishes the pipelines, releases and deployments stored for the first time - and the merged pull requests -
+// as CDEvents sent to a sink: pipelinerun.finished, artifact.published, service.deployed and change.merged events.
+// The git repository, build and contributors are in their custom data, as expected by the CDEvents endpoint - see CustomData.
+// The events are sent in the background, in order: they are dropped when the sink is too slow - or down - and on shutdown.
+// Their id is derived from the entity, so that the consumers can ignore the duplicates.
+type Publisher struct {
+	Notifier *store.Notifier
+	Sink     Sink
+	// Source identifies the collector in the events
+	Source string
+	// GitKind and GitServer are the kind and URL of the git server of the repositories, used in the package URLs of their artifacts
+	GitKind   string
+	GitServer string
+	Logger    *logrus.Logger
+
+	queue chan Event
+}
+
+func (p *Publisher) Start(ctx context.Context) error {
+	if p.Notifier == nil {
+		return errors.New("the storage doesn't notify the stored entities")
+	}
+	p.queue = make(chan Event, queueSize)
+	p.Notifier.Register(p)
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event := <-p.queue:
+				p.send(ctx, event)
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (p *Publisher) PipelineAdded(_ context.Context, pipeline store.Pipeline) {
+	event := p.newEvent(typePipelineRunFinished, pipelineRunID(pipeline), pipeline.EndTime)
+	content := PipelineRunContent{
+		PipelineName: pipeline.Context,
+		Outcome:      pipelineRunOutcome(pipeline.Status),
+	}
+	startTime := pipeline.StartTime
+	custom := CustomData{
+		Repository:  pipeline.Owner + "/" + pipeline.Repository,
+		PullRequest: pipeline.PullRequest,
+		Context:     pipeline.Context,
+		Build:       pipeline.Build,
+		Author:      pipeline.Author,
+		StartTime:   &startTime,
+	}
+	p.publish(event, content, custom)
+}
+
+func (p *Publisher) ReleaseAdded(_ context.Context, r store.Release) {
+	event := p.newEvent(typeArtifactPublished, p.artifactID(r.Owner, r.Repository, r.Version), r.ReleaseTime)
+	custom := CustomData{
+		Repository:   r.Owner + "/" + r.Repository,
+		Contributors: r.Contributors,
+	}
+	p.publish(event, struct{}{}, custom)
+}
+
+func (p *Publisher) DeploymentAdded(_ context.Context, d store.Deployment) {
+	event := p.newEvent(typeServiceDeployed, d.Owner+"/"+d.Repository, d.DeploymentTime)
+	content := ServiceContent{
+		Environment: Reference{ID: d.Environment},
+		ArtifactID:  p.artifactID(d.Owner, d.Repository, d.Version),
+	}
+	custom := CustomData{
+		Repository: d.Owner + "/" + d.Repository,
+	}
+	// the same version may be deployed to several environments
+	event.Context.ID = eventID(event.Context.Type, content.ArtifactID, d.Environment)
+	p.publish(event, content, custom)
+}
+
+func (p *Publisher) PullRequestMerged(_ context.Context, pr store.PullRequest) {
+	var mergedTime time.Time
+	if pr.MergedTime != nil {
+		mergedTime = *pr.MergedTime
+	}
+	repository := pr.Owner + "/" + pr.Repository
+	event := p.newEvent(typeChangeMerged, fmt.Sprintf("%s/pulls/%d", repository, pr.PullRequest), mergedTime)
+	content := ChangeContent{
+		Repository: Reference{ID: repository},
+	}
+	custom := CustomData{
+		Repository:  repository,
+		PullRequest: pr.PullRequest,
+		Author:      pr.Author,
+	}
+	p.publish(event, content, custom)
+}
+
+// newEvent returns an event of the given type about a subject, whose id is derived from them
+func (p *Publisher) newEvent(eventType, subjectID string, timestamp time.Time) Event {
+	var event Event
+	event.Context.Version = specVersion
+	event.Context.ID = eventID(eventType, subjectID)
+	event.Context.Source = p.Source
+	event.Context.Type = eventType
+	event.Context.Timestamp = timestamp.In(time.UTC)
+	if timestamp.IsZero() {
+		event.Context.Timestamp = time.Now().UTC()
+	}
+	event.Subject.ID = subjectID
+	event.Subject.Source = p.Source
+	return event
+}
+
+// publish completes the event with its content and custom data, and queues it - or drops it if the queue is full
+func (p *Publisher) publish(event Event, content interface{}, custom CustomData) {
+	log := p.Logger.WithField("type", event.Context.Type).WithField("id", event.Context.ID).WithField("subject", event.Subject.ID)
+
+	var err error
+	if event.Subject.Content, err = json.Marshal(content); err != nil {
+		log.WithError(err).Error("Failed to marshal the content of the CDEvent")
+		return
+	}
+	if event.CustomData, err = json.Marshal(custom); err != nil {
+		log.WithError(err).Error("Failed to marshal the custom data of the CDEvent")
+		return
+	}
+	event.CustomDataContentType = "application/json"
+
+	select {
+	case p.queue <- event:
+		log.Trace("Queued CDEvent")
+	default:
+		log.Error("Dropping CDEvent: the queue is full")
+	}
+}
+
+// send sends an event to the sink, retrying with an exponential backoff
+func (p *Publisher) send(ctx context.Context, event Event) {
+	log := p.Logger.WithField("type", event.Context.Type).WithField("id", event.Context.ID).WithField("subject", event.Subject.ID)
+
+	ce, err := newCloudEvent(event)
+	if err != nil {
+		log.WithError(err).Error("Failed to create the CloudEvent")
+		return
+	}
+	delay := retryDelay
+	for attempt := 1; ; attempt++ {
+		err = p.Sink.Send(ctx, ce)
+		if err == nil {
+			log.Debug("Published CDEvent")
+			return
+		}
+		if attempt == maxAttempts {
+			log.WithError(err).WithField("attempts", attempt).Error("Dropping CDEvent: failed to publish it")
+			return
+		}
+		log.WithError(err).WithField("attempt", attempt).Warning("Failed to publish CDEvent, retrying")
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// newCloudEvent returns the CloudEvent carrying a CDEvent, whose attributes are the ones of the context of the CDEvent
+func newCloudEvent(event Event) (CloudEvent, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return CloudEvent{}, fmt.Errorf("failed to marshal the CDEvent %s: %w", event, err)
+	}
+	return CloudEvent{
+		SpecVersion:     "1.0",
+		ID:              event.Context.ID,
+		Source:          event.Context.Source,
+		Type:            event.Context.Type,
+		Time:            event.Context.Timestamp.Format(time.RFC3339Nano),
+		DataContentType: "application/json",
+		Data:            data,
+	}, nil
+}
+
+// eventID returns a stable id for an event: the same entity always gives the same id
+func eventID(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\n")))
+	return hex.EncodeToString(sum[:16])
+}
+
+// pipelineRunID returns the id of the pipeline run of a pipeline
+func pipelineRunID(pipeline store.Pipeline) string {
+	if pipeline.PullRequest > 0 {
+		return fmt.Sprintf("%s/%s/PR-%d/%s/%d", pipeline.Owner, pipeline.Repository, pipeline.PullRequest, pipeline.Context, pipeline.Build)
+	}
+	return fmt.Sprintf("%s/%s/%s/%d", pipeline.Owner, pipeline.Repository, pipeline.Context, pipeline.Build)
+}
+
+// artifactID returns the package URL of a version of a git repository: of the github or bitbucket type for the repositories
+// of github.com or bitbucket.org, and else of the generic type, with the URL of the repository in its repository_url qualifier
+func (p *Publisher) artifactID(owner, repository, version string) string {
+	purl := fmt.Sprintf("%s/%s@%s", url.PathEscape(owner), url.PathEscape(repository), url.PathEscape(version))
+	server := strings.TrimSuffix(p.GitServer, "/")
+	switch {
+	case p.GitKind == "github" && (server == "" || server == "https://github.com"):
+		return "pkg:github/" + purl
+	case p.GitKind == "bitbucketcloud" && (server == "" || server == "https://bitbucket.org"):
+		return "pkg:bitbucket/" + purl
+	}
+	if i := strings.Index(server, "://"); i >= 0 {
+		server = server[i+3:]
+	}
+	qualifiers := url.Values{"repository_url": {server + "/" + owner + "/" + repository}}
+	return "pkg:generic/" + purl + "?" + qualifiers.Encode()
+}
+
+// pipelineRunOutcome maps the status of a pipeline to the outcome of a finished pipeline run
+func pipelineRunOutcome(status string) string {
+	switch jenkinsv1.ActivityStatusType(status) {
+	case jenkinsv1.ActivityStatusTypeSucceeded:
+		return OutcomeSuccess
+	case jenkinsv1.ActivityStatusTypeCancelled, jenkinsv1.ActivityStatusTypeAborted:
+		return OutcomeCancel
+	case jenkinsv1.ActivityStatusTypeError:
+		return OutcomeError
+	default:
+		return OutcomeFailure
+	}
+}
@@ -0,0 +1,143 @@
+package cdevents
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/jenkins-x/cd-indicators/store/memory"
+	"github.com/sirupsen/logrus"
+)
+
+func TestPublisherSendsTheStoredEntitiesToTheSink(t *testing.T) {
+	var (
+		requests atomic.Int32
+		received = make(chan CloudEvent, 10)
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" || r.Header.Get("Content-Type") != contentTypeStructured {
+			t.Errorf("expected a structured CloudEvent authenticated with the token, got %v", r.Header)
+		}
+		// the sink is down for the first request: retried
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var ce CloudEvent
+		if err := json.NewDecoder(r.Body).Decode(&ce); err != nil {
+			t.Errorf("failed to decode the CloudEvent: %v", err)
+		}
+		received <- ce
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := memory.New()
+	p := &Publisher{
+		Notifier:  s.Notifier,
+		Sink:      &HTTPSink{URL: server.URL, Token: "secret"},
+		Source:    "cd-indicators/jx",
+		GitKind:   "github",
+		GitServer: "https://github.com",
+		Logger:    logrus.New(),
+	}
+	if err := p.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	released := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	release := store.Release{Owner: "org", Repository: "app", Version: "1.2.3", Contributors: []string{"alice"}, ReleaseTime: released}
+	for _, r := range []store.Release{release, release} {
+		if err := s.Releases.Add(ctx, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Deployments.Add(ctx, store.Deployment{Owner: "org", Repository: "app", Version: "1.2.3", Environment: "production", DeploymentTime: released.Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+
+	var events []Event
+	for len(events) < 2 {
+		select {
+		case ce := <-received:
+			if ce.SpecVersion != "1.0" || ce.Source != "cd-indicators/jx" || ce.DataContentType != "application/json" {
+				t.Errorf("expected a CloudEvent of the collector with a JSON CDEvent, got %+v", ce)
+			}
+			var event Event
+			if err := json.Unmarshal(ce.Data, &event); err != nil {
+				t.Fatal(err)
+			}
+			if ce.ID != event.Context.ID || ce.Type != event.Context.Type {
+				t.Errorf("expected the attributes of the CloudEvent to be the context of the CDEvent, got %+v and %+v", ce, event.Context)
+			}
+			events = append(events, event)
+		case <-time.After(10 * time.Second):
+			t.Fatalf("timed out waiting for the CDEvents, got %d", len(events))
+		}
+	}
+
+	// the release is published once, then its deployment - in order
+	published, deployed := events[0], events[1]
+	if published.Context.Type != typeArtifactPublished || published.Context.Version != specVersion || published.Subject.ID != "pkg:github/org/app@1.2.3" {
+		t.Errorf("expected the artifact.published event of pkg:github/org/app@1.2.3, got %+v and %+v", published.Context, published.Subject)
+	}
+	if !published.Context.Timestamp.Equal(released) {
+		t.Errorf("expected the event at the release time, got %s", published.Context.Timestamp)
+	}
+	var custom CustomData
+	if err := json.Unmarshal(published.CustomData, &custom); err != nil {
+		t.Fatal(err)
+	}
+	if custom.Repository != "org/app" || len(custom.Contributors) != 1 || custom.Contributors[0] != "alice" {
+		t.Errorf("expected the repository and contributors of the release in the custom data, got %+v", custom)
+	}
+
+	if deployed.Context.Type != typeServiceDeployed {
+		t.Errorf("expected a service.deployed event, got %s", deployed.Context.Type)
+	}
+	var content ServiceContent
+	if err := json.Unmarshal(deployed.Subject.Content, &content); err != nil {
+		t.Fatal(err)
+	}
+	if content.Environment.ID != "production" || content.ArtifactID != "pkg:github/org/app@1.2.3" {
+		t.Errorf("expected the deployment of pkg:github/org/app@1.2.3 to production, got %+v", content)
+	}
+	if n := requests.Load(); n != 3 {
+		t.Errorf("expected the first request to be retried, got %d requests", n)
+	}
+}
+
+func TestArtifactID(t *testing.T) {
+	tests := []struct {
+		gitKind   string
+		gitServer string
+		expected  string
+	}{
+		{gitKind: "github", gitServer: "https://github.com", expected: "pkg:github/org/app@v1.2.3"},
+		{gitKind: "github", gitServer: "https://github.example.com", expected: "pkg:generic/org/app@v1.2.3?repository_url=github.example.com%2Forg%2Fapp"},
+		{gitKind: "bitbucketcloud", gitServer: "https://bitbucket.org/", expected: "pkg:bitbucket/org/app@v1.2.3"},
+		{gitKind: "gitlab", gitServer: "https://gitlab.com", expected: "pkg:generic/org/app@v1.2.3?repository_url=gitlab.com%2Forg%2Fapp"},
+		{gitKind: "gitea", gitServer: "http://gitea.example.com:3000", expected: "pkg:generic/org/app@v1.2.3?repository_url=gitea.example.com%3A3000%2Forg%2Fapp"},
+	}
+	for _, test := range tests {
+		p := &Publisher{GitKind: test.gitKind, GitServer: test.gitServer}
+		artifactID := p.artifactID("org", "app", "v1.2.3")
+		if artifactID != test.expected {
+			t.Errorf("expected the artifact of %s %s to be %s, got %s", test.gitKind, test.gitServer, test.expected, artifactID)
+		}
+		// the repository and version are read back by the CDEvents endpoint
+		purl, err := ParsePackageURL(artifactID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if purl.Owner() != "org" || purl.Name != "app" || purl.PackageVersion() != "1.2.3" {
+			t.Errorf("expected %s to be the version 1.2.3 of org/app, got %s/%s %s", artifactID, purl.Owner(), purl.Name, purl.PackageVersion())
+		}
+	}
+}
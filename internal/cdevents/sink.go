@@ -0,0 +1,57 @@
+package cdevents
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Sink sends the CloudEvents to their destination: an HTTP endpoint with HTTPSink,
+// or a message broker - such as NATS or Kafka - with an adapter implementing it.
+// It is retried by the Publisher if it fails.
+type Sink interface {
+	Send(ctx context.Context, event CloudEvent) error
+}
+
+// HTTPSink sends the CloudEvents to an HTTP endpoint, in the structured content mode of the HTTP protocol binding of CloudEvents
+type HTTPSink struct {
+	URL string
+	// Token is optional: when set, the requests are authenticated with it, as a bearer token
+	Token string
+	// Client is optional. Default: http.DefaultClient
+	Client *http.Client
+}
+
+func (s *HTTPSink) Send(ctx context.Context, event CloudEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the CloudEvent %s: %w", event.ID, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create the request to %s: %w", s.URL, err)
+	}
+	req.Header.Set("Content-Type", contentTypeStructured)
+	if s.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.Token)
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send the CloudEvent %s to %s: %w", event.ID, s.URL, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to send the CloudEvent %s to %s: status %s", event.ID, s.URL, resp.Status)
+	}
+	return nil
+}
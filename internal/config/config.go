@@ -0,0 +1,328 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x/cd-indicators/store"
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultApprovalLabel is the label added by the Lighthouse/Prow approve plugin
+const DefaultApprovalLabel = "approved"
+
+// Config holds the collection rules, which can be reloaded at runtime
+type Config struct {
+	// Owners filters the git owners/organizations
+	Owners Patterns `json:"owners,omitempty"`
+	// Repositories filters the repositories, as "owner/repository" patterns
+	Repositories Patterns `json:"repositories,omitempty"`
+	// Environments defines environment aliases, optionally for a subset of the repositories
+	Environments []EnvironmentAliases `json:"environments,omitempty"`
+	// BotAuthors are the patterns of the git users whose pull requests, pipelines and releases are flagged as automated.
+	// The GitHub Apps - whose login ends with "[bot]" - are always bots
+	BotAuthors []string `json:"botAuthors,omitempty"`
+	// ApprovalLabel is a pull request label used as the approval signal.
+	// Deprecated: use Approval.Labels instead
+	ApprovalLabel string `json:"approvalLabel,omitempty"`
+	// Approval defines the signals approving a pull request, used for the time to review
+	Approval Approval `json:"approval,omitempty"`
+	// Identities map the git user logins - or commit emails - of a person to a canonical person and team.
+	// They take precedence over the identities managed through the API
+	Identities []store.Identity `json:"identities,omitempty"`
+	// Teams map repositories to teams, in addition to the CODEOWNERS files and the SourceRepository labels
+	Teams []Team `json:"teams,omitempty"`
+	// EnvironmentRepositories are the GitOps repositories of the environments: merging a promotion pull request
+	// into one of them is a deployment of the promoted applications
+	EnvironmentRepositories []EnvironmentRepository `json:"environmentRepositories,omitempty"`
+}
+
+// EnvironmentRepository is the git repository of an environment - such as a jx3 cluster or environment repository
+type EnvironmentRepository struct {
+	// Repository is an "owner/repository" pattern
+	Repository string `json:"repository"`
+	// Environment is the name of the environment. Default: the "env/<environment>" label of the promotion pull request
+	Environment string `json:"environment,omitempty"`
+	// Owner is the git owner of the promoted applications. Default: the owner of the environment repository
+	Owner string `json:"owner,omitempty"`
+}
+
+// Team owns repositories
+type Team struct {
+	Name string `json:"name"`
+	// Repositories are "owner/repository" names, or "owner/*" for all the repositories of an owner
+	Repositories []string `json:"repositories"`
+}
+
+// Approval defines when a pull request is approved: either when one of the labels is added,
+// or - if enabled - when the approving reviews satisfy the minimum number of approvals and the required approvers
+type Approval struct {
+	// Labels are the pull request labels used as the approval signal. Default: the "approved" label
+	Labels []string `json:"labels,omitempty"`
+	// Reviews enables the approving reviews as an approval signal
+	Reviews bool `json:"reviews,omitempty"`
+	// MinApprovals is the minimum number of distinct approving reviewers. Default: 1
+	MinApprovals int `json:"minApprovals,omitempty"`
+	// RequiredApprovers defines - CODEOWNERS-style - the git users who must approve the pull requests of some repositories,
+	// or the pull requests changing some paths of these repositories
+	RequiredApprovers []RequiredApprovers `json:"requiredApprovers,omitempty"`
+}
+
+// RequiredApprovers requires an approving review from at least one of the approvers.
+// The approvers without paths are merged: one of them must approve. The approvers of paths are the owners of these paths:
+// one of them must approve the pull requests changing one of these paths, in addition to the other required approvers
+type RequiredApprovers struct {
+	// Repositories are the "owner/repository" patterns of the repositories using these approvers. Empty means all
+	Repositories []string `json:"repositories,omitempty"`
+	// Paths are the patterns of the changed files requiring these approvers, see MatchesPath. Empty means all the changed files
+	Paths     []string `json:"paths,omitempty"`
+	Approvers []string `json:"approvers"`
+}
+
+// Patterns includes and excludes values matching glob patterns, as defined by path.Match.
+// An empty include list includes everything.
+type Patterns struct {
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// EnvironmentAliases maps environment names to a canonical environment name,
+// for example "prod-eu" and "prod-us" to "production"
+type EnvironmentAliases struct {
+	// Repositories are the "owner/repository" patterns of the repositories using these aliases. Empty means all
+	Repositories []string `json:"repositories,omitempty"`
+	// Aliases are the environment name patterns, by canonical environment name
+	Aliases map[string][]string `json:"aliases"`
+}
+
+// Load reads the YAML configuration file at the given path
+func Load(filePath string) (*Config, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", filePath, err)
+	}
+
+	cfg := new(Config)
+	if err = yaml.UnmarshalStrict(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", filePath, err)
+	}
+	if err = cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", filePath, err)
+	}
+
+	return cfg, nil
+}
+
+// Validate checks that all the patterns and identities are valid
+func (c *Config) Validate() error {
+	var patterns []string
+	patterns = append(patterns, c.Owners.Include...)
+	patterns = append(patterns, c.Owners.Exclude...)
+	patterns = append(patterns, c.Repositories.Include...)
+	patterns = append(patterns, c.Repositories.Exclude...)
+	patterns = append(patterns, c.BotAuthors...)
+	for _, required := range c.Approval.RequiredApprovers {
+		patterns = append(patterns, required.Repositories...)
+		patterns = append(patterns, required.Paths...)
+	}
+	if c.Approval.MinApprovals < 0 {
+		return fmt.Errorf("invalid minimum number of approvals %d", c.Approval.MinApprovals)
+	}
+	for _, env := range c.Environments {
+		patterns = append(patterns, env.Repositories...)
+		for _, aliases := range env.Aliases {
+			patterns = append(patterns, aliases...)
+		}
+	}
+	for _, repository := range c.EnvironmentRepositories {
+		if owner, name, ok := strings.Cut(repository.Repository, "/"); !ok || owner == "" || name == "" {
+			return fmt.Errorf("invalid environment repository %q: must be an owner/repository pattern", repository.Repository)
+		}
+		patterns = append(patterns, repository.Repository)
+	}
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	for _, identity := range c.Identities {
+		if err := identity.Validate(); err != nil {
+			return err
+		}
+	}
+	for _, team := range c.Teams {
+		if strings.TrimSpace(team.Name) == "" {
+			return errors.New("the name of a team can't be empty")
+		}
+		for _, repository := range team.Repositories {
+			if owner, name, ok := strings.Cut(repository, "/"); !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+				return fmt.Errorf("invalid repository %q of team %s: must be owner/repository or owner/*", repository, team.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// AllowsRepository returns true if both the owner and the repository are included, and not excluded
+func (c *Config) AllowsRepository(owner, repository string) bool {
+	return c.Owners.Matches(owner) && c.Repositories.Matches(owner+"/"+repository)
+}
+
+// githubAppSuffix is the suffix of the logins of the GitHub Apps - such as dependabot or renovate -
+// whose user type is "Bot". The type isn't part of the go-scm users, so the suffix is used instead
+const githubAppSuffix = "[bot]"
+
+// IsBot returns true if the given git user login is a GitHub App, or matches one of the bot authors patterns
+func (c *Config) IsBot(login string) bool {
+	return strings.HasSuffix(strings.ToLower(login), githubAppSuffix) || matchesAny(c.BotAuthors, login)
+}
+
+// Environment returns the canonical name of the given environment, for the given repository
+func (c *Config) Environment(owner, repository, environment string) string {
+	for _, env := range c.Environments {
+		if len(env.Repositories) > 0 && !matchesAny(env.Repositories, owner+"/"+repository) {
+			continue
+		}
+		names := make([]string, 0, len(env.Aliases))
+		for name := range env.Aliases {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if matchesAny(env.Aliases[name], environment) {
+				return name
+			}
+		}
+	}
+	return environment
+}
+
+// EnvironmentRepository returns the first environment repository matching the given repository, if any
+func (c *Config) EnvironmentRepository(owner, repository string) (EnvironmentRepository, bool) {
+	for _, env := range c.EnvironmentRepositories {
+		if matchesAny([]string{env.Repository}, owner+"/"+repository) {
+			return env, true
+		}
+	}
+	return EnvironmentRepository{}, false
+}
+
+// IsApprovalLabel returns true if the given pull request label is an approval signal
+func (c *Config) IsApprovalLabel(label string) bool {
+	// don't append to the configured labels: the config is shared between goroutines
+	labels := append([]string(nil), c.Approval.Labels...)
+	if c.ApprovalLabel != "" {
+		labels = append(labels, c.ApprovalLabel)
+	}
+	if len(labels) == 0 {
+		labels = []string{DefaultApprovalLabel}
+	}
+	for _, l := range labels {
+		if strings.EqualFold(l, label) {
+			return true
+		}
+	}
+	return false
+}
+
+// TeamRepositories returns the repositories of the configured teams
+func (c *Config) TeamRepositories() []store.TeamRepository {
+	var mappings []store.TeamRepository
+	for _, team := range c.Teams {
+		for _, repository := range team.Repositories {
+			owner, name, _ := strings.Cut(repository, "/")
+			mappings = append(mappings, store.TeamRepository{
+				Team:       team.Name,
+				Owner:      owner,
+				Repository: name,
+				Source:     store.TeamSourceConfig,
+			})
+		}
+	}
+	return mappings
+}
+
+// RequiredApprovers returns the sets of git users - at least one of each set - who must approve the pull requests
+// of the given repository changing the given files. When the changed files are unknown, the approvers of paths are ignored.
+func (c *Config) RequiredApprovers(owner, repository string, changedPaths []string) [][]string {
+	var (
+		approvers     []string
+		pathApprovers [][]string
+	)
+	for _, required := range c.Approval.RequiredApprovers {
+		if len(required.Repositories) > 0 && !matchesAny(required.Repositories, owner+"/"+repository) {
+			continue
+		}
+		if len(required.Paths) == 0 {
+			approvers = append(approvers, required.Approvers...)
+			continue
+		}
+		for _, changedPath := range changedPaths {
+			if required.MatchesPath(changedPath) {
+				pathApprovers = append(pathApprovers, required.Approvers)
+				break
+			}
+		}
+	}
+	if len(approvers) > 0 {
+		return append([][]string{approvers}, pathApprovers...)
+	}
+	return pathApprovers
+}
+
+// HasPathApprovers returns true if some required approvers of the given repository depend on the changed files
+func (c *Config) HasPathApprovers(owner, repository string) bool {
+	for _, required := range c.Approval.RequiredApprovers {
+		if len(required.Paths) > 0 && (len(required.Repositories) == 0 || matchesAny(required.Repositories, owner+"/"+repository)) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesPath returns true if the changed file matches one of the paths, as in the CODEOWNERS files:
+// a pattern ending with a "/" matches the files of this directory and its sub-directories,
+// a pattern without "/" matches the file names in any directory, and the other patterns match the whole path.
+// A leading "/" is ignored
+func (r RequiredApprovers) MatchesPath(changedPath string) bool {
+	changedPath = strings.TrimPrefix(changedPath, "/")
+	for _, pattern := range r.Paths {
+		pattern = strings.TrimPrefix(pattern, "/")
+		var ok bool
+		switch {
+		case strings.HasSuffix(pattern, "/"):
+			ok = strings.HasPrefix(changedPath, pattern)
+		case !strings.Contains(pattern, "/"):
+			ok, _ = path.Match(pattern, path.Base(changedPath))
+		default:
+			ok, _ = path.Match(pattern, changedPath)
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches returns true if the value is included and not excluded
+func (p Patterns) Matches(value string) bool {
+	if len(p.Include) > 0 && !matchesAny(p.Include, value) {
+		return false
+	}
+	return !matchesAny(p.Exclude, value)
+}
+
+// matchesAny matches case-insensitively, because git owners and repositories are case-insensitive
+func matchesAny(patterns []string, value string) bool {
+	value = strings.ToLower(value)
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.ToLower(pattern), value); ok {
+			return true
+		}
+	}
+	return false
+}
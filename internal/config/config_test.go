@@ -0,0 +1,44 @@
+package config
+
+import "testing"
+
+func TestRequiredApproversMatchesPath(t *testing.T) {
+	required := RequiredApprovers{Paths: []string{"docs/", "/charts/*/values.yaml", "*.sql"}}
+	tests := map[string]bool{
+		"docs/README.md":             true,
+		"docs/guide/install.md":      true,
+		"charts/app/values.yaml":     true,
+		"db/migrations/001_init.sql": true,
+		"schema.sql":                 true,
+		"README.md":                  false,
+		"mydocs/README.md":           false,
+		"charts/app/Chart.yaml":      false,
+		"charts/app/ci/values.yaml":  false,
+	}
+	for changedPath, expected := range tests {
+		if matches := required.MatchesPath(changedPath); matches != expected {
+			t.Errorf("expected %s to match: %v, got %v", changedPath, expected, matches)
+		}
+	}
+}
+
+func TestRequiredApprovers(t *testing.T) {
+	cfg := &Config{Approval: Approval{RequiredApprovers: []RequiredApprovers{
+		{Approvers: []string{"alice"}},
+		{Repositories: []string{"org/*"}, Approvers: []string{"bob"}},
+		{Repositories: []string{"org/app"}, Paths: []string{"docs/"}, Approvers: []string{"carol"}},
+		{Repositories: []string{"other/*"}, Paths: []string{"docs/"}, Approvers: []string{"dave"}},
+	}}}
+
+	approvers := cfg.RequiredApprovers("org", "app", []string{"main.go", "docs/README.md"})
+	if len(approvers) != 2 || len(approvers[0]) != 2 || approvers[0][1] != "bob" || len(approvers[1]) != 1 || approvers[1][0] != "carol" {
+		t.Errorf("expected one of alice and bob, and carol for the docs, got %v", approvers)
+	}
+	// unknown changed files: only the approvers without paths
+	if approvers := cfg.RequiredApprovers("org", "app", nil); len(approvers) != 1 {
+		t.Errorf("expected only the approvers without paths, got %v", approvers)
+	}
+	if !cfg.HasPathApprovers("org", "app") || cfg.HasPathApprovers("org", "web") {
+		t.Errorf("expected only org/app to have approvers of paths")
+	}
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Watcher reloads the configuration file when it changes.
+// It polls the file instead of relying on filesystem events,
+// because Kubernetes updates the mounted ConfigMaps by swapping symlinks.
+type Watcher struct {
+	Path     string
+	Interval time.Duration
+	// Defaults is applied on top of each loaded configuration
+	Defaults func(*Config)
+	Logger   *logrus.Logger
+
+	config  atomic.Pointer[Config]
+	modTime time.Time
+	size    int64
+}
+
+// Start loads the configuration file, and then reloads it on changes until the given context is done
+func (w *Watcher) Start(ctx context.Context) error {
+	if err := w.load(); err != nil {
+		return err
+	}
+
+	go func() {
+		ticker := time.NewTicker(w.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			changed, err := w.changed()
+			if err != nil {
+				w.Logger.WithField("path", w.Path).WithError(err).Error("Failed to check the config file")
+				continue
+			}
+			if !changed {
+				continue
+			}
+			if err = w.load(); err != nil {
+				w.Logger.WithField("path", w.Path).WithError(err).Error("Failed to reload the config file, keeping the previous config")
+				continue
+			}
+			w.Logger.WithField("path", w.Path).Info("Reloaded the config file")
+		}
+	}()
+
+	return nil
+}
+
+// Config returns the current configuration
+func (w *Watcher) Config() *Config {
+	return w.config.Load()
+}
+
+func (w *Watcher) changed() (bool, error) {
+	info, err := os.Stat(w.Path)
+	if err != nil {
+		return false, fmt.Errorf("failed to stat config file %s: %w", w.Path, err)
+	}
+	return !info.ModTime().Equal(w.modTime) || info.Size() != w.size, nil
+}
+
+func (w *Watcher) load() error {
+	info, err := os.Stat(w.Path)
+	if err != nil {
+		return fmt.Errorf("failed to stat config file %s: %w", w.Path, err)
+	}
+	cfg, err := Load(w.Path)
+	if err != nil {
+		return err
+	}
+	if w.Defaults != nil {
+		w.Defaults(cfg)
+	}
+
+	w.config.Store(cfg)
+	w.modTime, w.size = info.ModTime(), info.Size()
+	return nil
+}
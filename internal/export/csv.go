@@ -0,0 +1,57 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/cd-indicators/store"
+)
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer, columns []store.Column) (*csvWriter, error) {
+	cw := &csvWriter{w: csv.NewWriter(w)}
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = column.Name
+	}
+	if err := cw.w.Write(header); err != nil {
+		return nil, fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	return cw, nil
+}
+
+func (cw *csvWriter) Write(values []any) error {
+	record := make([]string, len(values))
+	for i, value := range values {
+		switch v := value.(type) {
+		case nil:
+		case string:
+			record[i] = v
+		case int64:
+			record[i] = strconv.FormatInt(v, 10)
+		case float64:
+			record[i] = strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			record[i] = strconv.FormatBool(v)
+		case time.Time:
+			record[i] = v.Format(time.RFC3339)
+		case []string:
+			record[i] = strings.Join(v, ",")
+		default:
+			record[i] = fmt.Sprint(v)
+		}
+	}
+	return cw.w.Write(record)
+}
+
+func (cw *csvWriter) Close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
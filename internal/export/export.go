@@ -0,0 +1,77 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jenkins-x/cd-indicators/store"
+)
+
+// DefaultPeriod is the time range exported when no start time is given: a quarter
+const DefaultPeriod = 90 * 24 * time.Hour
+
+// Export writes all the rows of the given dataset matching the filter, in the given format
+func Export(ctx context.Context, s store.ExportStore, dataset string, format Format, filter store.ExportFilter, w io.Writer) error {
+	columns, err := store.DatasetColumns(dataset)
+	if err != nil {
+		return err
+	}
+
+	writer, err := NewWriter(format, w, columns)
+	if err != nil {
+		return err
+	}
+
+	err = s.Export(ctx, dataset, filter, writer.Write)
+	if err != nil {
+		return fmt.Errorf("failed to export dataset %s: %w", dataset, err)
+	}
+
+	if err = writer.Close(); err != nil {
+		return fmt.Errorf("failed to write dataset %s: %w", dataset, err)
+	}
+
+	return nil
+}
+
+// NewFilter builds an export filter from user input: the times are either RFC 3339 timestamps or dates.
+// An empty end time means now, and an empty start time means DefaultPeriod before the end time.
+func NewFilter(owner, from, to string) (store.ExportFilter, error) {
+	filter := store.ExportFilter{
+		Owner: owner,
+		To:    time.Now().UTC(),
+	}
+
+	var err error
+	if to != "" {
+		filter.To, err = parseTime(to)
+		if err != nil {
+			return filter, fmt.Errorf("invalid end time: %w", err)
+		}
+	}
+	filter.From = filter.To.Add(-DefaultPeriod)
+	if from != "" {
+		filter.From, err = parseTime(from)
+		if err != nil {
+			return filter, fmt.Errorf("invalid start time: %w", err)
+		}
+	}
+	if !filter.From.Before(filter.To) {
+		return filter, fmt.Errorf("start time %s must be before end time %s", filter.From.Format(time.RFC3339), filter.To.Format(time.RFC3339))
+	}
+
+	return filter, nil
+}
+
+func parseTime(value string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return t, fmt.Errorf("%q is neither a date (YYYY-MM-DD) nor a RFC 3339 timestamp", value)
+	}
+	return t.UTC(), nil
+}
@@ -0,0 +1,32 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/jenkins-x/cd-indicators/store"
+)
+
+type ndjsonWriter struct {
+	encoder *json.Encoder
+	columns []store.Column
+}
+
+func newNDJSONWriter(w io.Writer, columns []store.Column) *ndjsonWriter {
+	return &ndjsonWriter{
+		encoder: json.NewEncoder(w),
+		columns: columns,
+	}
+}
+
+func (nw *ndjsonWriter) Write(values []any) error {
+	object := make(map[string]any, len(values))
+	for i, column := range nw.columns {
+		object[column.Name] = values[i]
+	}
+	return nw.encoder.Encode(object)
+}
+
+func (nw *ndjsonWriter) Close() error {
+	return nil
+}
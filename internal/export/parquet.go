@@ -0,0 +1,80 @@
+package export
+
+import (
+	"io"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/parquet-go/parquet-go"
+)
+
+type parquetWriter struct {
+	w *parquet.Writer
+	// columnIndexes maps the position of a dataset column to its position in the parquet schema,
+	// which orders the columns by name
+	columnIndexes []int
+}
+
+func newParquetWriter(w io.Writer, columns []store.Column) *parquetWriter {
+	group := parquet.Group{}
+	for _, column := range columns {
+		var node parquet.Node
+		switch column.Type {
+		case store.ColumnTypeInt:
+			node = parquet.Int(64)
+		case store.ColumnTypeFloat:
+			node = parquet.Leaf(parquet.DoubleType)
+		case store.ColumnTypeTime:
+			node = parquet.Timestamp(parquet.Microsecond)
+		case store.ColumnTypeBool:
+			node = parquet.Leaf(parquet.BooleanType)
+		default:
+			node = parquet.String()
+		}
+		group[column.Name] = parquet.Optional(node)
+	}
+	schema := parquet.NewSchema("indicators", group)
+
+	positions := map[string]int{}
+	for i, path := range schema.Columns() {
+		positions[path[0]] = i
+	}
+	columnIndexes := make([]int, len(columns))
+	for i, column := range columns {
+		columnIndexes[i] = positions[column.Name]
+	}
+
+	return &parquetWriter{
+		w:             parquet.NewWriter(w, schema),
+		columnIndexes: columnIndexes,
+	}
+}
+
+func (pw *parquetWriter) Write(values []any) error {
+	row := make(parquet.Row, len(values))
+	for i, value := range values {
+		columnIndex := pw.columnIndexes[i]
+		var v parquet.Value
+		switch value := value.(type) {
+		case nil:
+			row[columnIndex] = parquet.NullValue().Level(0, 0, columnIndex)
+			continue
+		case time.Time:
+			v = parquet.Int64Value(value.UnixMicro())
+		case []string:
+			v = parquet.ByteArrayValue([]byte(strings.Join(value, ",")))
+		case string:
+			v = parquet.ByteArrayValue([]byte(value))
+		default:
+			v = parquet.ValueOf(value)
+		}
+		row[columnIndex] = v.Level(0, 1, columnIndex)
+	}
+	_, err := pw.w.WriteRows([]parquet.Row{row})
+	return err
+}
+
+func (pw *parquetWriter) Close() error {
+	return pw.w.Close()
+}
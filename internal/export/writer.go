@@ -0,0 +1,67 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jenkins-x/cd-indicators/store"
+)
+
+type Format string
+
+const (
+	FormatCSV     = Format("csv")
+	FormatNDJSON  = Format("ndjson")
+	FormatParquet = Format("parquet")
+)
+
+var Formats = []Format{FormatCSV, FormatNDJSON, FormatParquet}
+
+// ParseFormat returns the format with the given name, defaulting to CSV
+func ParseFormat(name string) (Format, error) {
+	if name == "" {
+		return FormatCSV, nil
+	}
+	for _, f := range Formats {
+		if string(f) == name {
+			return f, nil
+		}
+	}
+	var formats []string
+	for _, f := range Formats {
+		formats = append(formats, string(f))
+	}
+	return "", fmt.Errorf("unknown format %q - must be one of %s", name, strings.Join(formats, ", "))
+}
+
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// Writer writes the rows of a dataset, with values normalized by the store.ExportStore
+type Writer interface {
+	Write(values []any) error
+	Close() error
+}
+
+func NewWriter(format Format, w io.Writer, columns []store.Column) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, columns)
+	case FormatNDJSON:
+		return newNDJSONWriter(w, columns), nil
+	case FormatParquet:
+		return newParquetWriter(w, columns), nil
+	default:
+		_, err := ParseFormat(string(format))
+		return nil, err
+	}
+}
@@ -0,0 +1,97 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Check returns an error when the component is not ready
+type Check func(ctx context.Context) error
+
+// Checker is an HTTP handler reporting the readiness of all the registered components
+type Checker struct {
+	Timeout time.Duration
+	Logger  *logrus.Logger
+
+	mu           sync.RWMutex
+	checks       map[string]Check
+	shuttingDown atomic.Bool
+}
+
+type status struct {
+	Ready      bool              `json:"ready"`
+	Components map[string]string `json:"components"`
+}
+
+// Register adds the check for the named component
+func (c *Checker) Register(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.checks == nil {
+		c.checks = make(map[string]Check)
+	}
+	c.checks[name] = check
+}
+
+// ShutDown marks the process as not ready anymore, so that it stops receiving new requests
+func (c *Checker) ShutDown() {
+	c.shuttingDown.Store(true)
+}
+
+func (c *Checker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	s := c.check(ctx)
+	w.Header().Set("Content-Type", "application/json")
+	if !s.Ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(s); err != nil {
+		c.Logger.WithError(err).Debug("Failed to write readiness status")
+	}
+}
+
+func (c *Checker) check(ctx context.Context) status {
+	c.mu.RLock()
+	names := make([]string, 0, len(c.checks))
+	for name := range c.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	checks := make([]Check, 0, len(names))
+	for _, name := range names {
+		checks = append(checks, c.checks[name])
+	}
+	c.mu.RUnlock()
+
+	s := status{
+		Ready:      true,
+		Components: make(map[string]string, len(names)+1),
+	}
+	if c.shuttingDown.Load() {
+		s.Ready = false
+		s.Components["process"] = "shutting down"
+	}
+	for i, name := range names {
+		if err := checks[i](ctx); err != nil {
+			c.Logger.WithField("component", name).WithError(err).Debug("Component is not ready")
+			s.Ready = false
+			s.Components[name] = err.Error()
+			continue
+		}
+		s.Components[name] = "ok"
+	}
+	return s
+}
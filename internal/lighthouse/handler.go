@@ -45,14 +45,7 @@ func (h *Handler) ServeHTTP(_ http.ResponseWriter, r *http.Request) {
 	}
 
 	if webhook != nil {
-		log := log.WithField("repo", webhook.Repository().FullName)
-		log.Trace("Handling webhook")
-		for _, handler := range h.webhookHandlers {
-			err = handler(webhook)
-			if err != nil {
-				log.WithError(err).Error("Failed to process webhook")
-			}
-		}
+		h.handleWebhook(log, webhook)
 	}
 	if activity != nil {
 		log := log.WithField("activity", activity.Name)
@@ -66,6 +59,22 @@ func (h *Handler) ServeHTTP(_ http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// HandleWebhook dispatches a webhook received without Lighthouse - such as a native webhook of the git provider -
+// to the registered webhook handlers
+func (h *Handler) HandleWebhook(webhook scm.Webhook) {
+	h.handleWebhook(h.Logger.WithField("kind", webhook.Kind()), webhook)
+}
+
+func (h *Handler) handleWebhook(log *logrus.Entry, webhook scm.Webhook) {
+	log = log.WithField("repo", webhook.Repository().FullName)
+	log.Trace("Handling webhook")
+	for _, handler := range h.webhookHandlers {
+		if err := handler(webhook); err != nil {
+			log.WithError(err).Error("Failed to process webhook")
+		}
+	}
+}
+
 func (h *Handler) RegisterWebhookHandler(handler WebhookHandlerFunc) {
 	h.webhookHandlers = append(h.webhookHandlers, handler)
 }
@@ -0,0 +1,62 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/sirupsen/logrus"
+)
+
+// Job periodically applies the retention policies
+type Job struct {
+	Store    store.RetentionStore
+	Policies []store.RetentionPolicy
+	Interval time.Duration
+	Logger   *logrus.Logger
+}
+
+func (j *Job) Start(ctx context.Context) error { // nolint: unparam
+	if len(j.Policies) == 0 {
+		j.Logger.Debug("No retention policies defined, not starting the retention job")
+		return nil
+	}
+	if j.Store == nil {
+		return errors.New("the storage doesn't support retention policies")
+	}
+
+	go func() {
+		ticker := time.NewTicker(j.Interval)
+		defer ticker.Stop()
+
+		for {
+			j.run(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (j *Job) run(ctx context.Context) {
+	now := time.Now()
+	for _, policy := range j.Policies {
+		log := j.Logger.WithField("table", policy.Table).WithField("maxAge", policy.MaxAge).WithField("archive", policy.Archive)
+		log.Debug("Applying retention policy")
+		result, err := j.Store.Apply(ctx, policy, now)
+		if err != nil {
+			log.WithError(err).Error("Failed to apply retention policy")
+			continue
+		}
+		log.
+			WithField("rolledUp", result.RolledUp).
+			WithField("archived", result.Archived).
+			WithField("deleted", result.Deleted).
+			Info("Applied retention policy")
+	}
+}
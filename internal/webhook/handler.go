@@ -0,0 +1,58 @@
+package webhook
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/sirupsen/logrus"
+)
+
+// Handler receives the native webhooks of the git provider - GitHub, GitLab, Bitbucket or Gitea - without Lighthouse.
+// They are parsed and authenticated by the go-scm driver of the provider, and dispatched to the same handlers as the Lighthouse webhooks.
+type Handler struct {
+	// Webhooks is the webhook service of the go-scm driver of the git provider, see factory.NewWebHookService
+	Webhooks scm.WebhookService
+	// Secret is optional: when set, the webhooks must be signed with it - or carry it, depending on the provider
+	Secret string
+	// Dispatch processes the parsed webhooks, such as lighthouse.Handler.HandleWebhook
+	Dispatch func(scm.Webhook)
+	Logger   *logrus.Logger
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST requests are supported", http.StatusMethodNotAllowed)
+		return
+	}
+	log := h.Logger.WithField("UA", r.Header.Get("User-Agent"))
+
+	webhook, err := h.Webhooks.Parse(r, h.secret)
+	switch {
+	case scm.IsUnknownWebhook(err):
+		// the providers send many more events than the collected ones
+		log.WithError(err).Trace("Ignoring unknown webhook")
+		w.WriteHeader(http.StatusOK)
+		return
+	case errors.Is(err, scm.ErrSignatureInvalid):
+		log.WithError(err).Error("Invalid webhook signature")
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	case err != nil:
+		log.WithError(err).Error("Failed to parse webhook")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case webhook == nil:
+		log.Trace("Ignoring empty webhook")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	h.Dispatch(webhook)
+	w.WriteHeader(http.StatusOK)
+}
+
+// secret returns the secret of the webhooks, whatever their repository
+func (h *Handler) secret(scm.Webhook) (string, error) {
+	return h.Secret, nil
+}
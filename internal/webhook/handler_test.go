@@ -0,0 +1,88 @@
+package webhook
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/sirupsen/logrus"
+)
+
+// testWebhookService parses the webhooks with its parse func, once their X-Secret header is checked against the secret
+type testWebhookService struct {
+	parse func(*http.Request) (scm.Webhook, error)
+}
+
+func (s *testWebhookService) Parse(req *http.Request, fn scm.SecretFunc) (scm.Webhook, error) {
+	secret, err := fn(nil)
+	if err != nil {
+		return nil, err
+	}
+	if req.Header.Get("X-Secret") != secret {
+		return nil, scm.ErrSignatureInvalid
+	}
+	return s.parse(req)
+}
+
+func TestHandler(t *testing.T) {
+	pushHook := &scm.PushHook{Ref: "refs/heads/main"}
+	tests := []struct {
+		name       string
+		signature  string
+		parse      func(*http.Request) (scm.Webhook, error)
+		dispatched bool
+		expected   int
+	}{
+		{
+			name:      "unknown webhook",
+			signature: "secret",
+			parse:     func(*http.Request) (scm.Webhook, error) { return nil, scm.UnknownWebhook{Event: "star"} },
+			expected:  http.StatusOK,
+		},
+		{
+			name:      "invalid signature",
+			signature: "other",
+			parse:     func(*http.Request) (scm.Webhook, error) { return pushHook, nil },
+			expected:  http.StatusUnauthorized,
+		},
+		{
+			name:      "parse error",
+			signature: "secret",
+			parse:     func(*http.Request) (scm.Webhook, error) { return nil, errors.New("invalid JSON") },
+			expected:  http.StatusBadRequest,
+		},
+		{
+			name:       "parsed webhook",
+			signature:  "secret",
+			parse:      func(*http.Request) (scm.Webhook, error) { return pushHook, nil },
+			dispatched: true,
+			expected:   http.StatusOK,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var dispatched []scm.Webhook
+			h := &Handler{
+				Webhooks: &testWebhookService{parse: test.parse},
+				Secret:   "secret",
+				Dispatch: func(webhook scm.Webhook) { dispatched = append(dispatched, webhook) },
+				Logger:   logrus.New(),
+			}
+			req := httptest.NewRequest(http.MethodPost, "/git/events", nil)
+			req.Header.Set("X-Secret", test.signature)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != test.expected {
+				t.Errorf("expected status %d, got %d: %s", test.expected, rec.Code, rec.Body)
+			}
+			if test.dispatched && (len(dispatched) != 1 || dispatched[0] != pushHook) {
+				t.Errorf("expected the parsed webhook to be dispatched, got %v", dispatched)
+			}
+			if !test.dispatched && len(dispatched) != 0 {
+				t.Errorf("expected no webhook to be dispatched, got %v", dispatched)
+			}
+		})
+	}
+}
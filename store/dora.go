@@ -0,0 +1,244 @@
+package store
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DORAMetrics are the DORA metrics of a repository, as exported in the "dora" dataset
+type DORAMetrics struct {
+	Owner                       string
+	Repository                  string
+	Deployments                 int64
+	DeploymentsPerDay           float64
+	LeadTimeForChangesP50       *float64
+	LeadTimeForChangesP90       *float64
+	ReleasePipelines            int64
+	ReleasePipelinesFailureRate *float64
+	// ChangeFailureRate is the ratio of the production deployments failed by an incident, see Incident
+	ChangeFailureRate *float64
+	// TimeToRestoreP50 is the median time to restore of the resolved production incidents
+	TimeToRestoreP50 *float64
+}
+
+// Values returns the values of the "dora" dataset columns
+func (m DORAMetrics) Values() []any {
+	return append([]any{m.Owner, m.Repository}, m.metricValues()...)
+}
+
+func (m DORAMetrics) metricValues() []any {
+	return []any{
+		m.Deployments, m.DeploymentsPerDay,
+		floatValue(m.LeadTimeForChangesP50), floatValue(m.LeadTimeForChangesP90),
+		m.ReleasePipelines, floatValue(m.ReleasePipelinesFailureRate),
+		floatValue(m.ChangeFailureRate), floatValue(m.TimeToRestoreP50),
+	}
+}
+
+// TeamDORAMetrics are the DORA metrics of all the repositories of a team, as exported in the "team_dora" dataset.
+// The owner and repository of the embedded metrics are empty
+type TeamDORAMetrics struct {
+	Team string
+	DORAMetrics
+}
+
+// Values returns the values of the "team_dora" dataset columns
+func (m TeamDORAMetrics) Values() []any {
+	return append([]any{m.Team}, m.metricValues()...)
+}
+
+// IsProductionEnvironment returns true for the environments starting with "prod", like the Grafana dashboards
+func IsProductionEnvironment(environment string) bool {
+	return strings.HasPrefix(strings.ToLower(environment), "prod")
+}
+
+type repositoryKey struct {
+	owner, name string
+}
+
+// ComputeDORAMetrics computes the DORA metrics per repository, for the storage backends which can't do it in SQL.
+// The deployments, releases, pipelines and incidents don't need to be filtered beforehand.
+func ComputeDORAMetrics(filter ExportFilter, deployments []Deployment, releases []Release, pipelines []Pipeline, incidents []Incident) []DORAMetrics {
+	repositories := countDORAMetrics(filter, deployments, releases, pipelines, incidents, func(owner, repository string) []repositoryKey {
+		return []repositoryKey{{owner: owner, name: repository}}
+	})
+
+	var repos []repositoryKey
+	for repo := range repositories {
+		repos = append(repos, repo)
+	}
+	sort.Slice(repos, func(i, j int) bool {
+		if repos[i].owner != repos[j].owner {
+			return repos[i].owner < repos[j].owner
+		}
+		return repos[i].name < repos[j].name
+	})
+
+	var metrics []DORAMetrics
+	for _, repo := range repos {
+		m := repositories[repo].metrics(filter)
+		m.Owner, m.Repository = repo.owner, repo.name
+		metrics = append(metrics, m)
+	}
+	return metrics
+}
+
+// ComputeTeamDORAMetrics computes the DORA metrics per team, for the storage backends which can't do it in SQL.
+// The repositories without team are ignored.
+func ComputeTeamDORAMetrics(filter ExportFilter, teams []TeamRepository, deployments []Deployment, releases []Release, pipelines []Pipeline, incidents []Incident) []TeamDORAMetrics {
+	perTeam := countDORAMetrics(filter, deployments, releases, pipelines, incidents, repositoryTeams(teams))
+
+	var names []string
+	for team := range perTeam {
+		names = append(names, team)
+	}
+	sort.Strings(names)
+
+	var metrics []TeamDORAMetrics
+	for _, team := range names {
+		metrics = append(metrics, TeamDORAMetrics{Team: team, DORAMetrics: perTeam[team].metrics(filter)})
+	}
+	return metrics
+}
+
+// doraCounters are the production deployments, the release pipelines and the production incidents of a group of repositories
+type doraCounters struct {
+	deployments       int64
+	failedDeployments int64
+	leadTimes         []float64
+	runs              int64
+	failed            int64
+	restoreTimes      []float64
+}
+
+// countDORAMetrics counts the production deployments, release pipelines and production incidents of each group of repositories,
+// groupsOf returning the groups of a repository. Only the groups with production deployments are returned
+func countDORAMetrics[K comparable](filter ExportFilter, deployments []Deployment, releases []Release, pipelines []Pipeline, incidents []Incident, groupsOf func(owner, repository string) []K) map[K]*doraCounters {
+	type release struct {
+		repositoryKey
+		version string
+	}
+	type deployment struct {
+		release
+		environment string
+	}
+
+	// the incidents of a known version fail its deployment in their environment
+	failedDeployments := map[deployment]bool{}
+	for _, i := range incidents {
+		if i.Version != "" {
+			failedDeployments[deployment{release: release{repositoryKey: repositoryKey{owner: i.Owner, name: i.Repository}, version: i.Version}, environment: i.Environment}] = true
+		}
+	}
+
+	releaseTimes := map[release]time.Time{}
+	botReleases := map[release]bool{}
+	for _, r := range releases {
+		key := release{repositoryKey: repositoryKey{owner: r.Owner, name: r.Repository}, version: r.Version}
+		releaseTimes[key] = r.ReleaseTime
+		botReleases[key] = r.IsBot
+	}
+
+	groups := map[K]*doraCounters{}
+	for _, d := range deployments {
+		if !IsProductionEnvironment(d.Environment) || !filter.Matches(d.Owner, d.DeploymentTime) {
+			continue
+		}
+		key := release{repositoryKey: repositoryKey{owner: d.Owner, name: d.Repository}, version: d.Version}
+		if !filter.MatchesAuthor(botReleases[key]) {
+			continue
+		}
+		releaseTime, released := releaseTimes[key]
+		failed := failedDeployments[deployment{release: key, environment: d.Environment}]
+		for _, group := range groupsOf(d.Owner, d.Repository) {
+			if groups[group] == nil {
+				groups[group] = &doraCounters{}
+			}
+			groups[group].deployments++
+			if failed {
+				groups[group].failedDeployments++
+			}
+			if released {
+				groups[group].leadTimes = append(groups[group].leadTimes, d.DeploymentTime.Sub(releaseTime).Seconds())
+			}
+		}
+	}
+	for _, p := range pipelines {
+		if p.Type != PipelineTypeRelease || !filter.Matches(p.Owner, p.StartTime) || !filter.MatchesAuthor(p.IsBot) {
+			continue
+		}
+		for _, group := range groupsOf(p.Owner, p.Repository) {
+			c := groups[group]
+			if c == nil {
+				continue
+			}
+			c.runs++
+			if p.Status != "Succeeded" {
+				c.failed++
+			}
+		}
+	}
+	for _, i := range incidents {
+		key := release{repositoryKey: repositoryKey{owner: i.Owner, name: i.Repository}, version: i.Version}
+		if !IsProductionEnvironment(i.Environment) || !filter.Matches(i.Owner, i.DetectionTime) || !filter.MatchesAuthor(botReleases[key]) {
+			continue
+		}
+		timeToRestore, resolved := i.TimeToRestore()
+		if !resolved {
+			continue
+		}
+		for _, group := range groupsOf(i.Owner, i.Repository) {
+			if c := groups[group]; c != nil {
+				c.restoreTimes = append(c.restoreTimes, timeToRestore.Seconds())
+			}
+		}
+	}
+	return groups
+}
+
+// metrics returns the DORA metrics of the counters, without owner and repository
+func (c *doraCounters) metrics(filter ExportFilter) DORAMetrics {
+	days := math.Max(filter.To.Sub(filter.From).Hours()/24, 1)
+	m := DORAMetrics{
+		Deployments:       c.deployments,
+		DeploymentsPerDay: float64(c.deployments) / days,
+		ReleasePipelines:  c.runs,
+	}
+	if len(c.leadTimes) > 0 {
+		p50, p90 := Percentile(c.leadTimes, 0.5), Percentile(c.leadTimes, 0.9)
+		m.LeadTimeForChangesP50, m.LeadTimeForChangesP90 = &p50, &p90
+	}
+	if c.runs > 0 {
+		failureRate := float64(c.failed) / float64(c.runs)
+		m.ReleasePipelinesFailureRate = &failureRate
+	}
+	if c.deployments > 0 {
+		changeFailureRate := float64(c.failedDeployments) / float64(c.deployments)
+		m.ChangeFailureRate = &changeFailureRate
+	}
+	if len(c.restoreTimes) > 0 {
+		p50 := Percentile(c.restoreTimes, 0.5)
+		m.TimeToRestoreP50 = &p50
+	}
+	return m
+}
+
+// Percentile returns the continuous percentile of the values, like PostgreSQL's percentile_cont.
+// The values must not be empty.
+func Percentile(values []float64, p float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	position := p * float64(len(sorted)-1)
+	lower, upper := math.Floor(position), math.Ceil(position)
+	return sorted[int(lower)] + (sorted[int(upper)]-sorted[int(lower)])*(position-lower)
+}
+
+func floatValue(f *float64) any {
+	if f == nil {
+		return nil
+	}
+	return *f
+}
@@ -0,0 +1,276 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+type ColumnType string
+
+const (
+	ColumnTypeString  = ColumnType("string")
+	ColumnTypeInt     = ColumnType("int")
+	ColumnTypeFloat   = ColumnType("float")
+	ColumnTypeTime    = ColumnType("time")
+	ColumnTypeStrings = ColumnType("strings")
+	ColumnTypeBool    = ColumnType("bool")
+)
+
+// Column is an exported column. Its name is part of the export format, so it should never be renamed.
+type Column struct {
+	Name string
+	Type ColumnType
+}
+
+// ExportFilter restricts the exported rows to a time range - From is inclusive, To is exclusive -
+// and optionally to a single owner and to the changes made by humans
+type ExportFilter struct {
+	Owner string
+	From  time.Time
+	To    time.Time
+	// ExcludeBots excludes the automated changes: the pull requests, pipelines and releases of bots, and the deployments of these releases
+	ExcludeBots bool
+}
+
+// Matches returns true if the row of the given owner and time matches the filter
+func (f ExportFilter) Matches(owner string, t time.Time) bool {
+	if f.Owner != "" && f.Owner != owner {
+		return false
+	}
+	return !t.Before(f.From) && t.Before(f.To)
+}
+
+// MatchesAuthor returns true if the row - automated or not - matches the filter
+func (f ExportFilter) MatchesAuthor(isBot bool) bool {
+	return !f.ExcludeBots || !isBot
+}
+
+// datasetColumns are the columns of each exportable dataset
+var datasetColumns = map[string][]Column{
+	"pipelines": {
+		{Name: "type", Type: ColumnTypeString},
+		{Name: "owner", Type: ColumnTypeString},
+		{Name: "repository", Type: ColumnTypeString},
+		{Name: "pull_request", Type: ColumnTypeInt},
+		{Name: "context", Type: ColumnTypeString},
+		{Name: "build", Type: ColumnTypeInt},
+		{Name: "status", Type: ColumnTypeString},
+		{Name: "author", Type: ColumnTypeString},
+		{Name: "start_time", Type: ColumnTypeTime},
+		{Name: "end_time", Type: ColumnTypeTime},
+		{Name: "duration_seconds", Type: ColumnTypeInt},
+		{Name: "is_bot", Type: ColumnTypeBool},
+	},
+	"pipelinesteps": {
+		{Name: "type", Type: ColumnTypeString},
+		{Name: "owner", Type: ColumnTypeString},
+		{Name: "repository", Type: ColumnTypeString},
+		{Name: "pull_request", Type: ColumnTypeInt},
+		{Name: "context", Type: ColumnTypeString},
+		{Name: "build", Type: ColumnTypeInt},
+		{Name: "step_name", Type: ColumnTypeString},
+		{Name: "step_status", Type: ColumnTypeString},
+		{Name: "step_started_time", Type: ColumnTypeTime},
+		{Name: "step_completed_time", Type: ColumnTypeTime},
+		{Name: "step_duration_seconds", Type: ColumnTypeInt},
+		{Name: "is_bot", Type: ColumnTypeBool},
+	},
+	"pull_requests": {
+		{Name: "owner", Type: ColumnTypeString},
+		{Name: "repository", Type: ColumnTypeString},
+		{Name: "pull_request", Type: ColumnTypeInt},
+		{Name: "author", Type: ColumnTypeString},
+		{Name: "state", Type: ColumnTypeString},
+		{Name: "reviews", Type: ColumnTypeInt},
+		{Name: "reviewers", Type: ColumnTypeStrings},
+		{Name: "creation_time", Type: ColumnTypeTime},
+		{Name: "ready_for_review_time", Type: ColumnTypeTime},
+		{Name: "approved_time", Type: ColumnTypeTime},
+		{Name: "time_to_review_seconds", Type: ColumnTypeInt},
+		{Name: "merged_time", Type: ColumnTypeTime},
+		{Name: "time_to_merge_seconds", Type: ColumnTypeInt},
+		{Name: "approvers", Type: ColumnTypeStrings},
+		{Name: "first_review_time", Type: ColumnTypeTime},
+		{Name: "time_to_first_review_seconds", Type: ColumnTypeInt},
+		{Name: "first_comment_time", Type: ColumnTypeTime},
+		{Name: "time_to_first_comment_seconds", Type: ColumnTypeInt},
+		{Name: "change_requests", Type: ColumnTypeInt},
+		{Name: "pushes_after_first_review", Type: ColumnTypeInt},
+		{Name: "additions", Type: ColumnTypeInt},
+		{Name: "deletions", Type: ColumnTypeInt},
+		{Name: "changed_files", Type: ColumnTypeInt},
+		{Name: "closed_time", Type: ColumnTypeTime},
+		{Name: "closed_without_merge", Type: ColumnTypeBool},
+		{Name: "is_bot", Type: ColumnTypeBool},
+	},
+	"releases": {
+		{Name: "owner", Type: ColumnTypeString},
+		{Name: "repository", Type: ColumnTypeString},
+		{Name: "version", Type: ColumnTypeString},
+		{Name: "contributors", Type: ColumnTypeStrings},
+		{Name: "release_time", Type: ColumnTypeTime},
+		{Name: "is_bot", Type: ColumnTypeBool},
+	},
+	"deployments": {
+		{Name: "owner", Type: ColumnTypeString},
+		{Name: "repository", Type: ColumnTypeString},
+		{Name: "version", Type: ColumnTypeString},
+		{Name: "environment", Type: ColumnTypeString},
+		{Name: "deployment_time", Type: ColumnTypeTime},
+	},
+	// lighthouse_jobs are the jobs triggered by Lighthouse, see LighthouseJob. The queue and run durations are empty until known
+	"lighthouse_jobs": {
+		{Name: "name", Type: ColumnTypeString},
+		{Name: "type", Type: ColumnTypeString},
+		{Name: "job", Type: ColumnTypeString},
+		{Name: "owner", Type: ColumnTypeString},
+		{Name: "repository", Type: ColumnTypeString},
+		{Name: "pull_request", Type: ColumnTypeInt},
+		{Name: "context", Type: ColumnTypeString},
+		{Name: "sha", Type: ColumnTypeString},
+		{Name: "build", Type: ColumnTypeInt},
+		{Name: "state", Type: ColumnTypeString},
+		{Name: "author", Type: ColumnTypeString},
+		{Name: "trigger_time", Type: ColumnTypeTime},
+		{Name: "start_time", Type: ColumnTypeTime},
+		{Name: "end_time", Type: ColumnTypeTime},
+		{Name: "queue_duration_seconds", Type: ColumnTypeInt},
+		{Name: "duration_seconds", Type: ColumnTypeInt},
+		{Name: "is_retest", Type: ColumnTypeBool},
+		{Name: "is_bot", Type: ColumnTypeBool},
+	},
+	// helm_releases are the revisions of the Helm releases, see HelmRelease - including the failed and superseded ones
+	"helm_releases": {
+		{Name: "namespace", Type: ColumnTypeString},
+		{Name: "name", Type: ColumnTypeString},
+		{Name: "revision", Type: ColumnTypeInt},
+		{Name: "owner", Type: ColumnTypeString},
+		{Name: "repository", Type: ColumnTypeString},
+		{Name: "environment", Type: ColumnTypeString},
+		{Name: "chart", Type: ColumnTypeString},
+		{Name: "version", Type: ColumnTypeString},
+		{Name: "app_version", Type: ColumnTypeString},
+		{Name: "status", Type: ColumnTypeString},
+		{Name: "description", Type: ColumnTypeString},
+		{Name: "deployment_time", Type: ColumnTypeTime},
+		{Name: "is_rollback", Type: ColumnTypeBool},
+	},
+	// gitops_syncs are the syncs of the revisions by Argo CD and Flux, see GitOpsSync - including the failed ones
+	"gitops_syncs": {
+		{Name: "tool", Type: ColumnTypeString},
+		{Name: "kind", Type: ColumnTypeString},
+		{Name: "namespace", Type: ColumnTypeString},
+		{Name: "name", Type: ColumnTypeString},
+		{Name: "owner", Type: ColumnTypeString},
+		{Name: "repository", Type: ColumnTypeString},
+		{Name: "environment", Type: ColumnTypeString},
+		{Name: "revision", Type: ColumnTypeString},
+		{Name: "version", Type: ColumnTypeString},
+		{Name: "status", Type: ColumnTypeString},
+		{Name: "message", Type: ColumnTypeString},
+		{Name: "sync_time", Type: ColumnTypeTime},
+	},
+	// incidents are the incidents detected in the time range, see Incident. The time to restore is empty until they are resolved
+	"incidents": {
+		{Name: "source", Type: ColumnTypeString},
+		{Name: "id", Type: ColumnTypeString},
+		{Name: "owner", Type: ColumnTypeString},
+		{Name: "repository", Type: ColumnTypeString},
+		{Name: "environment", Type: ColumnTypeString},
+		{Name: "version", Type: ColumnTypeString},
+		{Name: "description", Type: ColumnTypeString},
+		{Name: "detection_time", Type: ColumnTypeTime},
+		{Name: "resolution_time", Type: ColumnTypeTime},
+		{Name: "time_to_restore_seconds", Type: ColumnTypeInt},
+	},
+	// dora are the DORA metrics per repository
+	"dora": {
+		{Name: "owner", Type: ColumnTypeString},
+		{Name: "repository", Type: ColumnTypeString},
+		{Name: "deployments", Type: ColumnTypeInt},
+		{Name: "deployments_per_day", Type: ColumnTypeFloat},
+		{Name: "lead_time_for_changes_p50_seconds", Type: ColumnTypeFloat},
+		{Name: "lead_time_for_changes_p90_seconds", Type: ColumnTypeFloat},
+		{Name: "release_pipelines", Type: ColumnTypeInt},
+		{Name: "release_pipelines_failure_rate", Type: ColumnTypeFloat},
+		{Name: "change_failure_rate", Type: ColumnTypeFloat},
+		{Name: "time_to_restore_p50_seconds", Type: ColumnTypeFloat},
+	},
+	// reviewer_workload is the review load per reviewer, for the pull requests created in the time range
+	"reviewer_workload": {
+		{Name: "owner", Type: ColumnTypeString},
+		{Name: "reviewer", Type: ColumnTypeString},
+		{Name: "pull_requests", Type: ColumnTypeInt},
+		{Name: "reviews", Type: ColumnTypeInt},
+		{Name: "approvals", Type: ColumnTypeInt},
+		{Name: "response_time_p50_seconds", Type: ColumnTypeFloat},
+	},
+	// review_pairs are the numbers of pull requests reviewed per author and reviewer
+	"review_pairs": {
+		{Name: "owner", Type: ColumnTypeString},
+		{Name: "author", Type: ColumnTypeString},
+		{Name: "reviewer", Type: ColumnTypeString},
+		{Name: "pull_requests", Type: ColumnTypeInt},
+	},
+	// review_bus_factor is the review bus factor per repository, see ReviewBusFactor
+	"review_bus_factor": {
+		{Name: "owner", Type: ColumnTypeString},
+		{Name: "repository", Type: ColumnTypeString},
+		{Name: "reviews", Type: ColumnTypeInt},
+		{Name: "reviewers", Type: ColumnTypeInt},
+		{Name: "bus_factor", Type: ColumnTypeInt},
+		{Name: "top_reviewer", Type: ColumnTypeString},
+		{Name: "top_reviewer_share", Type: ColumnTypeFloat},
+	},
+	// team_dora are the DORA metrics of all the repositories of each team, see TeamRepository
+	"team_dora": {
+		{Name: "team", Type: ColumnTypeString},
+		{Name: "deployments", Type: ColumnTypeInt},
+		{Name: "deployments_per_day", Type: ColumnTypeFloat},
+		{Name: "lead_time_for_changes_p50_seconds", Type: ColumnTypeFloat},
+		{Name: "lead_time_for_changes_p90_seconds", Type: ColumnTypeFloat},
+		{Name: "release_pipelines", Type: ColumnTypeInt},
+		{Name: "release_pipelines_failure_rate", Type: ColumnTypeFloat},
+		{Name: "change_failure_rate", Type: ColumnTypeFloat},
+		{Name: "time_to_restore_p50_seconds", Type: ColumnTypeFloat},
+	},
+	// team_pull_requests are the metrics of the pull requests created in the time range, per team
+	"team_pull_requests": {
+		{Name: "team", Type: ColumnTypeString},
+		{Name: "pull_requests", Type: ColumnTypeInt},
+		{Name: "merged", Type: ColumnTypeInt},
+		{Name: "closed_without_merge", Type: ColumnTypeInt},
+		{Name: "time_to_first_review_p50_seconds", Type: ColumnTypeFloat},
+		{Name: "time_to_review_p50_seconds", Type: ColumnTypeFloat},
+		{Name: "time_to_merge_p50_seconds", Type: ColumnTypeFloat},
+	},
+}
+
+// Datasets returns the names of the datasets which can be exported
+func Datasets() []string {
+	var names []string
+	for name := range datasetColumns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DatasetColumns returns the columns of the given dataset
+func DatasetColumns(name string) ([]Column, error) {
+	columns, ok := datasetColumns[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown dataset %q - must be one of %s", name, strings.Join(Datasets(), ", "))
+	}
+	return columns, nil
+}
+
+// ExportStore reads the rows of the datasets
+type ExportStore interface {
+	// Export calls rowFunc for each row of the given dataset matching the filter.
+	// The values match the column types: string, int64, float64, time.Time and []string - or nil.
+	Export(ctx context.Context, dataset string, filter ExportFilter, rowFunc func(values []any) error) error
+}
@@ -0,0 +1,43 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// The outcomes of the GitOps syncs
+const (
+	GitOpsSyncStatusSucceeded = "Succeeded"
+	GitOpsSyncStatusFailed    = "Failed"
+)
+
+// GitOpsSync is the outcome of the sync of a revision by a GitOps tool - such as Argo CD or Flux - to an environment
+type GitOpsSync struct {
+	// Tool is the GitOps tool: argocd or flux
+	Tool string
+	// Kind, Namespace and Name identify the resource of the tool, such as an Argo CD Application or a Flux HelmRelease
+	Kind        string
+	Namespace   string
+	Name        string
+	Owner       string
+	Repository  string
+	Environment string
+	// Revision is the synced revision: a chart version, or a git commit
+	Revision string
+	Version  string
+	Status   string
+	Message  string
+	// SyncTime is the end of the sync - or the time of the health check which failed it
+	SyncTime time.Time
+}
+
+// Succeeded returns true if the revision has been deployed
+func (s GitOpsSync) Succeeded() bool {
+	return s.Status == GitOpsSyncStatusSucceeded
+}
+
+// GitOpsSyncStore stores the outcomes of the GitOps syncs
+type GitOpsSyncStore interface {
+	// Add adds the sync, or updates the outcome of the sync of the same revision already stored - a failed sync may be retried
+	Add(ctx context.Context, s GitOpsSync) error
+}
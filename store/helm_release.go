@@ -0,0 +1,46 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// The statuses of the Helm release revisions
+const (
+	HelmReleaseStatusDeployed   = "deployed"
+	HelmReleaseStatusSuperseded = "superseded"
+	HelmReleaseStatusFailed     = "failed"
+)
+
+// HelmRelease is a revision of a Helm release: each install, upgrade or rollback of a release creates a new revision,
+// which is superseded by the next one
+type HelmRelease struct {
+	Namespace string
+	// Name is the name of the release
+	Name        string
+	Revision    int
+	Owner       string
+	Repository  string
+	Environment string
+	Chart       string
+	// Version is the version of the chart, AppVersion the version of the application it deploys
+	Version     string
+	AppVersion  string
+	Status      string
+	Description string
+	// DeploymentTime is the time of the install, upgrade or rollback
+	DeploymentTime time.Time
+	// IsRollback is true for the revisions created by a rollback to a previous revision
+	IsRollback bool
+}
+
+// Succeeded returns true if the revision has been deployed - even if it has been superseded since
+func (r HelmRelease) Succeeded() bool {
+	return r.Status == HelmReleaseStatusDeployed || r.Status == HelmReleaseStatusSuperseded
+}
+
+// HelmReleaseStore stores the revisions of the Helm releases
+type HelmReleaseStore interface {
+	// Add adds the revision, or updates the status of the revision already stored
+	Add(ctx context.Context, r HelmRelease) error
+}
@@ -0,0 +1,43 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// Identity maps the git user logins of a person - on all the git providers, and their bots - to a canonical person and team
+type Identity struct {
+	Person  string   `json:"person"`
+	Team    string   `json:"team,omitempty"`
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+// Validate checks that the identity has a person
+func (i Identity) Validate() error {
+	if strings.TrimSpace(i.Person) == "" {
+		return errors.New("the person of an identity can't be empty")
+	}
+	return nil
+}
+
+// Matches returns true if the login is the person or one of its aliases, case-insensitively
+func (i Identity) Matches(login string) bool {
+	if strings.EqualFold(i.Person, login) {
+		return true
+	}
+	for _, alias := range i.Aliases {
+		if strings.EqualFold(alias, login) {
+			return true
+		}
+	}
+	return false
+}
+
+// IdentityStore stores the identities managed through the API
+type IdentityStore interface {
+	List(ctx context.Context) ([]Identity, error)
+	// Put adds the identity, or replaces the identity of the same person
+	Put(ctx context.Context, identity Identity) error
+	Delete(ctx context.Context, person string) error
+}
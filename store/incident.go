@@ -0,0 +1,44 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Incident is an incident of an application in an environment, such as the ones of the CDEvents incident events.
+// An incident whose version is known fails the deployment of this version, for the change failure rate.
+type Incident struct {
+	// Source and ID identify the incident in the tool which detected it
+	Source      string
+	ID          string
+	Owner       string
+	Repository  string
+	Environment string
+	// Version is the deployed version causing the incident, if known
+	Version     string
+	Description string
+	// DetectionTime is the earliest time the incident was detected or reported
+	DetectionTime time.Time
+	// ResolutionTime is nil while the incident is not resolved
+	ResolutionTime *time.Time
+}
+
+func (i Incident) String() string {
+	return fmt.Sprintf(`%q from %q of "%s/%s" in %q`, i.ID, i.Source, i.Owner, i.Repository, i.Environment)
+}
+
+// TimeToRestore returns the time between the detection and the resolution of the incident, or false while it is not resolved
+func (i Incident) TimeToRestore() (time.Duration, bool) {
+	if i.ResolutionTime == nil {
+		return 0, false
+	}
+	return i.ResolutionTime.Sub(i.DetectionTime), true
+}
+
+// IncidentStore stores the incidents
+type IncidentStore interface {
+	// Add adds the incident, or merges it into the incident of the same source and id already stored:
+	// the earliest detection time is kept, and the other fields are updated unless empty - as the events of an incident may come out of order
+	Add(ctx context.Context, i Incident) error
+}
@@ -0,0 +1,83 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// The types of the Lighthouse jobs
+const (
+	LighthouseJobTypePresubmit  = "presubmit"
+	LighthouseJobTypePostsubmit = "postsubmit"
+	LighthouseJobTypePeriodic   = "periodic"
+	LighthouseJobTypeBatch      = "batch"
+)
+
+// LighthouseJob is a job triggered by Lighthouse: for a push, a pull request, a ChatOps command such as /retest, or a schedule.
+// Its build is the build of the pipeline it triggered.
+type LighthouseJob struct {
+	// Name is the name of the LighthouseJob resource
+	Name string
+	Type string
+	// Job is the name of the job in the Lighthouse config
+	Job string
+	// Owner and Repository are empty for the periodic jobs without repository
+	Owner       string
+	Repository  string
+	PullRequest int
+	Context     string
+	// SHA is the commit the job ran on: the head of the pull request, or the base commit
+	SHA    string
+	Build  int
+	State  string
+	Author string
+	// TriggerTime is the creation time of the job
+	TriggerTime time.Time
+	// StartTime is the start time of its pipeline - nil while the job is queued
+	StartTime *time.Time
+	EndTime   *time.Time
+	// QueueDuration is the duration between the trigger and the start of the pipeline
+	QueueDuration time.Duration
+	Duration      time.Duration
+	// IsRetest is computed on export: see MarkRetests
+	IsRetest bool
+	IsBot    bool
+}
+
+// LighthouseJobStore stores the Lighthouse jobs
+type LighthouseJobStore interface {
+	// Add adds the job, or updates the job of the same name with its latest state
+	Add(ctx context.Context, j LighthouseJob) error
+}
+
+// MarkRetests flags the jobs triggered again for a commit which already had a job of the same context,
+// so by a ChatOps command such as /retest or /test rather than by a push
+func MarkRetests(jobs []LighthouseJob) {
+	type key struct {
+		Type, Owner, Repository, Context, SHA string
+		PullRequest                           int
+	}
+
+	indexes := make([]int, len(jobs))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		return jobs[indexes[i]].TriggerTime.Before(jobs[indexes[j]].TriggerTime)
+	})
+
+	seen := map[key]time.Time{}
+	for _, i := range indexes {
+		j := &jobs[i]
+		if j.SHA == "" || j.Type == LighthouseJobTypePeriodic {
+			continue
+		}
+		k := key{Type: j.Type, Owner: j.Owner, Repository: j.Repository, Context: j.Context, SHA: j.SHA, PullRequest: j.PullRequest}
+		first, found := seen[k]
+		j.IsRetest = found && first.Before(j.TriggerTime)
+		if !found {
+			seen[k] = j.TriggerTime
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jenkins-x/cd-indicators/store"
+)
+
+type deploymentKey struct {
+	Owner       string
+	Repository  string
+	Version     string
+	Environment string
+}
+
+type DeploymentStore struct {
+	mutex       sync.Mutex
+	deployments []store.Deployment
+	index       map[deploymentKey]struct{}
+	notifier    *store.Notifier
+}
+
+func (s *DeploymentStore) Add(ctx context.Context, d store.Deployment) error {
+	if s.add(d) {
+		s.notifier.DeploymentAdded(ctx, d)
+	}
+	return nil
+}
+
+// add stores the deployment, and returns true if it was not stored yet
+func (s *DeploymentStore) add(d store.Deployment) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.index == nil {
+		s.index = map[deploymentKey]struct{}{}
+	}
+
+	key := deploymentKey{
+		Owner:       d.Owner,
+		Repository:  d.Repository,
+		Version:     d.Version,
+		Environment: d.Environment,
+	}
+	if _, found := s.index[key]; found {
+		return false
+	}
+	// only stored in the deployment events, which the in-memory storage doesn't have
+	d.SourceEnvironment = ""
+	s.deployments = append(s.deployments, d)
+	s.index[key] = struct{}{}
+
+	return true
+}
+
+// List returns a copy of all the stored deployments, in insertion order
+func (s *DeploymentStore) List() []store.Deployment {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]store.Deployment(nil), s.deployments...)
+}
+
+// filter keeps only the deployments for which keep returns true
+func (s *DeploymentStore) filter(keep func(d store.Deployment) bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var deployments []store.Deployment
+	s.index = map[deploymentKey]struct{}{}
+	for _, d := range s.deployments {
+		if !keep(d) {
+			continue
+		}
+		deployments = append(deployments, d)
+		s.index[deploymentKey{
+			Owner:       d.Owner,
+			Repository:  d.Repository,
+			Version:     d.Version,
+			Environment: d.Environment,
+		}] = struct{}{}
+	}
+	s.deployments = deployments
+}
@@ -0,0 +1,315 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jenkins-x/cd-indicators/store"
+)
+
+type ExportStore struct {
+	pipelines    *PipelineStore
+	pullRequests *PullRequestStore
+	events       *PullRequestEventStore
+	releases     *ReleaseStore
+	deployments  *DeploymentStore
+	teams        *TeamStore
+	jobs         *LighthouseJobStore
+	helmReleases *HelmReleaseStore
+	gitOpsSyncs  *GitOpsSyncStore
+	incidents    *IncidentStore
+}
+
+func (s *ExportStore) Export(_ context.Context, name string, filter store.ExportFilter, rowFunc func(values []any) error) error {
+	if _, err := store.DatasetColumns(name); err != nil {
+		return err
+	}
+
+	var rows [][]any
+	switch name {
+	case "pipelines":
+		rows = s.pipelineRows(filter)
+	case "pipelinesteps":
+		rows = s.pipelineStepRows(filter)
+	case "pull_requests":
+		rows = s.pullRequestRows(filter)
+	case "releases":
+		rows = s.releaseRows(filter)
+	case "deployments":
+		rows = s.deploymentRows(filter)
+	case "lighthouse_jobs":
+		rows = s.lighthouseJobRows(filter)
+	case "helm_releases":
+		rows = s.helmReleaseRows(filter)
+	case "gitops_syncs":
+		rows = s.gitOpsSyncRows(filter)
+	case "incidents":
+		rows = s.incidentRows(filter)
+	case "dora":
+		rows = s.doraRows(filter)
+	case "reviewer_workload":
+		for _, w := range store.ComputeReviewerWorkload(filter, s.pullRequests.List(), s.events.list()) {
+			rows = append(rows, w.Values())
+		}
+	case "review_pairs":
+		for _, p := range store.ComputeReviewPairs(filter, s.pullRequests.List()) {
+			rows = append(rows, p.Values())
+		}
+	case "review_bus_factor":
+		for _, b := range store.ComputeReviewBusFactors(filter, s.pullRequests.List()) {
+			rows = append(rows, b.Values())
+		}
+	case "team_dora":
+		for _, m := range store.ComputeTeamDORAMetrics(filter, s.teams.list(), s.deployments.List(), s.releases.List(), s.pipelines.List(), s.incidents.List()) {
+			rows = append(rows, m.Values())
+		}
+	case "team_pull_requests":
+		for _, m := range store.ComputeTeamPullRequestMetrics(filter, s.teams.list(), s.pullRequests.List()) {
+			rows = append(rows, m.Values())
+		}
+	default:
+		return fmt.Errorf("dataset %s is not supported by the in-memory storage", name)
+	}
+
+	for _, row := range rows {
+		if err := rowFunc(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *ExportStore) pipelineRows(filter store.ExportFilter) [][]any {
+	pipelines := s.pipelines.List()
+	sort.SliceStable(pipelines, func(i, j int) bool {
+		return pipelines[i].StartTime.Before(pipelines[j].StartTime)
+	})
+
+	var rows [][]any
+	for _, p := range pipelines {
+		if !filter.Matches(p.Owner, p.StartTime) || !filter.MatchesAuthor(p.IsBot) {
+			continue
+		}
+		rows = append(rows, []any{
+			string(p.Type), p.Owner, p.Repository, int64(p.PullRequest), p.Context, int64(p.Build), p.Status, p.Author,
+			p.StartTime.UTC(), p.EndTime.UTC(), int64(p.Duration.Seconds()), p.IsBot,
+		})
+	}
+	return rows
+}
+
+func (s *ExportStore) pipelineStepRows(filter store.ExportFilter) [][]any {
+	type pipelineStep struct {
+		pipeline store.Pipeline
+		step     store.SimplifiedActivityStep
+	}
+	var steps []pipelineStep
+	for _, p := range s.pipelines.List() {
+		for _, step := range p.Steps {
+			steps = append(steps, pipelineStep{pipeline: p, step: step})
+		}
+	}
+	sort.SliceStable(steps, func(i, j int) bool {
+		return steps[i].step.StartedTimestamp.Before(steps[j].step.StartedTimestamp)
+	})
+
+	var rows [][]any
+	for _, s := range steps {
+		p, step := s.pipeline, s.step
+		if !filter.Matches(p.Owner, step.StartedTimestamp) || !filter.MatchesAuthor(p.IsBot) {
+			continue
+		}
+		rows = append(rows, []any{
+			string(p.Type), p.Owner, p.Repository, int64(p.PullRequest), p.Context, int64(p.Build),
+			step.Name, step.Status, step.StartedTimestamp.UTC(), step.CompletedTimestamp.UTC(), int64(step.Duration.Seconds()), p.IsBot,
+		})
+	}
+	return rows
+}
+
+func (s *ExportStore) pullRequestRows(filter store.ExportFilter) [][]any {
+	var pullRequests []store.PullRequest
+	for _, pr := range s.pullRequests.List() {
+		if pr.CreationTime != nil && filter.Matches(pr.Owner, *pr.CreationTime) && filter.MatchesAuthor(pr.IsBot) {
+			pullRequests = append(pullRequests, pr)
+		}
+	}
+	sort.SliceStable(pullRequests, func(i, j int) bool {
+		return pullRequests[i].CreationTime.Before(*pullRequests[j].CreationTime)
+	})
+
+	var rows [][]any
+	for _, pr := range pullRequests {
+		rows = append(rows, []any{
+			pr.Owner, pr.Repository, int64(pr.PullRequest), pr.Author, pr.State, int64(pr.Reviews), pr.Reviewers,
+			timeValue(pr.CreationTime), timeValue(pr.ReadyForReviewTime), timeValue(pr.ApprovedTime), int64(pr.TimeToReview.Seconds()),
+			timeValue(pr.MergedTime), int64(pr.TimeToMerge.Seconds()), pr.Approvers,
+			timeValue(pr.FirstReviewTime), int64(pr.TimeToFirstReview.Seconds()), timeValue(pr.FirstCommentTime), int64(pr.TimeToFirstComment.Seconds()),
+			int64(pr.ChangeRequests), int64(pr.PushesAfterFirstReview), int64(pr.Additions), int64(pr.Deletions), int64(pr.ChangedFiles),
+			timeValue(pr.ClosedTime), pr.ClosedWithoutMerge(), pr.IsBot,
+		})
+	}
+	return rows
+}
+
+func (s *ExportStore) releaseRows(filter store.ExportFilter) [][]any {
+	releases := s.releases.List()
+	sort.SliceStable(releases, func(i, j int) bool {
+		return releases[i].ReleaseTime.Before(releases[j].ReleaseTime)
+	})
+
+	var rows [][]any
+	for _, r := range releases {
+		if !filter.Matches(r.Owner, r.ReleaseTime) || !filter.MatchesAuthor(r.IsBot) {
+			continue
+		}
+		rows = append(rows, []any{r.Owner, r.Repository, r.Version, r.Contributors, r.ReleaseTime.UTC(), r.IsBot})
+	}
+	return rows
+}
+
+func (s *ExportStore) deploymentRows(filter store.ExportFilter) [][]any {
+	// the deployments of the automated releases are excluded with them
+	botReleases := map[string]bool{}
+	for _, r := range s.releases.List() {
+		botReleases[r.Owner+"/"+r.Repository+"@"+r.Version] = r.IsBot
+	}
+
+	deployments := s.deployments.List()
+	sort.SliceStable(deployments, func(i, j int) bool {
+		return deployments[i].DeploymentTime.Before(deployments[j].DeploymentTime)
+	})
+
+	var rows [][]any
+	for _, d := range deployments {
+		if !filter.Matches(d.Owner, d.DeploymentTime) || !filter.MatchesAuthor(botReleases[d.Owner+"/"+d.Repository+"@"+d.Version]) {
+			continue
+		}
+		rows = append(rows, []any{d.Owner, d.Repository, d.Version, d.Environment, d.DeploymentTime.UTC()})
+	}
+	return rows
+}
+
+func (s *ExportStore) doraRows(filter store.ExportFilter) [][]any {
+	var rows [][]any
+	for _, m := range store.ComputeDORAMetrics(filter, s.deployments.List(), s.releases.List(), s.pipelines.List(), s.incidents.List()) {
+		rows = append(rows, m.Values())
+	}
+	return rows
+}
+
+func (s *ExportStore) lighthouseJobRows(filter store.ExportFilter) [][]any {
+	// the retests are found among all the jobs, including the ones out of the time range
+	jobs := s.jobs.List()
+	store.MarkRetests(jobs)
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return jobs[i].TriggerTime.Before(jobs[j].TriggerTime)
+	})
+
+	var rows [][]any
+	for _, j := range jobs {
+		if !filter.Matches(j.Owner, j.TriggerTime) || !filter.MatchesAuthor(j.IsBot) {
+			continue
+		}
+		var queueDuration, duration any
+		if j.StartTime != nil {
+			queueDuration = int64(j.QueueDuration.Seconds())
+		}
+		if j.StartTime != nil && j.EndTime != nil {
+			duration = int64(j.Duration.Seconds())
+		}
+		rows = append(rows, []any{
+			j.Name, j.Type, j.Job, j.Owner, j.Repository, int64(j.PullRequest), j.Context, j.SHA, int64(j.Build), j.State, j.Author,
+			j.TriggerTime.UTC(), timeValue(j.StartTime), timeValue(j.EndTime), queueDuration, duration, j.IsRetest, j.IsBot,
+		})
+	}
+	return rows
+}
+
+func (s *ExportStore) helmReleaseRows(filter store.ExportFilter) [][]any {
+	// the revisions of the automated releases are excluded with them, like their deployments
+	botReleases := map[string]bool{}
+	for _, r := range s.releases.List() {
+		botReleases[r.Owner+"/"+r.Repository+"@"+r.Version] = r.IsBot
+	}
+
+	releases := s.helmReleases.List()
+	sort.SliceStable(releases, func(i, j int) bool {
+		return releases[i].DeploymentTime.Before(releases[j].DeploymentTime)
+	})
+
+	var rows [][]any
+	for _, r := range releases {
+		if !filter.Matches(r.Owner, r.DeploymentTime) || !filter.MatchesAuthor(botReleases[r.Owner+"/"+r.Repository+"@"+r.Version]) {
+			continue
+		}
+		rows = append(rows, []any{
+			r.Namespace, r.Name, int64(r.Revision), r.Owner, r.Repository, r.Environment, r.Chart, r.Version, r.AppVersion, r.Status, r.Description,
+			r.DeploymentTime.UTC(), r.IsRollback,
+		})
+	}
+	return rows
+}
+
+func (s *ExportStore) gitOpsSyncRows(filter store.ExportFilter) [][]any {
+	// the syncs of the automated releases are excluded with them, like their deployments
+	botReleases := map[string]bool{}
+	for _, r := range s.releases.List() {
+		botReleases[r.Owner+"/"+r.Repository+"@"+r.Version] = r.IsBot
+	}
+
+	syncs := s.gitOpsSyncs.List()
+	sort.SliceStable(syncs, func(i, j int) bool {
+		return syncs[i].SyncTime.Before(syncs[j].SyncTime)
+	})
+
+	var rows [][]any
+	for _, sync := range syncs {
+		if !filter.Matches(sync.Owner, sync.SyncTime) || !filter.MatchesAuthor(botReleases[sync.Owner+"/"+sync.Repository+"@"+sync.Version]) {
+			continue
+		}
+		rows = append(rows, []any{
+			sync.Tool, sync.Kind, sync.Namespace, sync.Name, sync.Owner, sync.Repository, sync.Environment, sync.Revision, sync.Version,
+			sync.Status, sync.Message, sync.SyncTime.UTC(),
+		})
+	}
+	return rows
+}
+
+func (s *ExportStore) incidentRows(filter store.ExportFilter) [][]any {
+	// the incidents of the automated releases are excluded with them, like their deployments
+	botReleases := map[string]bool{}
+	for _, r := range s.releases.List() {
+		botReleases[r.Owner+"/"+r.Repository+"@"+r.Version] = r.IsBot
+	}
+
+	incidents := s.incidents.List()
+	sort.SliceStable(incidents, func(i, j int) bool {
+		return incidents[i].DetectionTime.Before(incidents[j].DetectionTime)
+	})
+
+	var rows [][]any
+	for _, i := range incidents {
+		if !filter.Matches(i.Owner, i.DetectionTime) || !filter.MatchesAuthor(botReleases[i.Owner+"/"+i.Repository+"@"+i.Version]) {
+			continue
+		}
+		var timeToRestore any
+		if d, resolved := i.TimeToRestore(); resolved {
+			timeToRestore = int64(d.Round(time.Second).Seconds())
+		}
+		rows = append(rows, []any{
+			i.Source, i.ID, i.Owner, i.Repository, i.Environment, i.Version, i.Description,
+			i.DetectionTime.UTC(), timeValue(i.ResolutionTime), timeToRestore,
+		})
+	}
+	return rows
+}
+
+func timeValue(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return t.UTC()
+}
@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jenkins-x/cd-indicators/store"
+)
+
+type gitOpsSyncKey struct {
+	Tool      string
+	Kind      string
+	Namespace string
+	Name      string
+	Revision  string
+}
+
+func newGitOpsSyncKey(s store.GitOpsSync) gitOpsSyncKey {
+	return gitOpsSyncKey{Tool: s.Tool, Kind: s.Kind, Namespace: s.Namespace, Name: s.Name, Revision: s.Revision}
+}
+
+type GitOpsSyncStore struct {
+	mutex sync.Mutex
+	syncs []store.GitOpsSync
+	index map[gitOpsSyncKey]int
+}
+
+func (s *GitOpsSyncStore) Add(_ context.Context, sync store.GitOpsSync) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.index == nil {
+		s.index = map[gitOpsSyncKey]int{}
+	}
+	key := newGitOpsSyncKey(sync)
+	i, found := s.index[key]
+	if !found {
+		s.syncs = append(s.syncs, sync)
+		s.index[key] = len(s.syncs) - 1
+		return nil
+	}
+
+	// like the other backends, only the outcome of the sync changes
+	stored := &s.syncs[i]
+	stored.Status = sync.Status
+	stored.Message = sync.Message
+	stored.SyncTime = sync.SyncTime
+	return nil
+}
+
+// List returns a copy of all the stored syncs, in insertion order
+func (s *GitOpsSyncStore) List() []store.GitOpsSync {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]store.GitOpsSync(nil), s.syncs...)
+}
+
+// filter keeps only the syncs for which keep returns true
+func (s *GitOpsSyncStore) filter(keep func(sync store.GitOpsSync) bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var syncs []store.GitOpsSync
+	s.index = map[gitOpsSyncKey]int{}
+	for _, sync := range s.syncs {
+		if !keep(sync) {
+			continue
+		}
+		syncs = append(syncs, sync)
+		s.index[newGitOpsSyncKey(sync)] = len(syncs) - 1
+	}
+	s.syncs = syncs
+}
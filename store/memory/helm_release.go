@@ -0,0 +1,68 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jenkins-x/cd-indicators/store"
+)
+
+type helmReleaseKey struct {
+	Namespace string
+	Name      string
+	Revision  int
+}
+
+type HelmReleaseStore struct {
+	mutex    sync.Mutex
+	releases []store.HelmRelease
+	index    map[helmReleaseKey]int
+}
+
+func (s *HelmReleaseStore) Add(_ context.Context, r store.HelmRelease) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.index == nil {
+		s.index = map[helmReleaseKey]int{}
+	}
+	key := helmReleaseKey{Namespace: r.Namespace, Name: r.Name, Revision: r.Revision}
+	i, found := s.index[key]
+	if !found {
+		s.releases = append(s.releases, r)
+		s.index[key] = len(s.releases) - 1
+		return nil
+	}
+
+	// like the other backends, only the status of the revision changes
+	stored := &s.releases[i]
+	stored.Status = r.Status
+	stored.Description = r.Description
+	stored.DeploymentTime = r.DeploymentTime
+	return nil
+}
+
+// List returns a copy of all the stored revisions, in insertion order
+func (s *HelmReleaseStore) List() []store.HelmRelease {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]store.HelmRelease(nil), s.releases...)
+}
+
+// filter keeps only the revisions for which keep returns true
+func (s *HelmReleaseStore) filter(keep func(r store.HelmRelease) bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var releases []store.HelmRelease
+	s.index = map[helmReleaseKey]int{}
+	for _, r := range s.releases {
+		if !keep(r) {
+			continue
+		}
+		releases = append(releases, r)
+		s.index[helmReleaseKey{Namespace: r.Namespace, Name: r.Name, Revision: r.Revision}] = len(releases) - 1
+	}
+	s.releases = releases
+}
@@ -0,0 +1,53 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/jenkins-x/cd-indicators/store"
+)
+
+type IdentityStore struct {
+	mutex      sync.Mutex
+	identities map[string]store.Identity
+}
+
+func (s *IdentityStore) List(_ context.Context) ([]store.Identity, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	identities := make([]store.Identity, 0, len(s.identities))
+	for _, identity := range s.identities {
+		identity.Aliases = append([]string(nil), identity.Aliases...)
+		identities = append(identities, identity)
+	}
+	sort.Slice(identities, func(i, j int) bool {
+		return identities[i].Person < identities[j].Person
+	})
+	return identities, nil
+}
+
+func (s *IdentityStore) Put(_ context.Context, identity store.Identity) error {
+	if err := identity.Validate(); err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.identities == nil {
+		s.identities = map[string]store.Identity{}
+	}
+	identity.Aliases = append([]string(nil), identity.Aliases...)
+	s.identities[identity.Person] = identity
+	return nil
+}
+
+func (s *IdentityStore) Delete(_ context.Context, person string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.identities, person)
+	return nil
+}
@@ -0,0 +1,81 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jenkins-x/cd-indicators/store"
+)
+
+type incidentKey struct {
+	Source string
+	ID     string
+}
+
+type IncidentStore struct {
+	mutex     sync.Mutex
+	incidents []store.Incident
+	index     map[incidentKey]int
+}
+
+func (s *IncidentStore) Add(_ context.Context, i store.Incident) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.index == nil {
+		s.index = map[incidentKey]int{}
+	}
+	key := incidentKey{Source: i.Source, ID: i.ID}
+	index, found := s.index[key]
+	if !found {
+		s.incidents = append(s.incidents, i)
+		s.index[key] = len(s.incidents) - 1
+		return nil
+	}
+
+	// like the other backends, the earliest detection time is kept, and the empty fields don't overwrite the stored ones
+	stored := &s.incidents[index]
+	for _, field := range []struct{ stored, value *string }{
+		{&stored.Owner, &i.Owner},
+		{&stored.Repository, &i.Repository},
+		{&stored.Environment, &i.Environment},
+		{&stored.Version, &i.Version},
+		{&stored.Description, &i.Description},
+	} {
+		if *field.value != "" {
+			*field.stored = *field.value
+		}
+	}
+	if i.DetectionTime.Before(stored.DetectionTime) {
+		stored.DetectionTime = i.DetectionTime
+	}
+	if i.ResolutionTime != nil {
+		stored.ResolutionTime = i.ResolutionTime
+	}
+	return nil
+}
+
+// List returns a copy of all the stored incidents, in insertion order
+func (s *IncidentStore) List() []store.Incident {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]store.Incident(nil), s.incidents...)
+}
+
+// filter keeps only the incidents for which keep returns true
+func (s *IncidentStore) filter(keep func(i store.Incident) bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var incidents []store.Incident
+	s.index = map[incidentKey]int{}
+	for _, i := range s.incidents {
+		if !keep(i) {
+			continue
+		}
+		incidents = append(incidents, i)
+		s.index[incidentKey{Source: i.Source, ID: i.ID}] = len(incidents) - 1
+	}
+	s.incidents = incidents
+}
@@ -0,0 +1,64 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jenkins-x/cd-indicators/store"
+)
+
+type LighthouseJobStore struct {
+	mutex sync.Mutex
+	jobs  []store.LighthouseJob
+	index map[string]int
+}
+
+func (s *LighthouseJobStore) Add(_ context.Context, j store.LighthouseJob) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.index == nil {
+		s.index = map[string]int{}
+	}
+	i, found := s.index[j.Name]
+	if !found {
+		s.jobs = append(s.jobs, j)
+		s.index[j.Name] = len(s.jobs) - 1
+		return nil
+	}
+
+	// like the other backends, only the state of the job changes
+	stored := &s.jobs[i]
+	stored.Build = j.Build
+	stored.State = j.State
+	stored.StartTime = j.StartTime
+	stored.EndTime = j.EndTime
+	stored.QueueDuration = j.QueueDuration
+	stored.Duration = j.Duration
+	return nil
+}
+
+// List returns a copy of all the stored jobs, in insertion order
+func (s *LighthouseJobStore) List() []store.LighthouseJob {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]store.LighthouseJob(nil), s.jobs...)
+}
+
+// filter keeps only the jobs for which keep returns true
+func (s *LighthouseJobStore) filter(keep func(j store.LighthouseJob) bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var jobs []store.LighthouseJob
+	s.index = map[string]int{}
+	for _, j := range s.jobs {
+		if !keep(j) {
+			continue
+		}
+		jobs = append(jobs, j)
+		s.index[j.Name] = len(jobs) - 1
+	}
+	s.jobs = jobs
+}
@@ -0,0 +1,116 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jenkins-x/cd-indicators/store"
+)
+
+type pipelineKey struct {
+	Type        store.PipelineType
+	Owner       string
+	Repository  string
+	PullRequest int
+	Context     string
+	Build       int
+}
+
+type PipelineStore struct {
+	mutex     sync.Mutex
+	pipelines []store.Pipeline
+	index     map[pipelineKey]int
+	notifier  *store.Notifier
+}
+
+func (s *PipelineStore) Add(ctx context.Context, p store.Pipeline) error {
+	if s.add(p) {
+		s.notifier.PipelineAdded(ctx, p)
+	}
+	return nil
+}
+
+// add stores the pipeline and its new steps, and returns true if the pipeline was not stored yet
+func (s *PipelineStore) add(p store.Pipeline) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.index == nil {
+		s.index = map[pipelineKey]int{}
+	}
+
+	key := pipelineKey{
+		Type:        p.Type,
+		Owner:       p.Owner,
+		Repository:  p.Repository,
+		PullRequest: p.PullRequest,
+		Context:     p.Context,
+		Build:       p.Build,
+	}
+	steps := p.Steps
+	i, found := s.index[key]
+	if !found {
+		p.Steps = nil
+		// only stored in the pipeline events, which the in-memory storage doesn't have
+		p.AuthorLogin = ""
+		s.pipelines = append(s.pipelines, p)
+		i = len(s.pipelines) - 1
+		s.index[key] = i
+	}
+
+	// like the pipeline, a step which has already been stored is kept as-is
+	stored := &s.pipelines[i]
+	for _, step := range steps {
+		if !hasStep(stored.Steps, step.Name) {
+			stored.Steps = append(stored.Steps, step)
+		}
+	}
+
+	return !found
+}
+
+// List returns a copy of all the stored pipelines, in insertion order
+func (s *PipelineStore) List() []store.Pipeline {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	pipelines := make([]store.Pipeline, len(s.pipelines))
+	for i, p := range s.pipelines {
+		p.Steps = append([]store.SimplifiedActivityStep(nil), p.Steps...)
+		pipelines[i] = p
+	}
+	return pipelines
+}
+
+// filter keeps only the pipelines for which keep returns true, after letting it update their steps
+func (s *PipelineStore) filter(keep func(p *store.Pipeline) bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var pipelines []store.Pipeline
+	s.index = map[pipelineKey]int{}
+	for _, p := range s.pipelines {
+		if !keep(&p) {
+			continue
+		}
+		pipelines = append(pipelines, p)
+		s.index[pipelineKey{
+			Type:        p.Type,
+			Owner:       p.Owner,
+			Repository:  p.Repository,
+			PullRequest: p.PullRequest,
+			Context:     p.Context,
+			Build:       p.Build,
+		}] = len(pipelines) - 1
+	}
+	s.pipelines = pipelines
+}
+
+func hasStep(steps []store.SimplifiedActivityStep, name string) bool {
+	for _, step := range steps {
+		if step.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/cd-indicators/store"
+)
+
+func TestPipelineStoreKeepsTheStoredPipelineAndAddsNewSteps(t *testing.T) {
+	ctx := context.Background()
+	s := &PipelineStore{notifier: &store.Notifier{}}
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	pipeline := store.Pipeline{
+		Type:       store.PipelineTypeRelease,
+		Owner:      "org",
+		Repository: "app",
+		Context:    "release",
+		Build:      1,
+		Status:     "Running",
+		StartTime:  start,
+		Steps: []store.SimplifiedActivityStep{
+			{Name: "build", Status: "Running"},
+		},
+	}
+	if err := s.Add(ctx, pipeline); err != nil {
+		t.Fatal(err)
+	}
+
+	pipeline.Status = "Succeeded"
+	pipeline.Steps = []store.SimplifiedActivityStep{
+		{Name: "build", Status: "Succeeded"},
+		{Name: "test", Status: "Succeeded"},
+	}
+	if err := s.Add(ctx, pipeline); err != nil {
+		t.Fatal(err)
+	}
+	other := pipeline
+	other.Build = 2
+	if err := s.Add(ctx, other); err != nil {
+		t.Fatal(err)
+	}
+
+	pipelines := s.List()
+	if len(pipelines) != 2 || pipelines[0].Build != 1 || pipelines[1].Build != 2 {
+		t.Fatalf("expected the 2 builds in insertion order, got %v", pipelines)
+	}
+	stored := pipelines[0]
+	if stored.Status != "Running" {
+		t.Errorf("expected the stored pipeline to be kept as-is, got status %q", stored.Status)
+	}
+	if len(stored.Steps) != 2 || stored.Steps[0].Status != "Running" || stored.Steps[1].Name != "test" {
+		t.Errorf("expected the stored step to be kept and the new step to be added, got %v", stored.Steps)
+	}
+}
+
+func TestReleaseStoreIgnoresTheStoredReleases(t *testing.T) {
+	ctx := context.Background()
+	s := &ReleaseStore{notifier: &store.Notifier{}}
+	releaseTime := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	for _, r := range []store.Release{
+		{Owner: "org", Repository: "app", Version: "1.0.0", Contributors: []string{"alice"}, ReleaseTime: releaseTime},
+		{Owner: "org", Repository: "app", Version: "1.0.0", Contributors: []string{"bob"}, ReleaseTime: releaseTime.Add(time.Hour)},
+		{Owner: "org", Repository: "app", Version: "1.0.1", ReleaseTime: releaseTime.Add(2 * time.Hour)},
+	} {
+		if err := s.Add(ctx, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	releases := s.List()
+	if len(releases) != 2 || releases[0].Version != "1.0.0" || releases[1].Version != "1.0.1" {
+		t.Fatalf("expected the 2 versions in insertion order, got %v", releases)
+	}
+	if len(releases[0].Contributors) != 1 || releases[0].Contributors[0] != "alice" || !releases[0].ReleaseTime.Equal(releaseTime) {
+		t.Errorf("expected the first release to be kept, got %v released at %s", releases[0].Contributors, releases[0].ReleaseTime)
+	}
+}
@@ -0,0 +1,123 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jenkins-x/cd-indicators/store"
+)
+
+type pullRequestKey struct {
+	Owner       string
+	Repository  string
+	PullRequest int
+}
+
+type PullRequestStore struct {
+	mutex        sync.Mutex
+	pullRequests []store.PullRequest
+	index        map[pullRequestKey]int
+	notifier     *store.Notifier
+}
+
+func (s *PullRequestStore) Add(ctx context.Context, pr store.PullRequest) error {
+	if merged, newlyMerged := s.add(pr); newlyMerged {
+		s.notifier.PullRequestMerged(ctx, merged)
+	}
+	return nil
+}
+
+// add merges the pull request with the stored one, and returns the result - and true if the pull request was just merged
+func (s *PullRequestStore) add(pr store.PullRequest) (store.PullRequest, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.index == nil {
+		s.index = map[pullRequestKey]int{}
+	}
+
+	key := pullRequestKey{
+		Owner:       pr.Owner,
+		Repository:  pr.Repository,
+		PullRequest: pr.PullRequest,
+	}
+	i, found := s.index[key]
+	var stored store.PullRequest
+	if found {
+		stored = s.pullRequests[i]
+		// the author is only set when the pull request is first stored
+		pr.Author = stored.Author
+	}
+	// always merge, even with an empty pull request, see PullRequest.MergeWith
+	pr.MergeWith(stored)
+	pr.ApplyApprovalRule()
+	pr.CalculateDurations()
+	newlyMerged := stored.MergedTime == nil && pr.MergedTime != nil
+
+	if found {
+		s.pullRequests[i] = copyPullRequest(pr)
+	} else {
+		s.pullRequests = append(s.pullRequests, copyPullRequest(pr))
+		s.index[key] = len(s.pullRequests) - 1
+	}
+
+	return copyPullRequest(pr), newlyMerged
+}
+
+// List returns a copy of all the stored pull requests, in insertion order
+func (s *PullRequestStore) List() []store.PullRequest {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	pullRequests := make([]store.PullRequest, len(s.pullRequests))
+	for i, pr := range s.pullRequests {
+		pullRequests[i] = copyPullRequest(pr)
+	}
+	return pullRequests
+}
+
+// filter keeps only the pull requests for which keep returns true
+func (s *PullRequestStore) filter(keep func(pr store.PullRequest) bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var pullRequests []store.PullRequest
+	s.index = map[pullRequestKey]int{}
+	for _, pr := range s.pullRequests {
+		if !keep(pr) {
+			continue
+		}
+		pullRequests = append(pullRequests, pr)
+		s.index[pullRequestKey{
+			Owner:       pr.Owner,
+			Repository:  pr.Repository,
+			PullRequest: pr.PullRequest,
+		}] = len(pullRequests) - 1
+	}
+	s.pullRequests = pullRequests
+}
+
+// copyPullRequest returns a deep copy, so that the stored pull requests don't share any state with the callers
+func copyPullRequest(pr store.PullRequest) store.PullRequest {
+	pr.Reviewers = append([]string(nil), pr.Reviewers...)
+	pr.Approvers = append([]string(nil), pr.Approvers...)
+	pr.ApprovalRule = nil
+	pr.RevokedApprovers = nil
+	pr.CreationTime = copyTime(pr.CreationTime)
+	pr.ReadyForReviewTime = copyTime(pr.ReadyForReviewTime)
+	pr.ApprovedTime = copyTime(pr.ApprovedTime)
+	pr.MergedTime = copyTime(pr.MergedTime)
+	pr.FirstReviewTime = copyTime(pr.FirstReviewTime)
+	pr.FirstCommentTime = copyTime(pr.FirstCommentTime)
+	pr.ClosedTime = copyTime(pr.ClosedTime)
+	return pr
+}
+
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
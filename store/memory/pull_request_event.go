@@ -0,0 +1,58 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jenkins-x/cd-indicators/store"
+)
+
+type PullRequestEventStore struct {
+	mutex  sync.Mutex
+	events []store.PullRequestEvent
+}
+
+func (s *PullRequestEventStore) Add(_ context.Context, event store.PullRequestEvent) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	event.ChangedPaths = append([]string(nil), event.ChangedPaths...)
+	s.events = append(s.events, event)
+	return nil
+}
+
+func (s *PullRequestEventStore) List(_ context.Context, filter store.PullRequestEventFilter, eventFunc func(store.PullRequestEvent) error) error {
+	for _, event := range s.list() {
+		if filter.Owner != "" && filter.Owner != event.Owner {
+			continue
+		}
+		if filter.Repository != "" && filter.Repository != event.Repository {
+			continue
+		}
+		if err := eventFunc(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// list returns a copy of all the events, in insertion order
+func (s *PullRequestEventStore) list() []store.PullRequestEvent {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]store.PullRequestEvent(nil), s.events...)
+}
+
+func (s *PullRequestEventStore) filter(keep func(e store.PullRequestEvent) bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var events []store.PullRequestEvent
+	for _, event := range s.events {
+		if keep(event) {
+			events = append(events, event)
+		}
+	}
+	s.events = events
+}
@@ -0,0 +1,154 @@
+package memory
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/cd-indicators/store"
+)
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func TestPullRequestStoreMergesWithTheStoredPullRequest(t *testing.T) {
+	ctx := context.Background()
+	s := &PullRequestStore{notifier: &store.Notifier{}}
+	var (
+		created  = time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+		reviewed = created.Add(1 * time.Hour)
+		approved = created.Add(2 * time.Hour)
+		merged   = created.Add(5 * time.Hour)
+	)
+	key := store.PullRequest{Owner: "org", Repository: "app", PullRequest: 1}
+
+	updates := []func(pr *store.PullRequest){
+		func(pr *store.PullRequest) {
+			pr.Author = "alice"
+			pr.CreationTime = timePtr(created)
+			pr.ReadyForReviewTime = timePtr(created)
+			pr.Additions, pr.Deletions, pr.ChangedFiles = 10, 2, 3
+		},
+		// not reviewed yet: not counted
+		func(pr *store.PullRequest) { pr.PushesAfterFirstReview = 1 },
+		func(pr *store.PullRequest) {
+			pr.Author = "bob" // the author is only set when the pull request is first stored
+			pr.Reviews = 1
+			pr.Reviewers = []string{"bob"}
+			pr.ChangeRequests = 1
+			pr.FirstReviewTime = timePtr(reviewed)
+		},
+		func(pr *store.PullRequest) { pr.PushesAfterFirstReview = 1 },
+		func(pr *store.PullRequest) {
+			pr.Reviews = 1
+			pr.Reviewers = []string{"carol"}
+			pr.FirstReviewTime = timePtr(approved)
+			pr.ApprovedTime = timePtr(approved)
+		},
+		func(pr *store.PullRequest) { pr.MergedTime = timePtr(merged) },
+	}
+	for _, update := range updates {
+		pr := key
+		update(&pr)
+		if err := s.Add(ctx, pr); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pullRequests := s.List()
+	if len(pullRequests) != 1 {
+		t.Fatalf("expected the pull request to be upserted, got %d pull requests", len(pullRequests))
+	}
+	pr := pullRequests[0]
+	if pr.Author != "alice" {
+		t.Errorf("expected the first author to be kept, got %q", pr.Author)
+	}
+	if pr.Reviews != 2 || pr.ChangeRequests != 1 || pr.PushesAfterFirstReview != 1 {
+		t.Errorf("expected 2 reviews, 1 change request and 1 push after the first review, got %d, %d and %d", pr.Reviews, pr.ChangeRequests, pr.PushesAfterFirstReview)
+	}
+	if reviewers := pr.Reviewers; !reflect.DeepEqual(reviewers, []string{"bob", "carol"}) && !reflect.DeepEqual(reviewers, []string{"carol", "bob"}) {
+		t.Errorf("expected the union of the reviewers, got %v", reviewers)
+	}
+	if !pr.FirstReviewTime.Equal(reviewed) {
+		t.Errorf("expected the earliest review time %s, got %s", reviewed, pr.FirstReviewTime)
+	}
+	if pr.Additions != 10 || pr.Deletions != 2 || pr.ChangedFiles != 3 {
+		t.Errorf("expected the size to be kept by the updates without any size, got +%d -%d in %d files", pr.Additions, pr.Deletions, pr.ChangedFiles)
+	}
+	if pr.TimeToReview != 2*time.Hour || pr.TimeToMerge != 3*time.Hour || pr.TimeToFirstReview != 1*time.Hour {
+		t.Errorf("expected the durations to be computed from the merged times, got %s to review, %s to merge and %s to first review", pr.TimeToReview, pr.TimeToMerge, pr.TimeToFirstReview)
+	}
+}
+
+func TestPullRequestStoreResetsWithZeroTimes(t *testing.T) {
+	ctx := context.Background()
+	s := &PullRequestStore{notifier: &store.Notifier{}}
+	closed := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	key := store.PullRequest{Owner: "org", Repository: "app", PullRequest: 1}
+
+	pr := key
+	pr.ReadyForReviewTime = timePtr(closed)
+	pr.ClosedTime = timePtr(closed)
+	if err := s.Add(ctx, pr); err != nil {
+		t.Fatal(err)
+	}
+	// reopened and converted to draft
+	pr = key
+	pr.ReadyForReviewTime = new(time.Time)
+	pr.ClosedTime = new(time.Time)
+	if err := s.Add(ctx, pr); err != nil {
+		t.Fatal(err)
+	}
+
+	stored := s.List()[0]
+	if stored.ReadyForReviewTime != nil || stored.ClosedTime != nil {
+		t.Errorf("expected the ready for review and closed times to be reset, got %v and %v", stored.ReadyForReviewTime, stored.ClosedTime)
+	}
+}
+
+func TestPullRequestStoreNotifiesTheMergeOnce(t *testing.T) {
+	ctx := context.Background()
+	notifier := &store.Notifier{}
+	listener := &mergeListener{}
+	notifier.Register(listener)
+	s := &PullRequestStore{notifier: notifier}
+	merged := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 2; i++ {
+		if err := s.Add(ctx, store.PullRequest{Owner: "org", Repository: "app", PullRequest: 1, MergedTime: timePtr(merged)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if listener.merged != 1 {
+		t.Errorf("expected 1 merge notification, got %d", listener.merged)
+	}
+}
+
+func TestPullRequestStoreListsInInsertionOrder(t *testing.T) {
+	ctx := context.Background()
+	s := &PullRequestStore{notifier: &store.Notifier{}}
+	for _, number := range []int{3, 1, 2, 1} {
+		if err := s.Add(ctx, store.PullRequest{Owner: "org", Repository: "app", PullRequest: number}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var numbers []int
+	for _, pr := range s.List() {
+		numbers = append(numbers, pr.PullRequest)
+	}
+	if !reflect.DeepEqual(numbers, []int{3, 1, 2}) {
+		t.Errorf("expected the pull requests in insertion order, got %v", numbers)
+	}
+}
+
+type mergeListener struct {
+	store.Listener
+	merged int
+}
+
+func (l *mergeListener) PullRequestMerged(context.Context, store.PullRequest) {
+	l.merged++
+}
@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jenkins-x/cd-indicators/store"
+)
+
+type releaseKey struct {
+	Owner      string
+	Repository string
+	Version    string
+}
+
+type ReleaseStore struct {
+	mutex    sync.Mutex
+	releases []store.Release
+	index    map[releaseKey]struct{}
+	notifier *store.Notifier
+}
+
+func (s *ReleaseStore) Add(ctx context.Context, r store.Release) error {
+	if s.add(r) {
+		s.notifier.ReleaseAdded(ctx, r)
+	}
+	return nil
+}
+
+// add stores the release, and returns true if it was not stored yet
+func (s *ReleaseStore) add(r store.Release) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.index == nil {
+		s.index = map[releaseKey]struct{}{}
+	}
+
+	key := releaseKey{
+		Owner:      r.Owner,
+		Repository: r.Repository,
+		Version:    r.Version,
+	}
+	if _, found := s.index[key]; found {
+		return false
+	}
+	r.Contributors = append([]string(nil), r.Contributors...)
+	s.releases = append(s.releases, r)
+	s.index[key] = struct{}{}
+
+	return true
+}
+
+// List returns a copy of all the stored releases, in insertion order
+func (s *ReleaseStore) List() []store.Release {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	releases := make([]store.Release, len(s.releases))
+	for i, r := range s.releases {
+		r.Contributors = append([]string(nil), r.Contributors...)
+		releases[i] = r
+	}
+	return releases
+}
+
+// filter keeps only the releases for which keep returns true
+func (s *ReleaseStore) filter(keep func(r store.Release) bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var releases []store.Release
+	s.index = map[releaseKey]struct{}{}
+	for _, r := range s.releases {
+		if !keep(r) {
+			continue
+		}
+		releases = append(releases, r)
+		s.index[releaseKey{
+			Owner:      r.Owner,
+			Repository: r.Repository,
+			Version:    r.Version,
+		}] = struct{}{}
+	}
+	s.releases = releases
+}
@@ -0,0 +1,96 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jenkins-x/cd-indicators/store"
+)
+
+type repositoryKey struct {
+	Owner string
+	Name  string
+}
+
+type RepositoryStore struct {
+	mutex        sync.Mutex
+	repositories map[repositoryKey]store.Repository
+
+	pipelines    *PipelineStore
+	pullRequests *PullRequestStore
+	releases     *ReleaseStore
+	deployments  *DeploymentStore
+}
+
+func (s *RepositoryStore) Add(_ context.Context, r store.Repository) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.repositories == nil {
+		s.repositories = map[repositoryKey]store.Repository{}
+	}
+	labels := make(map[string]string, len(r.Labels))
+	for k, v := range r.Labels {
+		labels[k] = v
+	}
+	r.Labels = labels
+	s.repositories[repositoryKey{Owner: r.Owner, Name: r.Name}] = r
+	return nil
+}
+
+func (s *RepositoryStore) Delete(_ context.Context, owner, name string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.repositories, repositoryKey{Owner: owner, Name: name})
+	return nil
+}
+
+func (s *RepositoryStore) ListActivity(_ context.Context, owner string) ([]store.RepositoryActivity, error) {
+	activities := map[repositoryKey]*store.RepositoryActivity{}
+	s.mutex.Lock()
+	for key, r := range s.repositories {
+		if owner == "" || r.Owner == owner {
+			activities[key] = &store.RepositoryActivity{Repository: r}
+		}
+	}
+	s.mutex.Unlock()
+
+	latest := func(owner, name string, t time.Time, field func(a *store.RepositoryActivity) **time.Time) {
+		a := activities[repositoryKey{Owner: owner, Name: name}]
+		if a == nil {
+			return
+		}
+		if last := field(a); *last == nil || t.After(**last) {
+			*last = &t
+		}
+	}
+	for _, pr := range s.pullRequests.List() {
+		if pr.CreationTime != nil {
+			latest(pr.Owner, pr.Repository, *pr.CreationTime, func(a *store.RepositoryActivity) **time.Time { return &a.LastPullRequestTime })
+		}
+	}
+	for _, p := range s.pipelines.List() {
+		latest(p.Owner, p.Repository, p.StartTime, func(a *store.RepositoryActivity) **time.Time { return &a.LastPipelineTime })
+	}
+	for _, r := range s.releases.List() {
+		latest(r.Owner, r.Repository, r.ReleaseTime, func(a *store.RepositoryActivity) **time.Time { return &a.LastReleaseTime })
+	}
+	for _, d := range s.deployments.List() {
+		latest(d.Owner, d.Repository, d.DeploymentTime, func(a *store.RepositoryActivity) **time.Time { return &a.LastDeploymentTime })
+	}
+
+	list := make([]store.RepositoryActivity, 0, len(activities))
+	for _, a := range activities {
+		list = append(list, *a)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Owner != list[j].Owner {
+			return list[i].Owner < list[j].Owner
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list, nil
+}
@@ -0,0 +1,142 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jenkins-x/cd-indicators/store"
+)
+
+type RetentionStore struct {
+	pipelines    *PipelineStore
+	pullRequests *PullRequestStore
+	events       *PullRequestEventStore
+	releases     *ReleaseStore
+	deployments  *DeploymentStore
+	jobs         *LighthouseJobStore
+	helmReleases *HelmReleaseStore
+	gitOpsSyncs  *GitOpsSyncStore
+	incidents    *IncidentStore
+}
+
+// Apply deletes the rows of the policy's table which are older than its cutoff.
+// There are no aggregate tables in memory, so nothing is rolled up.
+func (s *RetentionStore) Apply(_ context.Context, policy store.RetentionPolicy, now time.Time) (store.RetentionResult, error) {
+	var result store.RetentionResult
+	if err := policy.Validate(); err != nil {
+		return result, err
+	}
+	if policy.Archive {
+		return result, errors.New("archiving expired rows is not supported by the in-memory storage")
+	}
+	cutoff := policy.Cutoff(now)
+
+	switch policy.Table {
+	case "pipelines":
+		s.pipelines.filter(func(p *store.Pipeline) bool {
+			if expired(p.StartTime, cutoff) {
+				result.Deleted++
+				return false
+			}
+			return true
+		})
+	case "pipelinesteps":
+		s.pipelines.filter(func(p *store.Pipeline) bool {
+			var steps []store.SimplifiedActivityStep
+			for _, step := range p.Steps {
+				if expired(step.StartedTimestamp, cutoff) {
+					result.Deleted++
+					continue
+				}
+				steps = append(steps, step)
+			}
+			p.Steps = steps
+			return true
+		})
+	case "pull_requests":
+		s.pullRequests.filter(func(pr store.PullRequest) bool {
+			if t := pullRequestTime(pr); t != nil && expired(*t, cutoff) {
+				result.Deleted++
+				return false
+			}
+			return true
+		})
+	case "pull_request_events":
+		s.events.filter(func(e store.PullRequestEvent) bool {
+			if expired(e.Time, cutoff) {
+				result.Deleted++
+				return false
+			}
+			return true
+		})
+	case "releases":
+		s.releases.filter(func(r store.Release) bool {
+			if expired(r.ReleaseTime, cutoff) {
+				result.Deleted++
+				return false
+			}
+			return true
+		})
+	case "deployments":
+		s.deployments.filter(func(d store.Deployment) bool {
+			if expired(d.DeploymentTime, cutoff) {
+				result.Deleted++
+				return false
+			}
+			return true
+		})
+	case "helm_releases":
+		s.helmReleases.filter(func(r store.HelmRelease) bool {
+			if expired(r.DeploymentTime, cutoff) {
+				result.Deleted++
+				return false
+			}
+			return true
+		})
+	case "gitops_syncs":
+		s.gitOpsSyncs.filter(func(sync store.GitOpsSync) bool {
+			if expired(sync.SyncTime, cutoff) {
+				result.Deleted++
+				return false
+			}
+			return true
+		})
+	case "incidents":
+		s.incidents.filter(func(i store.Incident) bool {
+			if expired(i.DetectionTime, cutoff) {
+				result.Deleted++
+				return false
+			}
+			return true
+		})
+	case "lighthouse_jobs":
+		s.jobs.filter(func(j store.LighthouseJob) bool {
+			if expired(j.TriggerTime, cutoff) {
+				result.Deleted++
+				return false
+			}
+			return true
+		})
+	case "pipeline_events", "deployment_events":
+		// the in-memory storage has no event log for the pipelines and deployments, which can't be rebuilt
+	}
+
+	return result, nil
+}
+
+// pullRequestTime returns the first known time of a pull request - its creation time, or else the time of its first known event -
+// nil if there is none: such a pull request never expires
+func pullRequestTime(pr store.PullRequest) *time.Time {
+	for _, t := range []*time.Time{pr.CreationTime, pr.ReadyForReviewTime, pr.FirstCommentTime, pr.FirstReviewTime, pr.ApprovedTime, pr.MergedTime, pr.ClosedTime} {
+		if t != nil {
+			return t
+		}
+	}
+	return nil
+}
+
+// expired returns true if a time is known, and before the cutoff: the rows without any time yet never expire
+func expired(t, cutoff time.Time) bool {
+	return !t.IsZero() && t.Before(cutoff)
+}
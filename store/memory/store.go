@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"github.com/jenkins-x/cd-indicators/store"
+)
+
+// New returns a store keeping everything in memory, which is lost on restart.
+// It has the same merge semantics as the PostgreSQL store, but doesn't support archiving or rollups.
+func New() *store.Store {
+	var (
+		notifier     = &store.Notifier{}
+		pipelines    = &PipelineStore{notifier: notifier}
+		pullRequests = &PullRequestStore{notifier: notifier}
+		events       = &PullRequestEventStore{}
+		releases     = &ReleaseStore{notifier: notifier}
+		deployments  = &DeploymentStore{notifier: notifier}
+		teams        = &TeamStore{}
+		jobs         = &LighthouseJobStore{}
+		helmReleases = &HelmReleaseStore{}
+		gitOpsSyncs  = &GitOpsSyncStore{}
+		incidents    = &IncidentStore{}
+	)
+
+	return &store.Store{
+		Pipelines:         pipelines,
+		PullRequests:      pullRequests,
+		PullRequestEvents: events,
+		Releases:          releases,
+		Deployments:       deployments,
+		Identities:        &IdentityStore{},
+		Teams:             teams,
+		Repositories: &RepositoryStore{
+			pipelines:    pipelines,
+			pullRequests: pullRequests,
+			releases:     releases,
+			deployments:  deployments,
+		},
+		LighthouseJobs: jobs,
+		HelmReleases:   helmReleases,
+		GitOpsSyncs:    gitOpsSyncs,
+		Incidents:      incidents,
+		Notifier:       notifier,
+		Retention: &RetentionStore{
+			pipelines:    pipelines,
+			pullRequests: pullRequests,
+			events:       events,
+			releases:     releases,
+			deployments:  deployments,
+			jobs:         jobs,
+			helmReleases: helmReleases,
+			gitOpsSyncs:  gitOpsSyncs,
+			incidents:    incidents,
+		},
+		Export: &ExportStore{
+			pipelines:    pipelines,
+			pullRequests: pullRequests,
+			events:       events,
+			releases:     releases,
+			deployments:  deployments,
+			teams:        teams,
+			jobs:         jobs,
+			helmReleases: helmReleases,
+			gitOpsSyncs:  gitOpsSyncs,
+			incidents:    incidents,
+		},
+	}
+}
@@ -0,0 +1,58 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/jenkins-x/cd-indicators/store"
+)
+
+type TeamStore struct {
+	mutex    sync.Mutex
+	mappings map[string][]store.TeamRepository
+}
+
+func (s *TeamStore) List(_ context.Context) ([]store.TeamRepository, error) {
+	return s.list(), nil
+}
+
+func (s *TeamStore) Replace(_ context.Context, source string, mappings []store.TeamRepository) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.mappings == nil {
+		s.mappings = map[string][]store.TeamRepository{}
+	}
+	replaced := make([]store.TeamRepository, 0, len(mappings))
+	for _, m := range mappings {
+		m.Source = source
+		replaced = append(replaced, m)
+	}
+	s.mappings[source] = replaced
+	return nil
+}
+
+func (s *TeamStore) list() []store.TeamRepository {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var mappings []store.TeamRepository
+	for _, sourceMappings := range s.mappings {
+		mappings = append(mappings, sourceMappings...)
+	}
+	sort.Slice(mappings, func(i, j int) bool {
+		a, b := mappings[i], mappings[j]
+		if a.Team != b.Team {
+			return a.Team < b.Team
+		}
+		if a.Owner != b.Owner {
+			return a.Owner < b.Owner
+		}
+		if a.Repository != b.Repository {
+			return a.Repository < b.Repository
+		}
+		return a.Source < b.Source
+	})
+	return mappings
+}
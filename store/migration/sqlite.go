@@ -0,0 +1,92 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+type SQLiteFunc func(ctx context.Context, tx *sql.Tx) error
+
+type SQLiteMigratable interface {
+	TableName() string
+	Migrations() []SQLiteFunc
+}
+
+// SQLiteMigrator is the Migrator flow adapted to SQLite:
+// there is no table lock nor nested transaction, but a single transaction for all the migrations,
+// which is enough because SQLite only allows a single writer at a time.
+type SQLiteMigrator struct {
+	DB *sql.DB
+}
+
+func (m *SQLiteMigrator) Migrate(ctx context.Context, migratables ...SQLiteMigratable) error {
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start a new DB transaction: %w", err)
+	}
+	defer tx.Rollback() // nolint: errcheck
+
+	_, err = tx.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %[1]s (
+			table_name TEXT NOT NULL,
+			migration_level INTEGER NOT NULL,
+			CONSTRAINT %[1]s_pkey PRIMARY KEY (table_name)
+		);
+	`, migrationsTableName))
+	if err != nil {
+		return fmt.Errorf("failed to ensure that the migrations table '%s' exists: %w", migrationsTableName, err)
+	}
+
+	for _, migratable := range migratables {
+		var currentMigrationLevel int
+		err = tx.QueryRowContext(ctx, fmt.Sprintf("SELECT migration_level FROM %s WHERE table_name=?;", migrationsTableName), migratable.TableName()).Scan(&currentMigrationLevel)
+		if errors.Is(err, sql.ErrNoRows) {
+			currentMigrationLevel = 0
+			err = nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to retrieve current migration level for table %s: %w", migratable.TableName(), err)
+		}
+
+		err = m.migrate(ctx, tx, migratable, currentMigrationLevel)
+		if err != nil {
+			return fmt.Errorf("failed to migrate table %s: %w", migratable.TableName(), err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit DB transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (m *SQLiteMigrator) migrate(ctx context.Context, tx *sql.Tx, migratable SQLiteMigratable, currentMigrationLevel int) error {
+	for i, migrationFunc := range migratable.Migrations() {
+		migrationLevel := i + 1
+		if migrationLevel <= currentMigrationLevel {
+			continue
+		}
+
+		err := migrationFunc(ctx, tx)
+		if err != nil {
+			return fmt.Errorf("failed to run migration %d for table %s: %w", migrationLevel, migratable.TableName(), err)
+		}
+
+		_, err = tx.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (table_name, migration_level) VALUES(?, ?) ON CONFLICT (table_name) DO UPDATE SET migration_level = excluded.migration_level;", migrationsTableName), migratable.TableName(), migrationLevel)
+		if err != nil {
+			return fmt.Errorf("failed to update migrations table for table %s and migration level %d: %w", migratable.TableName(), migrationLevel, err)
+		}
+	}
+
+	return nil
+}
+
+func ExecSQLiteFunc(query string, arguments ...interface{}) SQLiteFunc {
+	return func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, query, arguments...)
+		return err
+	}
+}
@@ -0,0 +1,62 @@
+package store
+
+import (
+	"context"
+	"sync"
+)
+
+// Listener is notified of the changes of the stored entities, such as to publish them as events
+type Listener interface {
+	PipelineAdded(ctx context.Context, p Pipeline)
+	ReleaseAdded(ctx context.Context, r Release)
+	DeploymentAdded(ctx context.Context, d Deployment)
+	PullRequestMerged(ctx context.Context, pr PullRequest)
+}
+
+// Notifier notifies its listeners of the pipelines, releases and deployments stored for the first time -
+// not of the additions of the entities already stored, such as on a resync - and of the pull requests when they are merged.
+// The listeners are called after the commit, synchronously: they must not block. A nil Notifier has no listeners.
+type Notifier struct {
+	mutex     sync.RWMutex
+	listeners []Listener
+}
+
+// Register adds a listener
+func (n *Notifier) Register(l Listener) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	n.listeners = append(n.listeners, l)
+}
+
+func (n *Notifier) PipelineAdded(ctx context.Context, p Pipeline) {
+	for _, l := range n.list() {
+		l.PipelineAdded(ctx, p)
+	}
+}
+
+func (n *Notifier) ReleaseAdded(ctx context.Context, r Release) {
+	for _, l := range n.list() {
+		l.ReleaseAdded(ctx, r)
+	}
+}
+
+func (n *Notifier) DeploymentAdded(ctx context.Context, d Deployment) {
+	for _, l := range n.list() {
+		l.DeploymentAdded(ctx, d)
+	}
+}
+
+func (n *Notifier) PullRequestMerged(ctx context.Context, pr PullRequest) {
+	for _, l := range n.list() {
+		l.PullRequestMerged(ctx, pr)
+	}
+}
+
+func (n *Notifier) list() []Listener {
+	if n == nil {
+		return nil
+	}
+	n.mutex.RLock()
+	defer n.mutex.RUnlock()
+	return n.listeners
+}
@@ -0,0 +1,83 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/jenkins-x/cd-indicators/store/migration"
+)
+
+type DeploymentStore struct {
+	connPool *pgxpool.Pool
+	notifier *store.Notifier
+}
+
+func (s *DeploymentStore) TableName() string {
+	return "deployments"
+}
+
+func (s *DeploymentStore) Migrations() []migration.Func {
+	return []migration.Func{
+		migration.ExecSQLFunc(`
+			CREATE TABLE deployments (
+				owner VARCHAR NOT NULL,
+				repository VARCHAR NOT NULL,
+				version VARCHAR NOT NULL,
+				environment VARCHAR NOT NULL,
+				deployment_time timestamp without time zone,
+				CONSTRAINT deployments_pkey PRIMARY KEY (owner, repository, version, environment)
+			);
+		`), migration.ExecSQLFunc(`
+			CREATE TABLE deployment_events (
+				owner VARCHAR NOT NULL,
+				repository VARCHAR NOT NULL,
+				version VARCHAR NOT NULL,
+				source_environment VARCHAR NOT NULL,
+				environment VARCHAR NOT NULL,
+				deployment_time timestamp without time zone,
+				CONSTRAINT deployment_events_pkey PRIMARY KEY (owner, repository, version, source_environment)
+			);
+		`),
+	}
+}
+
+func (s *DeploymentStore) Add(ctx context.Context, d store.Deployment) error {
+	tx, err := s.connPool.BeginTx(ctx, pgx.TxOptions{
+		AccessMode: pgx.ReadWrite,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start a new DB transaction: %w", err)
+	}
+	defer tx.Rollback(ctx) // nolint: errcheck
+
+	tag, err := tx.Exec(ctx, `
+	INSERT INTO deployments (owner, repository, version, environment, deployment_time) 
+	VALUES ($1, $2, $3, $4, $5) 
+	ON CONFLICT ON CONSTRAINT deployments_pkey DO NOTHING;`,
+		d.Owner, d.Repository, d.Version, d.Environment, d.DeploymentTime)
+	if err != nil {
+		return fmt.Errorf("failed to add deployment: %w", err)
+	}
+
+	// the environment is stored as found by the collector, and with its alias - which identifies the deployment it has been added to
+	_, err = tx.Exec(ctx, `
+	INSERT INTO deployment_events (owner, repository, version, source_environment, environment, deployment_time)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	ON CONFLICT ON CONSTRAINT deployment_events_pkey DO NOTHING;`,
+		d.Owner, d.Repository, d.Version, d.EventEnvironment(), d.Environment, d.DeploymentTime)
+	if err != nil {
+		return fmt.Errorf("failed to add deployment event: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit insertion of deployment: %w", err)
+	}
+
+	if tag.RowsAffected() > 0 {
+		s.notifier.DeploymentAdded(ctx, d)
+	}
+	return nil
+}
@@ -0,0 +1,339 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jenkins-x/cd-indicators/store"
+)
+
+// datasetQueries are the queries of each dataset, returning the dataset columns in order.
+// They receive the owner as $1 (empty for all owners), the time range as $2 and $3,
+// and whether to exclude the automated changes as $4.
+var datasetQueries = map[string]string{
+	"pipelines": `
+		SELECT type, owner, repository, pull_request, context, build, status, author, start_time, end_time, duration, is_bot
+		FROM pipelines
+		WHERE ($1 = '' OR owner = $1) AND start_time >= $2 AND start_time < $3 AND NOT ($4 AND is_bot)
+		ORDER BY start_time;`,
+	"pipelinesteps": `
+		SELECT type, owner, repository, pull_request, context, build, step_name, step_status, step_started_time, step_completed_time, step_duration, is_bot
+		FROM pipelinesteps
+		WHERE ($1 = '' OR owner = $1) AND step_started_time >= $2 AND step_started_time < $3 AND NOT ($4 AND is_bot)
+		ORDER BY step_started_time;`,
+	"pull_requests": `
+		SELECT owner, repository, pull_request, author, state, reviews, reviewers, creation_time, ready_for_review_time, approved_time, time_to_review, merged_time, time_to_merge, approvers,
+			first_review_time, time_to_first_review, first_comment_time, time_to_first_comment, change_requests, pushes_after_first_review,
+			additions, deletions, changed_files, closed_time, closed_without_merge, is_bot
+		FROM pull_requests
+		WHERE ($1 = '' OR owner = $1) AND creation_time >= $2 AND creation_time < $3 AND NOT ($4 AND is_bot)
+		ORDER BY creation_time;`,
+	"releases": `
+		SELECT owner, repository, version, contributors, release_time, is_bot
+		FROM releases
+		WHERE ($1 = '' OR owner = $1) AND release_time >= $2 AND release_time < $3 AND NOT ($4 AND is_bot)
+		ORDER BY release_time;`,
+	// the deployments of the automated releases are excluded with them
+	"deployments": `
+		SELECT d.owner, d.repository, d.version, d.environment, d.deployment_time
+		FROM deployments d
+		WHERE ($1 = '' OR d.owner = $1) AND d.deployment_time >= $2 AND d.deployment_time < $3
+			AND NOT ($4 AND EXISTS (SELECT 1 FROM releases r WHERE r.owner = d.owner AND r.repository = d.repository AND r.version = d.version AND r.is_bot))
+		ORDER BY d.deployment_time;`,
+	// the revisions of the automated releases are excluded with them, like their deployments
+	"helm_releases": `
+		SELECT h.namespace, h.name, h.revision, h.owner, h.repository, h.environment, h.chart, h.version, h.app_version, h.status, h.description,
+			h.deployment_time, h.is_rollback
+		FROM helm_releases h
+		WHERE ($1 = '' OR h.owner = $1) AND h.deployment_time >= $2 AND h.deployment_time < $3
+			AND NOT ($4 AND EXISTS (SELECT 1 FROM releases r WHERE r.owner = h.owner AND r.repository = h.repository AND r.version = h.version AND r.is_bot))
+		ORDER BY h.deployment_time, h.namespace, h.name, h.revision;`,
+	// the syncs of the automated releases are excluded with them, like their deployments
+	"gitops_syncs": `
+		SELECT s.tool, s.kind, s.namespace, s.name, s.owner, s.repository, s.environment, s.revision, s.version, s.status, s.message, s.sync_time
+		FROM gitops_syncs s
+		WHERE ($1 = '' OR s.owner = $1) AND s.sync_time >= $2 AND s.sync_time < $3
+			AND NOT ($4 AND EXISTS (SELECT 1 FROM releases r WHERE r.owner = s.owner AND r.repository = s.repository AND r.version = s.version AND r.is_bot))
+		ORDER BY s.sync_time, s.tool, s.kind, s.namespace, s.name;`,
+	// the incidents of the automated releases are excluded with them, like their deployments
+	"incidents": `
+		SELECT i.source, i.id, i.owner, i.repository, i.environment, i.version, i.description, i.detection_time, i.resolution_time,
+			extract(epoch FROM i.resolution_time - i.detection_time)::bigint
+		FROM incidents i
+		WHERE ($1 = '' OR i.owner = $1) AND i.detection_time >= $2 AND i.detection_time < $3
+			AND NOT ($4 AND EXISTS (SELECT 1 FROM releases r WHERE r.owner = i.owner AND r.repository = i.repository AND r.version = i.version AND r.is_bot))
+		ORDER BY i.detection_time, i.source, i.id;`,
+	// the retests are the jobs triggered again for a commit which already had a job of the same context, see store.MarkRetests
+	"lighthouse_jobs": `
+		SELECT j.name, j.type, j.job, j.owner, j.repository, j.pull_request, j.context, j.sha, j.build, j.state, j.author,
+			j.trigger_time, j.start_time, j.end_time, j.queue_duration, j.duration,
+			j.sha != '' AND j.type != 'periodic' AND EXISTS (
+				SELECT 1 FROM lighthouse_jobs p
+				WHERE p.type = j.type AND p.owner = j.owner AND p.repository = j.repository AND p.pull_request = j.pull_request
+					AND p.context = j.context AND p.sha = j.sha AND p.trigger_time < j.trigger_time
+			) AS is_retest,
+			j.is_bot
+		FROM lighthouse_jobs j
+		WHERE ($1 = '' OR j.owner = $1) AND j.trigger_time >= $2 AND j.trigger_time < $3 AND NOT ($4 AND j.is_bot)
+		ORDER BY j.trigger_time;`,
+	// dora computes the DORA metrics per repository, the same way the Grafana dashboards do:
+	// production deployments are the ones in an environment starting with "prod",
+	// and the lead time for changes is the time between a release and its deployment in production.
+	// A production deployment fails when an incident of its version is detected in its environment,
+	// and the time to restore is the one of the production incidents detected in the time range
+	"dora": `
+		WITH production_deployments AS (
+			SELECT d.owner, d.repository, d.deployment_time - r.release_time AS lead_time,
+				EXISTS (
+					SELECT 1 FROM incidents i
+					WHERE i.owner = d.owner AND i.repository = d.repository AND i.environment = d.environment AND i.version = d.version
+				) AS failed
+			FROM deployments d
+			LEFT JOIN releases r ON r.owner = d.owner AND r.repository = d.repository AND r.version = d.version
+			WHERE d.environment ILIKE 'prod%' AND ($1 = '' OR d.owner = $1) AND d.deployment_time >= $2 AND d.deployment_time < $3 AND NOT ($4 AND coalesce(r.is_bot, false))
+		), release_pipelines AS (
+			SELECT owner, repository, count(1) AS runs, count(1) FILTER (WHERE status != 'Succeeded') AS failed
+			FROM pipelines
+			WHERE type = 'release' AND ($1 = '' OR owner = $1) AND start_time >= $2 AND start_time < $3 AND NOT ($4 AND is_bot)
+			GROUP BY owner, repository
+		), production_incidents AS (
+			SELECT i.owner, i.repository, percentile_cont(0.5) WITHIN GROUP (ORDER BY i.resolution_time - i.detection_time) AS time_to_restore
+			FROM incidents i
+			LEFT JOIN releases r ON r.owner = i.owner AND r.repository = i.repository AND r.version = i.version
+			WHERE i.environment ILIKE 'prod%' AND i.resolution_time IS NOT NULL AND ($1 = '' OR i.owner = $1) AND i.detection_time >= $2 AND i.detection_time < $3
+				AND NOT ($4 AND coalesce(r.is_bot, false))
+			GROUP BY i.owner, i.repository
+		)
+		SELECT
+			d.owner,
+			d.repository,
+			count(1),
+			count(1)::double precision / greatest(extract(epoch FROM $3::timestamp - $2::timestamp)::double precision / 86400, 1),
+			extract(epoch FROM percentile_cont(0.5) WITHIN GROUP (ORDER BY d.lead_time))::double precision,
+			extract(epoch FROM percentile_cont(0.9) WITHIN GROUP (ORDER BY d.lead_time))::double precision,
+			coalesce(max(p.runs), 0),
+			max(p.failed)::double precision / nullif(max(p.runs), 0),
+			count(1) FILTER (WHERE d.failed)::double precision / count(1),
+			extract(epoch FROM max(i.time_to_restore))::double precision
+		FROM production_deployments d
+		LEFT JOIN release_pipelines p ON p.owner = d.owner AND p.repository = d.repository
+		LEFT JOIN production_incidents i ON i.owner = d.owner AND i.repository = d.repository
+		GROUP BY d.owner, d.repository
+		ORDER BY d.owner, d.repository;`,
+	// reviewer_workload counts the reviews from the pull_request_events, but only for the reviewers of the summaries - which excludes the bots.
+	// The reviewers of the summaries are canonical persons, so are the actors of the events - falling back to the actor for the older events.
+	// The response time is the time between the pull request being ready for review and the reviewer's first review
+	"reviewer_workload": `
+		WITH reviewed AS (
+			SELECT p.owner, p.repository, p.pull_request, r.reviewer, r.reviewer = ANY(coalesce(p.approvers, '{}')) AS approved,
+				coalesce(p.ready_for_review_time, p.creation_time) AS ready_time
+			FROM pull_requests p
+			CROSS JOIN LATERAL unnest(p.reviewers) AS r(reviewer)
+			WHERE ($1 = '' OR p.owner = $1) AND p.creation_time >= $2 AND p.creation_time < $3 AND NOT ($4 AND p.is_bot)
+		), reviews AS (
+			SELECT owner, repository, pull_request, coalesce(actor_person, actor) AS reviewer, count(1) AS reviews, min(event_time) AS first_review_time
+			FROM pull_request_events
+			WHERE action = 'submitted' AND ($1 = '' OR owner = $1)
+			GROUP BY owner, repository, pull_request, coalesce(actor_person, actor)
+		)
+		SELECT
+			r.owner,
+			r.reviewer,
+			count(1),
+			coalesce(sum(e.reviews), 0)::bigint,
+			count(1) FILTER (WHERE r.approved),
+			extract(epoch FROM percentile_cont(0.5) WITHIN GROUP (ORDER BY e.first_review_time - r.ready_time) FILTER (WHERE e.first_review_time > r.ready_time))::double precision
+		FROM reviewed r
+		LEFT JOIN reviews e ON e.owner = r.owner AND e.repository = r.repository AND e.pull_request = r.pull_request AND e.reviewer = r.reviewer
+		GROUP BY r.owner, r.reviewer
+		ORDER BY r.owner, count(1) DESC, r.reviewer;`,
+	"review_pairs": `
+		SELECT p.owner, p.author, r.reviewer, count(1)
+		FROM pull_requests p
+		CROSS JOIN LATERAL unnest(p.reviewers) AS r(reviewer)
+		WHERE ($1 = '' OR p.owner = $1) AND p.creation_time >= $2 AND p.creation_time < $3 AND NOT ($4 AND p.is_bot)
+		GROUP BY p.owner, p.author, r.reviewer
+		ORDER BY p.owner, count(1) DESC, p.author, r.reviewer;`,
+	// review_bus_factor ranks the reviewers of each repository - the most active first - to find how many of them do more than half of the reviews
+	"review_bus_factor": `
+		WITH reviewers AS (
+			SELECT p.owner, p.repository, r.reviewer, count(1) AS reviews
+			FROM pull_requests p
+			CROSS JOIN LATERAL unnest(p.reviewers) AS r(reviewer)
+			WHERE ($1 = '' OR p.owner = $1) AND p.creation_time >= $2 AND p.creation_time < $3 AND NOT ($4 AND p.is_bot)
+			GROUP BY p.owner, p.repository, r.reviewer
+		), ranked AS (
+			SELECT owner, repository, reviewer, reviews,
+				sum(reviews) OVER (PARTITION BY owner, repository) AS total,
+				sum(reviews) OVER (PARTITION BY owner, repository ORDER BY reviews DESC, reviewer ROWS UNBOUNDED PRECEDING) AS cumulative,
+				row_number() OVER (PARTITION BY owner, repository ORDER BY reviews DESC, reviewer) AS rank
+			FROM reviewers
+		)
+		SELECT
+			owner,
+			repository,
+			max(total)::bigint,
+			count(1),
+			min(rank) FILTER (WHERE cumulative * 2 > total),
+			max(reviewer) FILTER (WHERE rank = 1),
+			max(reviews) FILTER (WHERE rank = 1)::double precision / max(total)::double precision
+		FROM ranked
+		GROUP BY owner, repository
+		ORDER BY 5, owner, repository;`,
+	// team_dora computes the DORA metrics like dora, but for all the repositories of each team.
+	// A repository mapped to the same team by several sources is only counted once
+	"team_dora": `
+		WITH production_deployments AS (
+			SELECT t.team, d.deployment_time - r.release_time AS lead_time,
+				EXISTS (
+					SELECT 1 FROM incidents i
+					WHERE i.owner = d.owner AND i.repository = d.repository AND i.environment = d.environment AND i.version = d.version
+				) AS failed
+			FROM deployments d
+			LEFT JOIN releases r ON r.owner = d.owner AND r.repository = d.repository AND r.version = d.version
+			CROSS JOIN LATERAL (
+				SELECT DISTINCT team FROM team_repositories WHERE owner = d.owner AND (repository = d.repository OR repository = '*')
+			) t
+			WHERE d.environment ILIKE 'prod%' AND ($1 = '' OR d.owner = $1) AND d.deployment_time >= $2 AND d.deployment_time < $3 AND NOT ($4 AND coalesce(r.is_bot, false))
+		), release_pipelines AS (
+			SELECT t.team, count(1) AS runs, count(1) FILTER (WHERE p.status != 'Succeeded') AS failed
+			FROM pipelines p
+			CROSS JOIN LATERAL (
+				SELECT DISTINCT team FROM team_repositories WHERE owner = p.owner AND (repository = p.repository OR repository = '*')
+			) t
+			WHERE p.type = 'release' AND ($1 = '' OR p.owner = $1) AND p.start_time >= $2 AND p.start_time < $3 AND NOT ($4 AND p.is_bot)
+			GROUP BY t.team
+		), production_incidents AS (
+			SELECT t.team, percentile_cont(0.5) WITHIN GROUP (ORDER BY i.resolution_time - i.detection_time) AS time_to_restore
+			FROM incidents i
+			LEFT JOIN releases r ON r.owner = i.owner AND r.repository = i.repository AND r.version = i.version
+			CROSS JOIN LATERAL (
+				SELECT DISTINCT team FROM team_repositories WHERE owner = i.owner AND (repository = i.repository OR repository = '*')
+			) t
+			WHERE i.environment ILIKE 'prod%' AND i.resolution_time IS NOT NULL AND ($1 = '' OR i.owner = $1) AND i.detection_time >= $2 AND i.detection_time < $3
+				AND NOT ($4 AND coalesce(r.is_bot, false))
+			GROUP BY t.team
+		)
+		SELECT
+			d.team,
+			count(1),
+			count(1)::double precision / greatest(extract(epoch FROM $3::timestamp - $2::timestamp)::double precision / 86400, 1),
+			extract(epoch FROM percentile_cont(0.5) WITHIN GROUP (ORDER BY d.lead_time))::double precision,
+			extract(epoch FROM percentile_cont(0.9) WITHIN GROUP (ORDER BY d.lead_time))::double precision,
+			coalesce(max(p.runs), 0),
+			max(p.failed)::double precision / nullif(max(p.runs), 0),
+			count(1) FILTER (WHERE d.failed)::double precision / count(1),
+			extract(epoch FROM max(i.time_to_restore))::double precision
+		FROM production_deployments d
+		LEFT JOIN release_pipelines p ON p.team = d.team
+		LEFT JOIN production_incidents i ON i.team = d.team
+		GROUP BY d.team
+		ORDER BY d.team;`,
+	"team_pull_requests": `
+		SELECT
+			t.team,
+			count(1),
+			count(1) FILTER (WHERE p.merged_time IS NOT NULL),
+			count(1) FILTER (WHERE p.closed_without_merge),
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY p.time_to_first_review) FILTER (WHERE p.time_to_first_review > 0),
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY p.time_to_review) FILTER (WHERE p.time_to_review > 0),
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY p.time_to_merge) FILTER (WHERE p.time_to_merge > 0)
+		FROM pull_requests p
+		CROSS JOIN LATERAL (
+			SELECT DISTINCT team FROM team_repositories WHERE owner = p.owner AND (repository = p.repository OR repository = '*')
+		) t
+		WHERE ($1 = '' OR p.owner = $1) AND p.creation_time >= $2 AND p.creation_time < $3 AND NOT ($4 AND p.is_bot)
+		GROUP BY t.team
+		ORDER BY t.team;`,
+}
+
+type ExportStore struct {
+	connPool *pgxpool.Pool
+}
+
+func (s *ExportStore) Export(ctx context.Context, name string, filter store.ExportFilter, rowFunc func(values []any) error) error {
+	columns, err := store.DatasetColumns(name)
+	if err != nil {
+		return err
+	}
+
+	rows, err := s.connPool.Query(ctx, datasetQueries[name], filter.Owner, filter.From.UTC(), filter.To.UTC(), filter.ExcludeBots)
+	if err != nil {
+		return fmt.Errorf("failed to query dataset %s: %w", name, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return fmt.Errorf("failed to read row of dataset %s: %w", name, err)
+		}
+		for i, column := range columns {
+			values[i], err = normalizeValue(column, values[i])
+			if err != nil {
+				return fmt.Errorf("failed to read row of dataset %s: %w", name, err)
+			}
+		}
+		if err = rowFunc(values); err != nil {
+			return err
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("failed to query dataset %s: %w", name, err)
+	}
+
+	return nil
+}
+
+func normalizeValue(column store.Column, value any) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	switch column.Type {
+	case store.ColumnTypeString:
+		if v, ok := value.(string); ok {
+			return v, nil
+		}
+	case store.ColumnTypeInt:
+		switch v := value.(type) {
+		case int16:
+			return int64(v), nil
+		case int32:
+			return int64(v), nil
+		case int64:
+			return v, nil
+		case float64:
+			return int64(v), nil
+		}
+	case store.ColumnTypeFloat:
+		switch v := value.(type) {
+		case float32:
+			return float64(v), nil
+		case float64:
+			return v, nil
+		}
+	case store.ColumnTypeTime:
+		if v, ok := value.(time.Time); ok {
+			return v.UTC(), nil
+		}
+	case store.ColumnTypeStrings:
+		if v, ok := value.([]any); ok {
+			values := make([]string, 0, len(v))
+			for _, item := range v {
+				if s, ok := item.(string); ok {
+					values = append(values, s)
+				}
+			}
+			return values, nil
+		}
+	case store.ColumnTypeBool:
+		if v, ok := value.(bool); ok {
+			return v, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unexpected value %#v for column %s of type %s", value, column.Name, column.Type)
+}
@@ -0,0 +1,58 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/jenkins-x/cd-indicators/store/migration"
+)
+
+type GitOpsSyncStore struct {
+	connPool *pgxpool.Pool
+}
+
+func (s *GitOpsSyncStore) TableName() string {
+	return "gitops_syncs"
+}
+
+func (s *GitOpsSyncStore) Migrations() []migration.Func {
+	return []migration.Func{
+		migration.ExecSQLFunc(`
+			CREATE TABLE gitops_syncs (
+				tool VARCHAR NOT NULL,
+				kind VARCHAR NOT NULL,
+				namespace VARCHAR NOT NULL,
+				name VARCHAR NOT NULL,
+				owner VARCHAR NOT NULL,
+				repository VARCHAR NOT NULL,
+				environment VARCHAR NOT NULL,
+				revision VARCHAR NOT NULL,
+				version VARCHAR NOT NULL,
+				status VARCHAR NOT NULL,
+				message VARCHAR NOT NULL,
+				sync_time timestamp without time zone NOT NULL,
+				CONSTRAINT gitops_syncs_pkey PRIMARY KEY (tool, kind, namespace, name, revision)
+			);
+		`),
+		migration.ExecSQLFunc(`
+			CREATE INDEX gitops_syncs_repository_idx ON gitops_syncs (owner, repository, environment);
+		`),
+	}
+}
+
+func (s *GitOpsSyncStore) Add(ctx context.Context, sync store.GitOpsSync) error {
+	_, err := s.connPool.Exec(ctx, `
+		INSERT INTO gitops_syncs (tool, kind, namespace, name, owner, repository, environment, revision, version, status, message, sync_time)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (tool, kind, namespace, name, revision) DO UPDATE SET
+			status = EXCLUDED.status,
+			message = EXCLUDED.message,
+			sync_time = EXCLUDED.sync_time;
+	`, sync.Tool, sync.Kind, sync.Namespace, sync.Name, sync.Owner, sync.Repository, sync.Environment, sync.Revision, sync.Version, sync.Status, sync.Message, sync.SyncTime)
+	if err != nil {
+		return fmt.Errorf("failed to add %s sync of %s %s/%s revision %s: %w", sync.Tool, sync.Kind, sync.Namespace, sync.Name, sync.Revision, err)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/jenkins-x/cd-indicators/store/migration"
+)
+
+type HelmReleaseStore struct {
+	connPool *pgxpool.Pool
+}
+
+func (s *HelmReleaseStore) TableName() string {
+	return "helm_releases"
+}
+
+func (s *HelmReleaseStore) Migrations() []migration.Func {
+	return []migration.Func{
+		migration.ExecSQLFunc(`
+			CREATE TABLE helm_releases (
+				namespace VARCHAR NOT NULL,
+				name VARCHAR NOT NULL,
+				revision int NOT NULL,
+				owner VARCHAR NOT NULL,
+				repository VARCHAR NOT NULL,
+				environment VARCHAR NOT NULL,
+				chart VARCHAR NOT NULL,
+				version VARCHAR NOT NULL,
+				app_version VARCHAR NOT NULL,
+				status VARCHAR NOT NULL,
+				description VARCHAR NOT NULL,
+				deployment_time timestamp without time zone NOT NULL,
+				is_rollback boolean NOT NULL DEFAULT false,
+				CONSTRAINT helm_releases_pkey PRIMARY KEY (namespace, name, revision)
+			);
+		`),
+		migration.ExecSQLFunc(`
+			CREATE INDEX helm_releases_repository_idx ON helm_releases (owner, repository, environment);
+		`),
+	}
+}
+
+func (s *HelmReleaseStore) Add(ctx context.Context, r store.HelmRelease) error {
+	_, err := s.connPool.Exec(ctx, `
+		INSERT INTO helm_releases (namespace, name, revision, owner, repository, environment, chart, version, app_version, status, description, deployment_time, is_rollback)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		ON CONFLICT (namespace, name, revision) DO UPDATE SET
+			status = EXCLUDED.status,
+			description = EXCLUDED.description,
+			deployment_time = EXCLUDED.deployment_time;
+	`, r.Namespace, r.Name, r.Revision, r.Owner, r.Repository, r.Environment, r.Chart, r.Version, r.AppVersion, r.Status, r.Description, r.DeploymentTime, r.IsRollback)
+	if err != nil {
+		return fmt.Errorf("failed to add helm release %s/%s revision %d: %w", r.Namespace, r.Name, r.Revision, err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/jenkins-x/cd-indicators/store/migration"
+)
+
+type IdentityStore struct {
+	connPool *pgxpool.Pool
+}
+
+func (s *IdentityStore) TableName() string {
+	return "identities"
+}
+
+func (s *IdentityStore) Migrations() []migration.Func {
+	return []migration.Func{
+		migration.ExecSQLFunc(`
+			CREATE TABLE identities (
+				person VARCHAR NOT NULL,
+				team VARCHAR,
+				aliases VARCHAR[],
+				CONSTRAINT identities_pkey PRIMARY KEY (person)
+			);
+		`),
+	}
+}
+
+func (s *IdentityStore) List(ctx context.Context) ([]store.Identity, error) {
+	rows, err := s.connPool.Query(ctx, "SELECT person, COALESCE(team, ''), COALESCE(aliases, '{}') FROM identities ORDER BY person;")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list identities: %w", err)
+	}
+	defer rows.Close()
+
+	var identities []store.Identity
+	for rows.Next() {
+		var identity store.Identity
+		if err = rows.Scan(&identity.Person, &identity.Team, &identity.Aliases); err != nil {
+			return nil, fmt.Errorf("failed to read identity: %w", err)
+		}
+		identities = append(identities, identity)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list identities: %w", err)
+	}
+	return identities, nil
+}
+
+func (s *IdentityStore) Put(ctx context.Context, identity store.Identity) error {
+	if err := identity.Validate(); err != nil {
+		return err
+	}
+	_, err := s.connPool.Exec(ctx, `
+	INSERT INTO identities (person, team, aliases)
+	VALUES ($1, $2, $3)
+	ON CONFLICT ON CONSTRAINT identities_pkey DO UPDATE
+	SET team = EXCLUDED.team, aliases = EXCLUDED.aliases;`,
+		identity.Person, identity.Team, identity.Aliases)
+	if err != nil {
+		return fmt.Errorf("failed to put identity of %s: %w", identity.Person, err)
+	}
+	return nil
+}
+
+func (s *IdentityStore) Delete(ctx context.Context, person string) error {
+	_, err := s.connPool.Exec(ctx, "DELETE FROM identities WHERE person = $1;", person)
+	if err != nil {
+		return fmt.Errorf("failed to delete identity of %s: %w", person, err)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/jenkins-x/cd-indicators/store/migration"
+)
+
+type IncidentStore struct {
+	connPool *pgxpool.Pool
+}
+
+func (s *IncidentStore) TableName() string {
+	return "incidents"
+}
+
+func (s *IncidentStore) Migrations() []migration.Func {
+	return []migration.Func{
+		migration.ExecSQLFunc(`
+			CREATE TABLE incidents (
+				source VARCHAR NOT NULL,
+				id VARCHAR NOT NULL,
+				owner VARCHAR NOT NULL,
+				repository VARCHAR NOT NULL,
+				environment VARCHAR NOT NULL,
+				version VARCHAR NOT NULL,
+				description VARCHAR NOT NULL,
+				detection_time timestamp without time zone NOT NULL,
+				resolution_time timestamp without time zone,
+				CONSTRAINT incidents_pkey PRIMARY KEY (source, id)
+			);
+		`),
+		migration.ExecSQLFunc(`
+			CREATE INDEX incidents_repository_idx ON incidents (owner, repository, environment);
+		`),
+	}
+}
+
+func (s *IncidentStore) Add(ctx context.Context, i store.Incident) error {
+	_, err := s.connPool.Exec(ctx, `
+		INSERT INTO incidents (source, id, owner, repository, environment, version, description, detection_time, resolution_time)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (source, id) DO UPDATE SET
+			owner = coalesce(nullif(EXCLUDED.owner, ''), incidents.owner),
+			repository = coalesce(nullif(EXCLUDED.repository, ''), incidents.repository),
+			environment = coalesce(nullif(EXCLUDED.environment, ''), incidents.environment),
+			version = coalesce(nullif(EXCLUDED.version, ''), incidents.version),
+			description = coalesce(nullif(EXCLUDED.description, ''), incidents.description),
+			detection_time = least(EXCLUDED.detection_time, incidents.detection_time),
+			resolution_time = coalesce(EXCLUDED.resolution_time, incidents.resolution_time);
+	`, i.Source, i.ID, i.Owner, i.Repository, i.Environment, i.Version, i.Description, i.DetectionTime, i.ResolutionTime)
+	if err != nil {
+		return fmt.Errorf("failed to add incident %s: %w", i, err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/jenkins-x/cd-indicators/store/migration"
+)
+
+type LighthouseJobStore struct {
+	connPool *pgxpool.Pool
+}
+
+func (s *LighthouseJobStore) TableName() string {
+	return "lighthouse_jobs"
+}
+
+func (s *LighthouseJobStore) Migrations() []migration.Func {
+	return []migration.Func{
+		migration.ExecSQLFunc(`
+			CREATE TABLE lighthouse_jobs (
+				name VARCHAR NOT NULL,
+				type VARCHAR NOT NULL,
+				job VARCHAR NOT NULL,
+				owner VARCHAR NOT NULL,
+				repository VARCHAR NOT NULL,
+				pull_request int NOT NULL,
+				context VARCHAR NOT NULL,
+				sha VARCHAR NOT NULL,
+				build int NOT NULL,
+				state VARCHAR NOT NULL,
+				author VARCHAR NOT NULL,
+				trigger_time timestamp without time zone NOT NULL,
+				start_time timestamp without time zone,
+				end_time timestamp without time zone,
+				queue_duration bigint,
+				duration bigint,
+				is_bot boolean NOT NULL DEFAULT false,
+				CONSTRAINT lighthouse_jobs_pkey PRIMARY KEY (name)
+			);
+		`),
+		migration.ExecSQLFunc(`
+			CREATE INDEX lighthouse_jobs_commit_idx ON lighthouse_jobs (owner, repository, context, sha);
+		`),
+	}
+}
+
+func (s *LighthouseJobStore) Add(ctx context.Context, j store.LighthouseJob) error {
+	var queueDuration, duration *int64
+	if j.StartTime != nil {
+		seconds := int64(j.QueueDuration.Seconds())
+		queueDuration = &seconds
+	}
+	if j.StartTime != nil && j.EndTime != nil {
+		seconds := int64(j.Duration.Seconds())
+		duration = &seconds
+	}
+
+	_, err := s.connPool.Exec(ctx, `
+		INSERT INTO lighthouse_jobs (name, type, job, owner, repository, pull_request, context, sha, build, state, author, trigger_time, start_time, end_time, queue_duration, duration, is_bot)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+		ON CONFLICT (name) DO UPDATE SET
+			build = EXCLUDED.build,
+			state = EXCLUDED.state,
+			start_time = EXCLUDED.start_time,
+			end_time = EXCLUDED.end_time,
+			queue_duration = EXCLUDED.queue_duration,
+			duration = EXCLUDED.duration;
+	`, j.Name, j.Type, j.Job, j.Owner, j.Repository, j.PullRequest, j.Context, j.SHA, j.Build, j.State, j.Author, j.TriggerTime, j.StartTime, j.EndTime, queueDuration, duration, j.IsBot)
+	if err != nil {
+		return fmt.Errorf("failed to add lighthouse job %s: %w", j.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/jenkins-x/cd-indicators/store/migration"
+)
+
+type PipelineStore struct {
+	connPool *pgxpool.Pool
+	notifier *store.Notifier
+}
+
+func (s *PipelineStore) TableName() string {
+	return "pipelines"
+}
+
+func (s *PipelineStore) Migrations() []migration.Func {
+	return []migration.Func{
+		migration.ExecSQLFunc(`
+			CREATE TABLE pipelines (
+				type VARCHAR NOT NULL,
+				owner VARCHAR NOT NULL,
+				repository VARCHAR NOT NULL,
+				pull_request int,
+				context VARCHAR NOT NULL,
+				build int NOT NULL,
+				status VARCHAR NOT NULL,
+				author VARCHAR,
+				start_time timestamp without time zone NOT NULL,
+				end_time timestamp without time zone NOT NULL,
+				duration bigint NOT NULL,
+				CONSTRAINT pipeline_pkey PRIMARY KEY (type, owner, repository, pull_request, context, build)
+			);
+			
+		`), migration.ExecSQLFunc(`
+			CREATE TABLE pipelinesteps (
+				type VARCHAR NOT NULL,
+				owner VARCHAR NOT NULL,
+				repository VARCHAR NOT NULL,
+				pull_request int,
+				context VARCHAR NOT NULL,
+				build int NOT NULL,
+				step_name VARCHAR NOT NULL,
+				step_status VARCHAR NOT NULL,
+				step_started_time timestamp without time zone NOT NULL,
+				step_completed_time timestamp without time zone NOT NULL,
+				step_duration bigint NOT NULL,
+				CONSTRAINT pipelinesteps_pkey PRIMARY KEY (type, owner, repository, pull_request, context, build, step_name)
+			);
+		`), migration.ExecSQLFunc(`
+			ALTER TABLE pipelines ADD COLUMN is_bot boolean NOT NULL DEFAULT false;
+			ALTER TABLE pipelinesteps ADD COLUMN is_bot boolean NOT NULL DEFAULT false;
+		`), migration.ExecSQLFunc(`
+			CREATE TABLE pipeline_events (
+				type VARCHAR NOT NULL,
+				owner VARCHAR NOT NULL,
+				repository VARCHAR NOT NULL,
+				pull_request int,
+				context VARCHAR NOT NULL,
+				build int NOT NULL,
+				status VARCHAR NOT NULL,
+				author_login VARCHAR,
+				start_time timestamp without time zone NOT NULL,
+				end_time timestamp without time zone NOT NULL,
+				steps jsonb NOT NULL,
+				CONSTRAINT pipeline_events_pkey PRIMARY KEY (type, owner, repository, pull_request, context, build)
+			);
+		`),
+	}
+}
+
+func (s *PipelineStore) Add(ctx context.Context, p store.Pipeline) error {
+	tx, err := s.connPool.BeginTx(ctx, pgx.TxOptions{
+		AccessMode: pgx.ReadWrite,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start a new DB transaction: %w", err)
+	}
+	defer tx.Rollback(ctx) // nolint: errcheck
+
+	tag, err := tx.Exec(ctx, "INSERT INTO pipelines (type, owner, repository, pull_request, context, build, status, author, start_time, end_time, duration, is_bot) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) ON CONFLICT DO NOTHING;", p.Type, p.Owner, p.Repository, p.PullRequest, p.Context, p.Build, p.Status, p.Author, p.StartTime, p.EndTime, p.Duration.Seconds(), p.IsBot)
+	if err != nil {
+		return fmt.Errorf("failed to add pipeline: %w", err)
+	}
+
+	steps, err := json.Marshal(p.Steps)
+	if err != nil {
+		return fmt.Errorf("failed to encode the steps of pipeline: %w", err)
+	}
+	_, err = tx.Exec(ctx, "INSERT INTO pipeline_events (type, owner, repository, pull_request, context, build, status, author_login, start_time, end_time, steps) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) ON CONFLICT DO NOTHING;", p.Type, p.Owner, p.Repository, p.PullRequest, p.Context, p.Build, p.Status, p.EventAuthor(), p.StartTime, p.EndTime, json.RawMessage(steps))
+	if err != nil {
+		return fmt.Errorf("failed to add pipeline event: %w", err)
+	}
+
+	for _, step := range p.Steps {
+		_, err = tx.Exec(ctx, "INSERT INTO pipelinesteps (type, owner, repository, pull_request, context, build, step_name, step_status, step_started_time, step_completed_time, step_duration, is_bot) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) ON CONFLICT DO NOTHING;", p.Type, p.Owner, p.Repository, p.PullRequest, p.Context, p.Build, step.Name, step.Status, step.StartedTimestamp, step.CompletedTimestamp, step.Duration.Seconds(), p.IsBot)
+		if err != nil {
+			return fmt.Errorf("failed to add pipeline step: %w", err)
+		}
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit insertion of pipeline: %w", err)
+	}
+
+	if tag.RowsAffected() > 0 {
+		s.notifier.PipelineAdded(ctx, p)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/jenkins-x/cd-indicators/store/migration"
+)
+
+type PullRequestStore struct {
+	connPool *pgxpool.Pool
+	notifier *store.Notifier
+}
+
+func (s *PullRequestStore) TableName() string {
+	return "pull_requests"
+}
+
+func (s *PullRequestStore) Migrations() []migration.Func {
+	return []migration.Func{
+		migration.ExecSQLFunc(`
+			CREATE TABLE pull_requests (
+				owner VARCHAR NOT NULL,
+				repository VARCHAR NOT NULL,
+				pull_request int NOT NULL,
+				author VARCHAR,
+				state VARCHAR,
+				reviews int,
+				reviewers VARCHAR[],
+				creation_time timestamp without time zone,
+				ready_for_review_time timestamp without time zone,
+				approved_time timestamp without time zone,
+				time_to_review bigint,
+				merged_time timestamp without time zone,
+				time_to_merge bigint,
+				CONSTRAINT pull_requests_pkey PRIMARY KEY (owner, repository, pull_request)
+			);
+		`),
+		migration.ExecSQLFunc(`
+			ALTER TABLE pull_requests ADD COLUMN approvers VARCHAR[];
+		`),
+		migration.ExecSQLFunc(`
+			ALTER TABLE pull_requests
+				ADD COLUMN first_review_time timestamp without time zone,
+				ADD COLUMN time_to_first_review bigint,
+				ADD COLUMN first_comment_time timestamp without time zone,
+				ADD COLUMN time_to_first_comment bigint,
+				ADD COLUMN change_requests int,
+				ADD COLUMN pushes_after_first_review int,
+				ADD COLUMN additions int,
+				ADD COLUMN deletions int,
+				ADD COLUMN changed_files int,
+				ADD COLUMN closed_time timestamp without time zone,
+				ADD COLUMN closed_without_merge boolean;
+		`),
+		migration.ExecSQLFunc(`
+			ALTER TABLE pull_requests ADD COLUMN is_bot boolean NOT NULL DEFAULT false;
+		`),
+	}
+}
+
+func (s *PullRequestStore) Add(ctx context.Context, pr store.PullRequest) error {
+	tx, err := s.connPool.BeginTx(ctx, pgx.TxOptions{
+		AccessMode: pgx.ReadWrite,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start a new DB transaction: %w", err)
+	}
+	defer tx.Rollback(ctx) // nolint: errcheck
+
+	merged, newlyMerged, err := addPullRequest(ctx, tx, pr)
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit insertion of pullrequest: %w", err)
+	}
+
+	if newlyMerged {
+		s.notifier.PullRequestMerged(ctx, merged)
+	}
+	return nil
+}
+
+// addPullRequest merges the pull request with the stored one, within the given transaction.
+// It returns the merged pull request, and whether it has been merged by this addition
+func addPullRequest(ctx context.Context, tx pgx.Tx, pr store.PullRequest) (store.PullRequest, bool, error) {
+	// make sure the row exists, so that it can be locked until the end of the transaction:
+	// concurrent events for the same pull request - from multiple replicas - are then merged one after the other
+	_, err := tx.Exec(ctx, `
+	INSERT INTO pull_requests (owner, repository, pull_request, author, state)
+	VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT ON CONSTRAINT pull_requests_pkey DO NOTHING;`, pr.Owner, pr.Repository, pr.PullRequest, pr.Author, pr.State)
+	if err != nil {
+		return pr, false, fmt.Errorf("failed to initialize pullrequest %s: %w", pr, err)
+	}
+
+	var prFromDB store.PullRequest
+	err = tx.QueryRow(ctx, `
+	SELECT creation_time, ready_for_review_time, approved_time, merged_time, COALESCE(reviews, 0), COALESCE(reviewers, '{}'), COALESCE(approvers, '{}'),
+		first_review_time, first_comment_time, COALESCE(change_requests, 0), COALESCE(pushes_after_first_review, 0),
+		COALESCE(additions, 0), COALESCE(deletions, 0), COALESCE(changed_files, 0), closed_time
+	FROM pull_requests WHERE owner=$1 AND repository=$2 AND pull_request=$3
+	FOR UPDATE`, pr.Owner, pr.Repository, pr.PullRequest).Scan(
+		&prFromDB.CreationTime,
+		&prFromDB.ReadyForReviewTime,
+		&prFromDB.ApprovedTime,
+		&prFromDB.MergedTime,
+		&prFromDB.Reviews,
+		&prFromDB.Reviewers,
+		&prFromDB.Approvers,
+		&prFromDB.FirstReviewTime,
+		&prFromDB.FirstCommentTime,
+		&prFromDB.ChangeRequests,
+		&prFromDB.PushesAfterFirstReview,
+		&prFromDB.Additions,
+		&prFromDB.Deletions,
+		&prFromDB.ChangedFiles,
+		&prFromDB.ClosedTime,
+	)
+	if err != nil {
+		return pr, false, fmt.Errorf("failed to retrieve current pullrequest %s: %w", pr, err)
+	}
+	pr.MergeWith(prFromDB)
+	pr.ApplyApprovalRule()
+	pr.CalculateDurations()
+
+	_, err = tx.Exec(ctx, `
+	INSERT INTO pull_requests (owner, repository, pull_request, author, state, creation_time, ready_for_review_time, approved_time, time_to_review, merged_time, time_to_merge, reviews, reviewers, approvers,
+		first_review_time, time_to_first_review, first_comment_time, time_to_first_comment, change_requests, pushes_after_first_review, additions, deletions, changed_files, closed_time, closed_without_merge, is_bot) 
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26) 
+	ON CONFLICT ON CONSTRAINT pull_requests_pkey DO UPDATE 
+	SET state = EXCLUDED.state, creation_time = EXCLUDED.creation_time, ready_for_review_time = EXCLUDED.ready_for_review_time, approved_time = EXCLUDED.approved_time, time_to_review = EXCLUDED.time_to_review, merged_time = EXCLUDED.merged_time, time_to_merge = EXCLUDED.time_to_merge, reviews = EXCLUDED.reviews, reviewers = EXCLUDED.reviewers, approvers = EXCLUDED.approvers,
+		first_review_time = EXCLUDED.first_review_time, time_to_first_review = EXCLUDED.time_to_first_review, first_comment_time = EXCLUDED.first_comment_time, time_to_first_comment = EXCLUDED.time_to_first_comment, change_requests = EXCLUDED.change_requests, pushes_after_first_review = EXCLUDED.pushes_after_first_review,
+		additions = EXCLUDED.additions, deletions = EXCLUDED.deletions, changed_files = EXCLUDED.changed_files, closed_time = EXCLUDED.closed_time, closed_without_merge = EXCLUDED.closed_without_merge, is_bot = EXCLUDED.is_bot;`,
+		pr.Owner, pr.Repository, pr.PullRequest, pr.Author, pr.State, pr.CreationTime, pr.ReadyForReviewTime, pr.ApprovedTime, pr.TimeToReview.Seconds(), pr.MergedTime, pr.TimeToMerge.Seconds(), pr.Reviews, pr.Reviewers, pr.Approvers,
+		pr.FirstReviewTime, pr.TimeToFirstReview.Seconds(), pr.FirstCommentTime, pr.TimeToFirstComment.Seconds(), pr.ChangeRequests, pr.PushesAfterFirstReview, pr.Additions, pr.Deletions, pr.ChangedFiles, pr.ClosedTime, pr.ClosedWithoutMerge(), pr.IsBot)
+	if err != nil {
+		return pr, false, fmt.Errorf("failed to add pullrequest: %w", err)
+	}
+	return pr, prFromDB.MergedTime == nil && pr.MergedTime != nil, nil
+}
@@ -0,0 +1,103 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/jenkins-x/cd-indicators/store/migration"
+)
+
+type PullRequestEventStore struct {
+	connPool *pgxpool.Pool
+}
+
+func (s *PullRequestEventStore) TableName() string {
+	return "pull_request_events"
+}
+
+func (s *PullRequestEventStore) Migrations() []migration.Func {
+	return []migration.Func{
+		migration.ExecSQLFunc(`
+			CREATE TABLE pull_request_events (
+				id bigserial NOT NULL,
+				owner VARCHAR NOT NULL,
+				repository VARCHAR NOT NULL,
+				pull_request int NOT NULL,
+				action VARCHAR NOT NULL,
+				actor VARCHAR,
+				event_time timestamp without time zone NOT NULL,
+				author VARCHAR,
+				state VARCHAR,
+				draft boolean NOT NULL DEFAULT false,
+				merged boolean NOT NULL DEFAULT false,
+				creation_time timestamp without time zone,
+				label VARCHAR,
+				review_state VARCHAR,
+				additions int NOT NULL DEFAULT 0,
+				deletions int NOT NULL DEFAULT 0,
+				changed_files int NOT NULL DEFAULT 0,
+				CONSTRAINT pull_request_events_pkey PRIMARY KEY (id)
+			);
+		`),
+		migration.ExecSQLFunc(`
+			CREATE INDEX pull_request_events_pull_request_idx ON pull_request_events (owner, repository, pull_request);
+		`),
+		migration.ExecSQLFunc(`
+			ALTER TABLE pull_request_events ADD COLUMN actor_person VARCHAR;
+		`),
+		migration.ExecSQLFunc(`
+			ALTER TABLE pull_request_events ADD COLUMN changed_paths VARCHAR[];
+		`),
+	}
+}
+
+func (s *PullRequestEventStore) Add(ctx context.Context, e store.PullRequestEvent) error {
+	_, err := s.connPool.Exec(ctx, `
+	INSERT INTO pull_request_events (owner, repository, pull_request, action, actor, event_time, author, state, draft, merged, creation_time, label, review_state, additions, deletions, changed_files, actor_person, changed_paths)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, NULLIF($17, ''), $18);`,
+		e.Owner, e.Repository, e.PullRequest, e.Action, e.Actor, e.Time, e.Author, e.State, e.Draft, e.Merged, e.CreationTime, e.Label, e.ReviewState, e.Additions, e.Deletions, e.ChangedFiles, e.ActorPerson, e.ChangedPaths)
+	if err != nil {
+		return fmt.Errorf("failed to add pullrequest event %s: %w", e, err)
+	}
+	return nil
+}
+
+func (s *PullRequestEventStore) List(ctx context.Context, filter store.PullRequestEventFilter, eventFunc func(store.PullRequestEvent) error) error {
+	rows, err := s.connPool.Query(ctx, listPullRequestEventsSQL, filter.Owner, filter.Repository)
+	if err != nil {
+		return fmt.Errorf("failed to list pullrequest events: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e, err := scanPullRequestEvent(rows)
+		if err != nil {
+			return err
+		}
+		if err = eventFunc(e); err != nil {
+			return err
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("failed to list pullrequest events: %w", err)
+	}
+	return nil
+}
+
+const listPullRequestEventsSQL = `
+	SELECT owner, repository, pull_request, action, COALESCE(actor, ''), event_time, COALESCE(author, ''), COALESCE(state, ''), draft, merged, creation_time, COALESCE(label, ''), COALESCE(review_state, ''), additions, deletions, changed_files, COALESCE(actor_person, ''), changed_paths
+	FROM pull_request_events
+	WHERE ($1 = '' OR owner = $1) AND ($2 = '' OR repository = $2)
+	ORDER BY id;`
+
+func scanPullRequestEvent(rows pgx.Rows) (store.PullRequestEvent, error) {
+	var e store.PullRequestEvent
+	err := rows.Scan(&e.Owner, &e.Repository, &e.PullRequest, &e.Action, &e.Actor, &e.Time, &e.Author, &e.State, &e.Draft, &e.Merged, &e.CreationTime, &e.Label, &e.ReviewState, &e.Additions, &e.Deletions, &e.ChangedFiles, &e.ActorPerson, &e.ChangedPaths)
+	if err != nil {
+		return e, fmt.Errorf("failed to read pullrequest event: %w", err)
+	}
+	return e, nil
+}
@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/jenkins-x/cd-indicators/store/migration"
+)
+
+type ReleaseStore struct {
+	connPool *pgxpool.Pool
+	notifier *store.Notifier
+}
+
+func (s *ReleaseStore) TableName() string {
+	return "releases"
+}
+
+func (s *ReleaseStore) Migrations() []migration.Func {
+	return []migration.Func{
+		migration.ExecSQLFunc(`
+			CREATE TABLE releases (
+				owner VARCHAR NOT NULL,
+				repository VARCHAR NOT NULL,
+				version VARCHAR,
+				contributors VARCHAR[],
+				release_time timestamp without time zone NOT NULL,
+				CONSTRAINT releases_pkey PRIMARY KEY (owner, repository, version)
+			);
+		`),
+		migration.ExecSQLFunc(`
+			ALTER TABLE releases ADD COLUMN is_bot boolean NOT NULL DEFAULT false;
+		`),
+	}
+}
+
+func (s *ReleaseStore) Add(ctx context.Context, r store.Release) error {
+	tx, err := s.connPool.BeginTx(ctx, pgx.TxOptions{
+		AccessMode: pgx.ReadWrite,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start a new DB transaction: %w", err)
+	}
+	defer tx.Rollback(ctx) // nolint: errcheck
+
+	tag, err := tx.Exec(ctx, "INSERT INTO releases (owner, repository, version, contributors, release_time, is_bot) VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT DO NOTHING;", r.Owner, r.Repository, r.Version, r.Contributors, r.ReleaseTime, r.IsBot)
+	if err != nil {
+		return fmt.Errorf("failed to add release: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit insertion of release: %w", err)
+	}
+
+	if tag.RowsAffected() > 0 {
+		s.notifier.ReleaseAdded(ctx, r)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/jenkins-x/cd-indicators/store/migration"
+)
+
+type RepositoryStore struct {
+	connPool *pgxpool.Pool
+}
+
+func (s *RepositoryStore) TableName() string {
+	return "repositories"
+}
+
+func (s *RepositoryStore) Migrations() []migration.Func {
+	return []migration.Func{
+		migration.ExecSQLFunc(`
+			CREATE TABLE repositories (
+				provider VARCHAR NOT NULL,
+				owner VARCHAR NOT NULL,
+				repository VARCHAR NOT NULL,
+				url VARCHAR NOT NULL,
+				labels jsonb NOT NULL DEFAULT '{}',
+				creation_time timestamp without time zone,
+				CONSTRAINT repositories_pkey PRIMARY KEY (owner, repository)
+			);
+		`),
+	}
+}
+
+func (s *RepositoryStore) Add(ctx context.Context, r store.Repository) error {
+	labels := r.Labels
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	_, err := s.connPool.Exec(ctx, `
+		INSERT INTO repositories (provider, owner, repository, url, labels, creation_time) VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (owner, repository) DO UPDATE SET
+			provider = EXCLUDED.provider,
+			url = EXCLUDED.url,
+			labels = EXCLUDED.labels,
+			creation_time = EXCLUDED.creation_time;
+	`, r.Provider, r.Owner, r.Name, r.URL, labels, r.CreationTime)
+	if err != nil {
+		return fmt.Errorf("failed to add repository %s: %w", r, err)
+	}
+	return nil
+}
+
+func (s *RepositoryStore) Delete(ctx context.Context, owner, name string) error {
+	_, err := s.connPool.Exec(ctx, "DELETE FROM repositories WHERE owner = $1 AND repository = $2;", owner, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete repository %s/%s: %w", owner, name, err)
+	}
+	return nil
+}
+
+func (s *RepositoryStore) ListActivity(ctx context.Context, owner string) ([]store.RepositoryActivity, error) {
+	rows, err := s.connPool.Query(ctx, `
+		SELECT r.provider, r.owner, r.repository, r.url, r.labels, r.creation_time,
+			(SELECT max(creation_time) FROM pull_requests WHERE owner = r.owner AND repository = r.repository),
+			(SELECT max(start_time) FROM pipelines WHERE owner = r.owner AND repository = r.repository),
+			(SELECT max(release_time) FROM releases WHERE owner = r.owner AND repository = r.repository),
+			(SELECT max(deployment_time) FROM deployments WHERE owner = r.owner AND repository = r.repository)
+		FROM repositories r
+		WHERE $1 = '' OR r.owner = $1
+		ORDER BY r.owner, r.repository;
+	`, owner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repositories: %w", err)
+	}
+	defer rows.Close()
+
+	var activities []store.RepositoryActivity
+	for rows.Next() {
+		var a store.RepositoryActivity
+		err = rows.Scan(&a.Provider, &a.Owner, &a.Name, &a.URL, &a.Labels, &a.CreationTime,
+			&a.LastPullRequestTime, &a.LastPipelineTime, &a.LastReleaseTime, &a.LastDeploymentTime)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read repository: %w", err)
+		}
+		activities = append(activities, a)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list repositories: %w", err)
+	}
+	return activities, nil
+}
@@ -0,0 +1,392 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/jenkins-x/cd-indicators/store/migration"
+)
+
+type retentionTable struct {
+	timeColumn string
+	// timeFallbacks are the columns replacing the time column when it is NULL
+	timeFallbacks []string
+	rollup        *rollup
+}
+
+// timeExpression returns the time of the rows, compared with the cutoff
+func (t retentionTable) timeExpression() string {
+	if len(t.timeFallbacks) == 0 {
+		return t.timeColumn
+	}
+	return fmt.Sprintf("COALESCE(%s, %s)", t.timeColumn, strings.Join(t.timeFallbacks, ", "))
+}
+
+// rollup describes how the raw rows of a table are aggregated per period before being deleted:
+// the number of rows of each group, and optionally their outcome and the percentiles of their duration
+type rollup struct {
+	groupBy []string
+	// isBot is the expression of the is_bot dimension, so that the aggregates can exclude the automated changes
+	isBot       string
+	countColumn string
+	outcome     *rollupOutcome
+	duration    *rollupDuration
+}
+
+// rollupOutcome counts the rows matching a condition - such as the succeeded pipelines - the other rows, and the ratio of the matching rows
+type rollupOutcome struct {
+	condition     string
+	matchedColumn string
+	otherColumn   string
+	rateColumn    string
+}
+
+// rollupDuration aggregates the non-NULL durations of the rows in the <prefix>_avg, _p50, _p90, _p95 and _max columns
+type rollupDuration struct {
+	expression string
+	prefix     string
+}
+
+var retentionTables = map[string]retentionTable{
+	"pipelines": {
+		timeColumn: "start_time",
+		rollup: &rollup{
+			groupBy:     []string{"type", "owner", "repository", "context"},
+			isBot:       "is_bot",
+			countColumn: "runs",
+			outcome: &rollupOutcome{
+				condition:     "status = 'Succeeded'",
+				matchedColumn: "succeeded",
+				otherColumn:   "failed",
+				rateColumn:    "success_rate",
+			},
+			duration: &rollupDuration{expression: "duration", prefix: "duration"},
+		},
+	},
+	"pipelinesteps": {
+		timeColumn: "step_started_time",
+		rollup: &rollup{
+			groupBy:     []string{"type", "owner", "repository", "context", "step_name"},
+			isBot:       "is_bot",
+			countColumn: "runs",
+			outcome: &rollupOutcome{
+				condition:     "step_status = 'Succeeded'",
+				matchedColumn: "succeeded",
+				otherColumn:   "failed",
+				rateColumn:    "success_rate",
+			},
+			duration: &rollupDuration{expression: "step_duration", prefix: "duration"},
+		},
+	},
+	"pull_requests": {
+		timeColumn:    "creation_time",
+		timeFallbacks: []string{"ready_for_review_time", "first_comment_time", "first_review_time", "approved_time", "merged_time", "closed_time"},
+		rollup: &rollup{
+			groupBy:     []string{"owner", "repository"},
+			isBot:       "is_bot",
+			countColumn: "pull_requests",
+			outcome: &rollupOutcome{
+				condition:     "merged_time IS NOT NULL",
+				matchedColumn: "merged",
+				otherColumn:   "not_merged",
+				rateColumn:    "merge_rate",
+			},
+			duration: &rollupDuration{expression: "CASE WHEN merged_time IS NOT NULL THEN time_to_merge END", prefix: "time_to_merge"},
+		},
+	},
+	"pull_request_events": {
+		timeColumn: "event_time",
+	},
+	"pipeline_events": {
+		timeColumn: "start_time",
+	},
+	"deployment_events": {
+		timeColumn: "deployment_time",
+	},
+	"releases": {
+		timeColumn: "release_time",
+		rollup: &rollup{
+			groupBy:     []string{"owner", "repository"},
+			isBot:       "is_bot",
+			countColumn: "releases",
+		},
+	},
+	"deployments": {
+		timeColumn: "deployment_time",
+		rollup: &rollup{
+			groupBy: []string{"owner", "repository", "environment"},
+			// a deployment is automated when its release is
+			isBot:       "EXISTS (SELECT 1 FROM releases r WHERE r.owner = deployments.owner AND r.repository = deployments.repository AND r.version = deployments.version AND r.is_bot)",
+			countColumn: "deployments",
+		},
+	},
+	"lighthouse_jobs": {
+		timeColumn: "trigger_time",
+		rollup: &rollup{
+			groupBy:     []string{"type", "owner", "repository", "context"},
+			isBot:       "is_bot",
+			countColumn: "jobs",
+			outcome: &rollupOutcome{
+				condition:     "state = 'success'",
+				matchedColumn: "succeeded",
+				otherColumn:   "failed",
+				rateColumn:    "success_rate",
+			},
+			duration: &rollupDuration{expression: "queue_duration", prefix: "queue_duration"},
+		},
+	},
+	"helm_releases": {
+		timeColumn: "deployment_time",
+	},
+	"gitops_syncs": {
+		timeColumn: "sync_time",
+	},
+	"incidents": {
+		timeColumn: "detection_time",
+	},
+}
+
+type rollupPeriod struct {
+	unit        string
+	tableSuffix string
+}
+
+var rollupPeriods = []rollupPeriod{
+	{unit: "day", tableSuffix: "daily"},
+	{unit: "week", tableSuffix: "weekly"},
+}
+
+type RetentionStore struct {
+	connPool *pgxpool.Pool
+}
+
+func (s *RetentionStore) TableName() string {
+	return "retention"
+}
+
+func (s *RetentionStore) Migrations() []migration.Func {
+	return []migration.Func{
+		migration.ExecSQLFunc(`
+			CREATE TABLE retention_archive (
+				table_name VARCHAR NOT NULL,
+				archived_time timestamp without time zone NOT NULL,
+				data jsonb NOT NULL
+			);
+			CREATE INDEX retention_archive_table_name_idx ON retention_archive (table_name);
+		`),
+		migration.ExecSQLFunc(`
+			CREATE TABLE pipelines_daily (
+				period_start timestamp without time zone NOT NULL,
+				type VARCHAR NOT NULL,
+				owner VARCHAR NOT NULL,
+				repository VARCHAR NOT NULL,
+				context VARCHAR NOT NULL,
+				runs int NOT NULL,
+				succeeded int NOT NULL,
+				failed int NOT NULL,
+				success_rate double precision NOT NULL,
+				duration_avg double precision,
+				duration_p50 double precision,
+				duration_p90 double precision,
+				duration_p95 double precision,
+				duration_max bigint,
+				CONSTRAINT pipelines_daily_pkey PRIMARY KEY (period_start, type, owner, repository, context)
+			);
+			CREATE TABLE pipelines_weekly (LIKE pipelines_daily INCLUDING ALL);
+		`),
+		migration.ExecSQLFunc(`
+			CREATE TABLE pipelinesteps_daily (
+				period_start timestamp without time zone NOT NULL,
+				type VARCHAR NOT NULL,
+				owner VARCHAR NOT NULL,
+				repository VARCHAR NOT NULL,
+				context VARCHAR NOT NULL,
+				step_name VARCHAR NOT NULL,
+				runs int NOT NULL,
+				succeeded int NOT NULL,
+				failed int NOT NULL,
+				success_rate double precision NOT NULL,
+				duration_avg double precision,
+				duration_p50 double precision,
+				duration_p90 double precision,
+				duration_p95 double precision,
+				duration_max bigint,
+				CONSTRAINT pipelinesteps_daily_pkey PRIMARY KEY (period_start, type, owner, repository, context, step_name)
+			);
+			CREATE TABLE pipelinesteps_weekly (LIKE pipelinesteps_daily INCLUDING ALL);
+		`),
+		// the aggregates computed before are kept as not automated
+		migration.ExecSQLFunc(`
+			ALTER TABLE pipelines_daily ADD COLUMN is_bot boolean NOT NULL DEFAULT false,
+				DROP CONSTRAINT pipelines_daily_pkey,
+				ADD CONSTRAINT pipelines_daily_pkey PRIMARY KEY (period_start, type, owner, repository, context, is_bot);
+			ALTER TABLE pipelines_weekly ADD COLUMN is_bot boolean NOT NULL DEFAULT false,
+				DROP CONSTRAINT pipelines_weekly_pkey,
+				ADD CONSTRAINT pipelines_weekly_pkey PRIMARY KEY (period_start, type, owner, repository, context, is_bot);
+			ALTER TABLE pipelinesteps_daily ADD COLUMN is_bot boolean NOT NULL DEFAULT false,
+				DROP CONSTRAINT pipelinesteps_daily_pkey,
+				ADD CONSTRAINT pipelinesteps_daily_pkey PRIMARY KEY (period_start, type, owner, repository, context, step_name, is_bot);
+			ALTER TABLE pipelinesteps_weekly ADD COLUMN is_bot boolean NOT NULL DEFAULT false,
+				DROP CONSTRAINT pipelinesteps_weekly_pkey,
+				ADD CONSTRAINT pipelinesteps_weekly_pkey PRIMARY KEY (period_start, type, owner, repository, context, step_name, is_bot);
+		`),
+		migration.ExecSQLFunc(`
+			CREATE TABLE pull_requests_daily (
+				period_start timestamp without time zone NOT NULL,
+				owner VARCHAR NOT NULL,
+				repository VARCHAR NOT NULL,
+				is_bot boolean NOT NULL,
+				pull_requests int NOT NULL,
+				merged int NOT NULL,
+				not_merged int NOT NULL,
+				merge_rate double precision NOT NULL,
+				time_to_merge_avg double precision,
+				time_to_merge_p50 double precision,
+				time_to_merge_p90 double precision,
+				time_to_merge_p95 double precision,
+				time_to_merge_max bigint,
+				CONSTRAINT pull_requests_daily_pkey PRIMARY KEY (period_start, owner, repository, is_bot)
+			);
+			CREATE TABLE pull_requests_weekly (LIKE pull_requests_daily INCLUDING ALL);
+			CREATE TABLE releases_daily (
+				period_start timestamp without time zone NOT NULL,
+				owner VARCHAR NOT NULL,
+				repository VARCHAR NOT NULL,
+				is_bot boolean NOT NULL,
+				releases int NOT NULL,
+				CONSTRAINT releases_daily_pkey PRIMARY KEY (period_start, owner, repository, is_bot)
+			);
+			CREATE TABLE releases_weekly (LIKE releases_daily INCLUDING ALL);
+			CREATE TABLE deployments_daily (
+				period_start timestamp without time zone NOT NULL,
+				owner VARCHAR NOT NULL,
+				repository VARCHAR NOT NULL,
+				environment VARCHAR NOT NULL,
+				is_bot boolean NOT NULL,
+				deployments int NOT NULL,
+				CONSTRAINT deployments_daily_pkey PRIMARY KEY (period_start, owner, repository, environment, is_bot)
+			);
+			CREATE TABLE deployments_weekly (LIKE deployments_daily INCLUDING ALL);
+			CREATE TABLE lighthouse_jobs_daily (
+				period_start timestamp without time zone NOT NULL,
+				type VARCHAR NOT NULL,
+				owner VARCHAR NOT NULL,
+				repository VARCHAR NOT NULL,
+				context VARCHAR NOT NULL,
+				is_bot boolean NOT NULL,
+				jobs int NOT NULL,
+				succeeded int NOT NULL,
+				failed int NOT NULL,
+				success_rate double precision NOT NULL,
+				queue_duration_avg double precision,
+				queue_duration_p50 double precision,
+				queue_duration_p90 double precision,
+				queue_duration_p95 double precision,
+				queue_duration_max bigint,
+				CONSTRAINT lighthouse_jobs_daily_pkey PRIMARY KEY (period_start, type, owner, repository, context, is_bot)
+			);
+			CREATE TABLE lighthouse_jobs_weekly (LIKE lighthouse_jobs_daily INCLUDING ALL);
+		`),
+	}
+}
+
+// Apply rolls up, archives and deletes the rows of the policy's table which are older than its cutoff
+func (s *RetentionStore) Apply(ctx context.Context, policy store.RetentionPolicy, now time.Time) (store.RetentionResult, error) {
+	var result store.RetentionResult
+	if err := policy.Validate(); err != nil {
+		return result, err
+	}
+	table := retentionTables[policy.Table]
+	cutoff := policy.Cutoff(now)
+
+	tx, err := s.connPool.BeginTx(ctx, pgx.TxOptions{
+		AccessMode: pgx.ReadWrite,
+	})
+	if err != nil {
+		return result, fmt.Errorf("failed to start a new DB transaction: %w", err)
+	}
+	defer tx.Rollback(ctx) // nolint: errcheck
+
+	// the rows without any time yet - such as the pull requests only known by their label events - never expire
+	timeExpression := table.timeExpression()
+	where := fmt.Sprintf("%s < $1", timeExpression)
+
+	if table.rollup != nil {
+		for _, period := range rollupPeriods {
+			ct, err := tx.Exec(ctx, table.rollup.sql(policy.Table, timeExpression, period, where), cutoff)
+			if err != nil {
+				return result, fmt.Errorf("failed to roll up %s per %s: %w", policy.Table, period.unit, err)
+			}
+			result.RolledUp += ct.RowsAffected()
+		}
+	}
+
+	if policy.Archive {
+		ct, err := tx.Exec(ctx, fmt.Sprintf(`
+		INSERT INTO retention_archive (table_name, archived_time, data)
+		SELECT $2, $3, to_jsonb(t) FROM %s t WHERE %s;`, policy.Table, where), cutoff, policy.Table, now.UTC())
+		if err != nil {
+			return result, fmt.Errorf("failed to archive %s: %w", policy.Table, err)
+		}
+		result.Archived = ct.RowsAffected()
+	}
+
+	ct, err := tx.Exec(ctx, fmt.Sprintf("DELETE FROM %s WHERE %s;", policy.Table, where), cutoff)
+	if err != nil {
+		return result, fmt.Errorf("failed to delete expired rows from %s: %w", policy.Table, err)
+	}
+	result.Deleted = ct.RowsAffected()
+
+	if err = tx.Commit(ctx); err != nil {
+		return result, fmt.Errorf("failed to commit retention of %s: %w", policy.Table, err)
+	}
+
+	return result, nil
+}
+
+// sql returns the statement aggregating the rows matching the where clause - and having a time - into the table's daily or weekly rollup table.
+// Aggregates which already exist are kept: they were computed before the rows were deleted,
+// so rows re-inserted later by a resync are already accounted for.
+func (r *rollup) sql(table, timeExpression string, period rollupPeriod, where string) string {
+	columns := append([]string{"period_start"}, r.groupBy...)
+	columns = append(columns, "is_bot", r.countColumn)
+	values := append([]string{fmt.Sprintf("date_trunc('%s', %s)", period.unit, timeExpression)}, r.groupBy...)
+	values = append(values, r.isBot, "count(1)")
+	groupBy := make([]string, 0, len(r.groupBy)+2)
+	for i := 1; i <= len(r.groupBy)+2; i++ {
+		groupBy = append(groupBy, strconv.Itoa(i))
+	}
+
+	if r.outcome != nil {
+		matched := fmt.Sprintf("count(1) FILTER (WHERE %s)", r.outcome.condition)
+		columns = append(columns, r.outcome.matchedColumn, r.outcome.otherColumn, r.outcome.rateColumn)
+		values = append(values, matched, "count(1) - "+matched, matched+"::double precision / count(1)")
+	}
+	if r.duration != nil {
+		d := r.duration.expression
+		columns = append(columns, r.duration.prefix+"_avg", r.duration.prefix+"_p50", r.duration.prefix+"_p90", r.duration.prefix+"_p95", r.duration.prefix+"_max")
+		values = append(values,
+			fmt.Sprintf("avg(%s)", d),
+			fmt.Sprintf("percentile_cont(0.5) WITHIN GROUP (ORDER BY %s)", d),
+			fmt.Sprintf("percentile_cont(0.9) WITHIN GROUP (ORDER BY %s)", d),
+			fmt.Sprintf("percentile_cont(0.95) WITHIN GROUP (ORDER BY %s)", d),
+			fmt.Sprintf("max(%s)", d),
+		)
+	}
+
+	targetTable := fmt.Sprintf("%s_%s", table, period.tableSuffix)
+	return fmt.Sprintf(`
+	INSERT INTO %[1]s (%[2]s)
+	SELECT %[3]s
+	FROM %[4]s
+	WHERE %[5]s AND %[6]s IS NOT NULL
+	GROUP BY %[7]s
+	ON CONFLICT ON CONSTRAINT %[1]s_pkey DO NOTHING;`,
+		targetTable, strings.Join(columns, ", "), strings.Join(values, ", "), table, where, timeExpression, strings.Join(groupBy, ", "))
+}
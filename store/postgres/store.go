@@ -0,0 +1,105 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/jenkins-x/cd-indicators/store/migration"
+)
+
+// New returns a store backed by PostgreSQL, after running the migrations
+func New(ctx context.Context, connPool *pgxpool.Pool) (*store.Store, error) {
+	notifier := &store.Notifier{}
+	var (
+		pipelines = &PipelineStore{
+			connPool: connPool,
+			notifier: notifier,
+		}
+		pullRequests = &PullRequestStore{
+			connPool: connPool,
+			notifier: notifier,
+		}
+		pullRequestEvents = &PullRequestEventStore{
+			connPool: connPool,
+		}
+		releases = &ReleaseStore{
+			connPool: connPool,
+			notifier: notifier,
+		}
+		deployments = &DeploymentStore{
+			connPool: connPool,
+			notifier: notifier,
+		}
+		identities = &IdentityStore{
+			connPool: connPool,
+		}
+		teams = &TeamStore{
+			connPool: connPool,
+		}
+		repositories = &RepositoryStore{
+			connPool: connPool,
+		}
+		lighthouseJobs = &LighthouseJobStore{
+			connPool: connPool,
+		}
+		helmReleases = &HelmReleaseStore{
+			connPool: connPool,
+		}
+		gitOpsSyncs = &GitOpsSyncStore{
+			connPool: connPool,
+		}
+		incidents = &IncidentStore{
+			connPool: connPool,
+		}
+		retention = &RetentionStore{
+			connPool: connPool,
+		}
+	)
+
+	err := (&migration.Migrator{
+		ConnPool: connPool,
+	}).Migrate(ctx,
+		pipelines,
+		pullRequests,
+		pullRequestEvents,
+		releases,
+		deployments,
+		identities,
+		teams,
+		repositories,
+		lighthouseJobs,
+		helmReleases,
+		gitOpsSyncs,
+		incidents,
+		retention,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run store migrations: %w", err)
+	}
+
+	return &store.Store{
+		Pipelines:         pipelines,
+		PullRequests:      pullRequests,
+		PullRequestEvents: pullRequestEvents,
+		Releases:          releases,
+		Deployments:       deployments,
+		Identities:        identities,
+		Teams:             teams,
+		Repositories:      repositories,
+		LighthouseJobs:    lighthouseJobs,
+		HelmReleases:      helmReleases,
+		GitOpsSyncs:       gitOpsSyncs,
+		Incidents:         incidents,
+		Retention:         retention,
+		Notifier:          notifier,
+		Rebuild: &RebuildStore{
+			connPool: connPool,
+		},
+		Export: &ExportStore{
+			connPool: connPool,
+		},
+		Health: connPool,
+	}, nil
+}
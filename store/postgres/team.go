@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/jenkins-x/cd-indicators/store/migration"
+)
+
+type TeamStore struct {
+	connPool *pgxpool.Pool
+}
+
+func (s *TeamStore) TableName() string {
+	return "team_repositories"
+}
+
+func (s *TeamStore) Migrations() []migration.Func {
+	return []migration.Func{
+		migration.ExecSQLFunc(`
+			CREATE TABLE team_repositories (
+				team VARCHAR NOT NULL,
+				owner VARCHAR NOT NULL,
+				repository VARCHAR NOT NULL,
+				source VARCHAR NOT NULL,
+				CONSTRAINT team_repositories_pkey PRIMARY KEY (team, owner, repository, source)
+			);
+		`),
+		migration.ExecSQLFunc(`
+			CREATE INDEX team_repositories_repository_idx ON team_repositories (owner, repository);
+		`),
+	}
+}
+
+func (s *TeamStore) List(ctx context.Context) ([]store.TeamRepository, error) {
+	rows, err := s.connPool.Query(ctx, "SELECT team, owner, repository, source FROM team_repositories ORDER BY team, owner, repository, source;")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list team repositories: %w", err)
+	}
+	defer rows.Close()
+
+	var mappings []store.TeamRepository
+	for rows.Next() {
+		var m store.TeamRepository
+		if err = rows.Scan(&m.Team, &m.Owner, &m.Repository, &m.Source); err != nil {
+			return nil, fmt.Errorf("failed to read team repository: %w", err)
+		}
+		mappings = append(mappings, m)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list team repositories: %w", err)
+	}
+	return mappings, nil
+}
+
+func (s *TeamStore) Replace(ctx context.Context, source string, mappings []store.TeamRepository) error {
+	tx, err := s.connPool.BeginTx(ctx, pgx.TxOptions{
+		AccessMode: pgx.ReadWrite,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start a new DB transaction: %w", err)
+	}
+	defer tx.Rollback(ctx) // nolint: errcheck
+
+	if _, err = tx.Exec(ctx, "DELETE FROM team_repositories WHERE source = $1;", source); err != nil {
+		return fmt.Errorf("failed to delete the team repositories of %s: %w", source, err)
+	}
+	for _, m := range mappings {
+		_, err = tx.Exec(ctx, "INSERT INTO team_repositories (team, owner, repository, source) VALUES ($1, $2, $3, $4) ON CONFLICT DO NOTHING;", m.Team, m.Owner, m.Repository, source)
+		if err != nil {
+			return fmt.Errorf("failed to add team repository %s/%s of %s: %w", m.Owner, m.Repository, m.Team, err)
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit replacement of the team repositories of %s: %w", source, err)
+	}
+	return nil
+}
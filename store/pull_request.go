@@ -2,13 +2,10 @@ package store
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"strings"
 	"time"
 
-	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/jenkins-x/cd-indicators/store/migration"
 	"github.com/scylladb/go-set/strset"
 )
 
@@ -20,20 +17,88 @@ type PullRequest struct {
 	State              string
 	Reviews            int
 	Reviewers          []string
+	Approvers          []string
 	CreationTime       *time.Time
 	ReadyForReviewTime *time.Time
 	ApprovedTime       *time.Time
 	TimeToReview       time.Duration
 	MergedTime         *time.Time
 	TimeToMerge        time.Duration
+	FirstReviewTime    *time.Time
+	TimeToFirstReview  time.Duration
+	FirstCommentTime   *time.Time
+	TimeToFirstComment time.Duration
+	// ChangeRequests is the number of reviews requesting changes - the review rounds
+	ChangeRequests int
+	// PushesAfterFirstReview is the number of pushes of new commits (synchronize events) after the first review
+	PushesAfterFirstReview int
+	Additions              int
+	Deletions              int
+	ChangedFiles           int
+	// ClosedTime is set when the pull request is closed without being merged
+	ClosedTime *time.Time
+	// IsBot is true if the author is a bot
+	IsBot bool
+
+	// ApprovalRule is set when handling an approving review. It is not stored.
+	ApprovalRule *ApprovalRule
+	// RevokedApprovers are removed from the approvers: the reviewers whose review has been dismissed,
+	// or who requested changes since their approval. It is not stored.
+	RevokedApprovers []string
+}
+
+// ApprovalRule defines when the approving reviews approve a pull request
+type ApprovalRule struct {
+	MinApprovals int
+	// RequiredApprovers are the sets of git users - at least one of each set must approve
+	RequiredApprovers [][]string
+	// Time is when the approving review has been submitted
+	Time time.Time
+}
+
+// IsSatisfiedBy returns true if the given approvers are enough to approve the pull request
+func (r ApprovalRule) IsSatisfiedBy(approvers []string) bool {
+	if len(approvers) == 0 || len(approvers) < r.MinApprovals {
+		return false
+	}
+	for _, required := range r.RequiredApprovers {
+		if !containsAny(required, approvers) {
+			return false
+		}
+	}
+	return true
+}
+
+// containsAny returns true if one of the values is in the list, case-insensitively
+func containsAny(list, values []string) bool {
+	for _, item := range list {
+		for _, value := range values {
+			if strings.EqualFold(item, value) {
+				return true
+			}
+		}
+	}
+	return false
 }
 
+// MergeWith merges the pull request with the stored one.
+// It should always be called - with an empty pull request if none has been stored yet -
+// because some counters depend on the stored state.
 func (pr *PullRequest) MergeWith(other PullRequest) {
+	if other.FirstReviewTime == nil {
+		pr.PushesAfterFirstReview = 0 // not reviewed yet
+	}
+	pr.PushesAfterFirstReview += other.PushesAfterFirstReview
 	pr.Reviews += other.Reviews
+	pr.ChangeRequests += other.ChangeRequests
 	pr.Reviewers = strset.Union(
 		strset.New(pr.Reviewers...),
 		strset.New(other.Reviewers...),
 	).List()
+	pr.Approvers = strset.Difference(
+		strset.Union(strset.New(pr.Approvers...), strset.New(other.Approvers...)),
+		strset.New(pr.RevokedApprovers...),
+	).List()
 	if pr.CreationTime == nil && other.CreationTime != nil {
 		pr.CreationTime = other.CreationTime
 	}
@@ -52,6 +117,40 @@ func (pr *PullRequest) MergeWith(other PullRequest) {
 	if pr.MergedTime == nil && other.MergedTime != nil {
 		pr.MergedTime = other.MergedTime
 	}
+	pr.FirstReviewTime = earliest(pr.FirstReviewTime, other.FirstReviewTime)
+	pr.FirstCommentTime = earliest(pr.FirstCommentTime, other.FirstCommentTime)
+	if pr.ChangedFiles == 0 && pr.Additions == 0 && pr.Deletions == 0 {
+		pr.Additions, pr.Deletions, pr.ChangedFiles = other.Additions, other.Deletions, other.ChangedFiles
+	}
+	if pr.ClosedTime == nil && other.ClosedTime != nil {
+		pr.ClosedTime = other.ClosedTime
+	}
+	if pr.ClosedTime != nil && pr.ClosedTime.IsZero() {
+		pr.ClosedTime = nil // force a reset
+	}
+}
+
+// ClosedWithoutMerge returns true if the pull request has been closed without being merged
+func (pr PullRequest) ClosedWithoutMerge() bool {
+	return pr.ClosedTime != nil && pr.MergedTime == nil
+}
+
+func earliest(a, b *time.Time) *time.Time {
+	if a == nil || (b != nil && b.Before(*a)) {
+		return b
+	}
+	return a
+}
+
+// ApplyApprovalRule sets the approved time once the approving reviews satisfy the approval rule
+func (pr *PullRequest) ApplyApprovalRule() {
+	if pr.ApprovalRule == nil || pr.ApprovedTime != nil {
+		return
+	}
+	if pr.ApprovalRule.IsSatisfiedBy(pr.Approvers) {
+		approvedTime := pr.ApprovalRule.Time
+		pr.ApprovedTime = &approvedTime
+	}
 }
 
 func (pr *PullRequest) CalculateDurations() {
@@ -64,84 +163,25 @@ func (pr *PullRequest) CalculateDurations() {
 	if pr.TimeToMerge > 0 && pr.TimeToReview == 0 {
 		pr.TimeToReview = pr.TimeToMerge // if it has never been approved, but forced-merge
 	}
+	// the first review and comment can happen before the pull request is ready for review
+	readyTime := pr.ReadyForReviewTime
+	if readyTime == nil {
+		readyTime = pr.CreationTime
+	}
+	if readyTime != nil && pr.FirstReviewTime != nil && pr.FirstReviewTime.After(*readyTime) {
+		pr.TimeToFirstReview = pr.FirstReviewTime.Sub(*readyTime)
+	}
+	if readyTime != nil && pr.FirstCommentTime != nil && pr.FirstCommentTime.After(*readyTime) {
+		pr.TimeToFirstComment = pr.FirstCommentTime.Sub(*readyTime)
+	}
 }
 
 func (pr PullRequest) String() string {
 	return fmt.Sprintf(`"%s/%s" #%v by %q`, pr.Owner, pr.Repository, pr.PullRequest, pr.Author)
 }
 
-type PullRequestStore struct {
-	connPool *pgxpool.Pool
-}
-
-func (s *PullRequestStore) TableName() string {
-	return "pull_requests"
-}
-
-func (s *PullRequestStore) Migrations() []migration.Func {
-	return []migration.Func{
-		migration.ExecSQLFunc(`
-			CREATE TABLE pull_requests (
-				owner VARCHAR NOT NULL,
-				repository VARCHAR NOT NULL,
-				pull_request int NOT NULL,
-				author VARCHAR,
-				state VARCHAR,
-				reviews int,
-				reviewers VARCHAR[],
-				creation_time timestamp without time zone,
-				ready_for_review_time timestamp without time zone,
-				approved_time timestamp without time zone,
-				time_to_review bigint,
-				merged_time timestamp without time zone,
-				time_to_merge bigint,
-				CONSTRAINT pull_requests_pkey PRIMARY KEY (owner, repository, pull_request)
-			);
-		`),
-	}
-}
-
-func (s *PullRequestStore) Add(ctx context.Context, pr PullRequest) error {
-	tx, err := s.connPool.BeginTx(ctx, pgx.TxOptions{
-		AccessMode: pgx.ReadWrite,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to start a new DB transaction: %w", err)
-	}
-	defer tx.Rollback(ctx) // nolint: errcheck
-
-	var prFromDB PullRequest
-	err = tx.QueryRow(ctx, fmt.Sprintf(`
-	SELECT creation_time, ready_for_review_time, approved_time, merged_time, reviews, reviewers 
-	FROM %s WHERE owner=$1 AND repository=$2 AND pull_request=$3`, s.TableName()), pr.Owner, pr.Repository, pr.PullRequest).Scan(
-		&prFromDB.CreationTime,
-		&prFromDB.ReadyForReviewTime,
-		&prFromDB.ApprovedTime,
-		&prFromDB.MergedTime,
-		&prFromDB.Reviews,
-		&prFromDB.Reviewers,
-	)
-	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
-		return fmt.Errorf("failed to retrieve current pullrequest %s: %w", pr, err)
-	}
-	if err == nil {
-		pr.MergeWith(prFromDB)
-	}
-	pr.CalculateDurations()
-
-	_, err = tx.Exec(ctx, `
-	INSERT INTO pull_requests (owner, repository, pull_request, author, state, creation_time, ready_for_review_time, approved_time, time_to_review, merged_time, time_to_merge, reviews, reviewers) 
-	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) 
-	ON CONFLICT ON CONSTRAINT pull_requests_pkey DO UPDATE 
-	SET state = EXCLUDED.state, creation_time = EXCLUDED.creation_time, ready_for_review_time = EXCLUDED.ready_for_review_time, approved_time = EXCLUDED.approved_time, time_to_review = EXCLUDED.time_to_review, merged_time = EXCLUDED.merged_time, time_to_merge = EXCLUDED.time_to_merge, reviews = EXCLUDED.reviews, reviewers = EXCLUDED.reviewers;`,
-		pr.Owner, pr.Repository, pr.PullRequest, pr.Author, pr.State, pr.CreationTime, pr.ReadyForReviewTime, pr.ApprovedTime, pr.TimeToReview.Seconds(), pr.MergedTime, pr.TimeToMerge.Seconds(), pr.Reviews, pr.Reviewers)
-	if err != nil {
-		return fmt.Errorf("failed to add pullrequest: %w", err)
-	}
-
-	if err = tx.Commit(ctx); err != nil {
-		return fmt.Errorf("failed to commit insertion of pullrequest: %w", err)
-	}
-
-	return nil
+// PullRequestStore stores pull requests.
+// Adding a pull request which has already been stored merges it with the stored one, see PullRequest.MergeWith.
+type PullRequestStore interface {
+	Add(ctx context.Context, pr PullRequest) error
 }
@@ -0,0 +1,61 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// PullRequestEvent is a handled pull request webhook, with everything needed to recompute the pull request summary
+type PullRequestEvent struct {
+	Owner       string
+	Repository  string
+	PullRequest int
+	// Action is the webhook action, as defined by scm.Action.String
+	Action string
+	// Actor is the git user who triggered the event: the reviewer, commenter, or pull request author
+	Actor string
+	// ActorPerson is the canonical person of the actor's identity, when the event has been stored - or rebuilt.
+	// It is empty for the events stored before it existed: the actor is then used instead, see Reviewer
+	ActorPerson string
+	// Time is when the event happened - or when it has been received, if the webhook doesn't have a time
+	Time time.Time
+	// Author, State, Draft, Merged and CreationTime are the pull request's, at the time of the event
+	Author       string
+	State        string
+	Draft        bool
+	Merged       bool
+	CreationTime time.Time
+	Label        string
+	ReviewState  string
+	Additions    int
+	Deletions    int
+	ChangedFiles int
+	// ChangedPaths are the paths of the changed files, only kept for the approving reviews when some required approvers depend on them
+	ChangedPaths []string
+}
+
+// Reviewer returns the canonical person of the actor, to match the reviewers of the pull request summaries
+func (e PullRequestEvent) Reviewer() string {
+	if e.ActorPerson != "" {
+		return e.ActorPerson
+	}
+	return e.Actor
+}
+
+func (e PullRequestEvent) String() string {
+	return fmt.Sprintf(`"%s/%s" #%v %s by %q`, e.Owner, e.Repository, e.PullRequest, e.Action, e.Actor)
+}
+
+// PullRequestEventFilter restricts the events to an owner and a repository. Empty values match everything.
+type PullRequestEventFilter struct {
+	Owner      string
+	Repository string
+}
+
+// PullRequestEventStore is an append-only log of the pull request events
+type PullRequestEventStore interface {
+	Add(ctx context.Context, event PullRequestEvent) error
+	// List calls eventFunc for each matching event, in the order in which they have been added
+	List(ctx context.Context, filter PullRequestEventFilter, eventFunc func(PullRequestEvent) error) error
+}
@@ -0,0 +1,77 @@
+package store
+
+import "context"
+
+// RebuildFilter restricts a rebuild to a git owner and/or a repository. Empty values match everything.
+type RebuildFilter struct {
+	Owner      string
+	Repository string
+}
+
+// Rebuilder recomputes the derived data, with the current code and configuration
+type Rebuilder struct {
+	// PullRequest returns the changes to merge into the pull request summary for an event, or false to ignore the event
+	PullRequest func(PullRequestEvent) (PullRequest, bool)
+	// Person returns the canonical person of a git user login, stored again on the events - see PullRequestEvent.ActorPerson
+	Person func(login string) string
+	// Pipeline returns the pipeline rebuilt from the one of its event, which has an AuthorLogin but no Author
+	Pipeline func(Pipeline) Pipeline
+	// Deployment returns the deployment rebuilt from the one of its event, which has a SourceEnvironment
+	Deployment func(Deployment) Deployment
+}
+
+// RebuildResult counts the rebuilt rows
+type RebuildResult struct {
+	PullRequestEvents int
+	PullRequests      int
+	// IncompletePullRequests are the pull requests kept as-is, because their event history is incomplete
+	IncompletePullRequests int
+	Pipelines              int
+	Deployments            int
+}
+
+// RebuildStore rebuilds the derived tables from the event logs, in a single transaction:
+//   - the pull_requests summaries from the pull_request_events. Only the pull requests whose event history is complete
+//     are rebuilt, see CompleteEventHistories. The canonical persons of the actors of all the events are updated too,
+//     so that they match the rebuilt summaries.
+//   - the pipelines and pipelinesteps from the pipeline_events
+//   - the deployments from the deployment_events
+//
+// Only the pipelines and deployments still stored are rebuilt: the ones collected before their events were stored are kept as-is,
+// and the ones deleted by the retention job are not restored.
+type RebuildStore interface {
+	Rebuild(ctx context.Context, filter RebuildFilter, rebuilder Rebuilder) (RebuildResult, error)
+}
+
+// OpenedAction is the action of the event of the creation of a pull request - see scm.ActionOpen
+const OpenedAction = "opened"
+
+// CompleteEventHistories splits the events - in order - between the pull requests whose event history is complete,
+// because it starts with their creation, and the other pull requests: the ones collected before the events were stored,
+// or whose older events have been deleted by the retention job. It returns the events of the complete histories,
+// and the number of pull requests with an incomplete history.
+func CompleteEventHistories(events []PullRequestEvent) ([]PullRequestEvent, int) {
+	type pullRequestKey struct {
+		owner, repository string
+		pullRequest       int
+	}
+	complete := map[pullRequestKey]bool{}
+	for _, e := range events {
+		key := pullRequestKey{owner: e.Owner, repository: e.Repository, pullRequest: e.PullRequest}
+		complete[key] = complete[key] || e.Action == OpenedAction
+	}
+
+	var completeEvents []PullRequestEvent
+	for _, e := range events {
+		if complete[pullRequestKey{owner: e.Owner, repository: e.Repository, pullRequest: e.PullRequest}] {
+			completeEvents = append(completeEvents, e)
+		}
+	}
+	var incomplete int
+	for _, ok := range complete {
+		if !ok {
+			incomplete++
+		}
+	}
+	return completeEvents, incomplete
+}
@@ -4,10 +4,6 @@ import (
 	"context"
 	"fmt"
 	"time"
-
-	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/jenkins-x/cd-indicators/store/migration"
 )
 
 type Release struct {
@@ -16,52 +12,16 @@ type Release struct {
 	Version      string
 	Contributors []string
 	ReleaseTime  time.Time
+	// IsBot is true for the automated releases, which only have bot contributors
+	IsBot bool
 }
 
 func (r Release) String() string {
 	return fmt.Sprintf(`"%s/%s" %q`, r.Owner, r.Repository, r.Version)
 }
 
-type ReleaseStore struct {
-	connPool *pgxpool.Pool
-}
-
-func (s *ReleaseStore) TableName() string {
-	return "releases"
-}
-
-func (s *ReleaseStore) Migrations() []migration.Func {
-	return []migration.Func{
-		migration.ExecSQLFunc(`
-			CREATE TABLE releases (
-				owner VARCHAR NOT NULL,
-				repository VARCHAR NOT NULL,
-				version VARCHAR,
-				contributors VARCHAR[],
-				release_time timestamp without time zone NOT NULL,
-				CONSTRAINT releases_pkey PRIMARY KEY (owner, repository, version)
-			);
-		`),
-	}
-}
-
-func (s *ReleaseStore) Add(ctx context.Context, r Release) error {
-	tx, err := s.connPool.BeginTx(ctx, pgx.TxOptions{
-		AccessMode: pgx.ReadWrite,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to start a new DB transaction: %w", err)
-	}
-	defer tx.Rollback(ctx) // nolint: errcheck
-
-	_, err = tx.Exec(ctx, "INSERT INTO releases (owner, repository, version, contributors, release_time) VALUES ($1, $2, $3, $4, $5) ON CONFLICT DO NOTHING;", r.Owner, r.Repository, r.Version, r.Contributors, r.ReleaseTime)
-	if err != nil {
-		return fmt.Errorf("failed to add release: %w", err)
-	}
-
-	if err = tx.Commit(ctx); err != nil {
-		return fmt.Errorf("failed to commit insertion of release: %w", err)
-	}
-
-	return nil
+// ReleaseStore stores releases.
+// Adding a release which has already been stored is a no-op.
+type ReleaseStore interface {
+	Add(ctx context.Context, r Release) error
 }
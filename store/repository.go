@@ -0,0 +1,63 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Repository is a git repository of the inventory, as imported in Jenkins X - even if it has no activity
+type Repository struct {
+	Provider     string            `json:"provider"`
+	Owner        string            `json:"owner"`
+	Name         string            `json:"name"`
+	URL          string            `json:"url,omitempty"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	CreationTime time.Time         `json:"creationTime"`
+}
+
+func (r Repository) String() string {
+	return fmt.Sprintf("%s/%s", r.Owner, r.Name)
+}
+
+// RepositoryActivity is a repository of the inventory, with the time of its latest indicators
+type RepositoryActivity struct {
+	Repository
+	// LastPullRequestTime is the creation time of the latest pull request
+	LastPullRequestTime *time.Time `json:"lastPullRequestTime,omitempty"`
+	// LastPipelineTime is the start time of the latest pipeline
+	LastPipelineTime   *time.Time `json:"lastPipelineTime,omitempty"`
+	LastReleaseTime    *time.Time `json:"lastReleaseTime,omitempty"`
+	LastDeploymentTime *time.Time `json:"lastDeploymentTime,omitempty"`
+}
+
+// LastActivityTime returns the time of the latest pull request, pipeline, release or deployment - or nil if there is none
+func (a RepositoryActivity) LastActivityTime() *time.Time {
+	var last *time.Time
+	for _, t := range []*time.Time{a.LastPullRequestTime, a.LastPipelineTime, a.LastReleaseTime, a.LastDeploymentTime} {
+		if t != nil && (last == nil || t.After(*last)) {
+			last = t
+		}
+	}
+	return last
+}
+
+// InactiveSince returns true if the repository has no activity since the given time
+func (a RepositoryActivity) InactiveSince(since time.Time) bool {
+	last := a.LastActivityTime()
+	return last == nil || last.Before(since)
+}
+
+// NeverDeployed returns true if the repository has never been deployed
+func (a RepositoryActivity) NeverDeployed() bool {
+	return a.LastDeploymentTime == nil
+}
+
+// RepositoryStore stores the inventory of the repositories
+type RepositoryStore interface {
+	// Add adds the repository, or replaces the repository of the same owner and name
+	Add(ctx context.Context, r Repository) error
+	Delete(ctx context.Context, owner, name string) error
+	// ListActivity returns the repositories of the inventory - optionally of a single owner - with their latest indicators
+	ListActivity(ctx context.Context, owner string) ([]RepositoryActivity, error)
+}
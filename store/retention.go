@@ -0,0 +1,67 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RetentionPolicy defines how long the raw rows of a table are kept
+type RetentionPolicy struct {
+	Table  string
+	MaxAge time.Duration
+	// Archive copies the expired rows to the archive table before deleting them
+	Archive bool
+}
+
+func (p RetentionPolicy) String() string {
+	return fmt.Sprintf("%s older than %s (archive: %v)", p.Table, p.MaxAge, p.Archive)
+}
+
+// Validate checks that the policy targets a table supporting retention, with a positive max age
+func (p RetentionPolicy) Validate() error {
+	if !isRetentionTable(p.Table) {
+		return fmt.Errorf("table %q does not support retention - must be one of %s", p.Table, strings.Join(RetentionTables(), ", "))
+	}
+	if p.MaxAge <= 0 {
+		return fmt.Errorf("invalid max age %s for table %s", p.MaxAge, p.Table)
+	}
+	return nil
+}
+
+// Cutoff returns the time before which rows expire.
+// It is aligned on the start of the (ISO) week, so that the daily and weekly aggregates
+// are always computed from complete periods.
+func (p RetentionPolicy) Cutoff(now time.Time) time.Time {
+	cutoff := now.Add(-p.MaxAge).UTC()
+	cutoff = time.Date(cutoff.Year(), cutoff.Month(), cutoff.Day(), 0, 0, 0, 0, time.UTC)
+	daysSinceMonday := (int(cutoff.Weekday()) + 6) % 7
+	return cutoff.AddDate(0, 0, -daysSinceMonday)
+}
+
+// RetentionResult is the outcome of applying a RetentionPolicy
+type RetentionResult struct {
+	RolledUp int64
+	Archived int64
+	Deleted  int64
+}
+
+// RetentionTables returns the names of the tables which support a retention policy
+func RetentionTables() []string {
+	return []string{"deployment_events", "deployments", "gitops_syncs", "helm_releases", "incidents", "lighthouse_jobs", "pipeline_events", "pipelines", "pipelinesteps", "pull_request_events", "pull_requests", "releases"}
+}
+
+func isRetentionTable(table string) bool {
+	for _, t := range RetentionTables() {
+		if t == table {
+			return true
+		}
+	}
+	return false
+}
+
+// RetentionStore expires old rows
+type RetentionStore interface {
+	Apply(ctx context.Context, policy RetentionPolicy, now time.Time) (RetentionResult, error)
+}
@@ -0,0 +1,262 @@
+package store
+
+import (
+	"sort"
+	"time"
+)
+
+// ReviewerWorkload is the review load of a reviewer, as exported in the "reviewer_workload" dataset
+type ReviewerWorkload struct {
+	Owner    string
+	Reviewer string
+	// PullRequests is the number of reviewed pull requests
+	PullRequests int64
+	// Reviews is the number of submitted reviews, from the pull request events
+	Reviews   int64
+	Approvals int64
+	// ResponseTimeP50 is the median time between a pull request being ready for review and the reviewer's first review
+	ResponseTimeP50 *float64
+}
+
+// Values returns the values of the "reviewer_workload" dataset columns
+func (w ReviewerWorkload) Values() []any {
+	return []any{w.Owner, w.Reviewer, w.PullRequests, w.Reviews, w.Approvals, floatValue(w.ResponseTimeP50)}
+}
+
+// ReviewPair is the number of pull requests of an author reviewed by a reviewer, as exported in the "review_pairs" dataset
+type ReviewPair struct {
+	Owner        string
+	Author       string
+	Reviewer     string
+	PullRequests int64
+}
+
+// Values returns the values of the "review_pairs" dataset columns
+func (p ReviewPair) Values() []any {
+	return []any{p.Owner, p.Author, p.Reviewer, p.PullRequests}
+}
+
+// ReviewBusFactor is the review bus factor of a repository, as exported in the "review_bus_factor" dataset.
+// The bus factor is the smallest number of reviewers - the most active first - doing more than half of the reviews,
+// counting one review per reviewer and pull request: a bus factor of 1 means that a single reviewer does most of the reviews.
+type ReviewBusFactor struct {
+	Owner            string
+	Repository       string
+	Reviews          int64
+	Reviewers        int64
+	BusFactor        int64
+	TopReviewer      string
+	TopReviewerShare float64
+}
+
+// Values returns the values of the "review_bus_factor" dataset columns
+func (b ReviewBusFactor) Values() []any {
+	return []any{b.Owner, b.Repository, b.Reviews, b.Reviewers, b.BusFactor, b.TopReviewer, b.TopReviewerShare}
+}
+
+// ComputeReviewerWorkload computes the review load per reviewer, for the storage backends which can't do it in SQL.
+// The pull requests and events don't need to be filtered beforehand.
+func ComputeReviewerWorkload(filter ExportFilter, pullRequests []PullRequest, events []PullRequestEvent) []ReviewerWorkload {
+	type reviewKey struct {
+		owner, repository string
+		pullRequest       int
+		reviewer          string
+	}
+	type review struct {
+		count     int64
+		firstTime time.Time
+	}
+	reviews := map[reviewKey]*review{}
+	for _, e := range events {
+		if e.Action != "submitted" { // scm.ActionSubmitted
+			continue
+		}
+		key := reviewKey{owner: e.Owner, repository: e.Repository, pullRequest: e.PullRequest, reviewer: e.Reviewer()}
+		if reviews[key] == nil {
+			reviews[key] = &review{firstTime: e.Time}
+		}
+		reviews[key].count++
+		if e.Time.Before(reviews[key].firstTime) {
+			reviews[key].firstTime = e.Time
+		}
+	}
+
+	type reviewerKey struct {
+		owner, reviewer string
+	}
+	type counters struct {
+		pullRequests, reviews, approvals int64
+		responseTimes                    []float64
+	}
+	reviewers := map[reviewerKey]*counters{}
+	for _, pr := range filterPullRequests(filter, pullRequests) {
+		readyTime := pr.ReadyForReviewTime
+		if readyTime == nil {
+			readyTime = pr.CreationTime
+		}
+		for _, reviewer := range pr.Reviewers {
+			key := reviewerKey{owner: pr.Owner, reviewer: reviewer}
+			if reviewers[key] == nil {
+				reviewers[key] = &counters{}
+			}
+			c := reviewers[key]
+			c.pullRequests++
+			if containsString(pr.Approvers, reviewer) {
+				c.approvals++
+			}
+			r := reviews[reviewKey{owner: pr.Owner, repository: pr.Repository, pullRequest: pr.PullRequest, reviewer: reviewer}]
+			if r == nil {
+				continue
+			}
+			c.reviews += r.count
+			if r.firstTime.After(*readyTime) {
+				c.responseTimes = append(c.responseTimes, r.firstTime.Sub(*readyTime).Seconds())
+			}
+		}
+	}
+
+	var workloads []ReviewerWorkload
+	for key, c := range reviewers {
+		w := ReviewerWorkload{
+			Owner:        key.owner,
+			Reviewer:     key.reviewer,
+			PullRequests: c.pullRequests,
+			Reviews:      c.reviews,
+			Approvals:    c.approvals,
+		}
+		if len(c.responseTimes) > 0 {
+			p50 := Percentile(c.responseTimes, 0.5)
+			w.ResponseTimeP50 = &p50
+		}
+		workloads = append(workloads, w)
+	}
+	sort.Slice(workloads, func(i, j int) bool {
+		if workloads[i].Owner != workloads[j].Owner {
+			return workloads[i].Owner < workloads[j].Owner
+		}
+		if workloads[i].PullRequests != workloads[j].PullRequests {
+			return workloads[i].PullRequests > workloads[j].PullRequests
+		}
+		return workloads[i].Reviewer < workloads[j].Reviewer
+	})
+	return workloads
+}
+
+// ComputeReviewPairs computes the number of reviewed pull requests per author and reviewer,
+// for the storage backends which can't do it in SQL. The pull requests don't need to be filtered beforehand.
+func ComputeReviewPairs(filter ExportFilter, pullRequests []PullRequest) []ReviewPair {
+	type pairKey struct {
+		owner, author, reviewer string
+	}
+	counts := map[pairKey]int64{}
+	for _, pr := range filterPullRequests(filter, pullRequests) {
+		for _, reviewer := range pr.Reviewers {
+			counts[pairKey{owner: pr.Owner, author: pr.Author, reviewer: reviewer}]++
+		}
+	}
+
+	var pairs []ReviewPair
+	for key, count := range counts {
+		pairs = append(pairs, ReviewPair{Owner: key.owner, Author: key.author, Reviewer: key.reviewer, PullRequests: count})
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].Owner != pairs[j].Owner {
+			return pairs[i].Owner < pairs[j].Owner
+		}
+		if pairs[i].PullRequests != pairs[j].PullRequests {
+			return pairs[i].PullRequests > pairs[j].PullRequests
+		}
+		if pairs[i].Author != pairs[j].Author {
+			return pairs[i].Author < pairs[j].Author
+		}
+		return pairs[i].Reviewer < pairs[j].Reviewer
+	})
+	return pairs
+}
+
+// ComputeReviewBusFactors computes the review bus factor per repository, the lowest first,
+// for the storage backends which can't do it in SQL. The pull requests don't need to be filtered beforehand.
+func ComputeReviewBusFactors(filter ExportFilter, pullRequests []PullRequest) []ReviewBusFactor {
+	type repository struct {
+		owner, name string
+	}
+	type reviewerCount struct {
+		reviewer string
+		count    int64
+	}
+	counts := map[repository]map[string]int64{}
+	for _, pr := range filterPullRequests(filter, pullRequests) {
+		repo := repository{owner: pr.Owner, name: pr.Repository}
+		for _, reviewer := range pr.Reviewers {
+			if counts[repo] == nil {
+				counts[repo] = map[string]int64{}
+			}
+			counts[repo][reviewer]++
+		}
+	}
+
+	var busFactors []ReviewBusFactor
+	for repo, reviewers := range counts {
+		var (
+			sorted []reviewerCount
+			total  int64
+		)
+		for reviewer, count := range reviewers {
+			sorted = append(sorted, reviewerCount{reviewer: reviewer, count: count})
+			total += count
+		}
+		sort.Slice(sorted, func(i, j int) bool {
+			if sorted[i].count != sorted[j].count {
+				return sorted[i].count > sorted[j].count
+			}
+			return sorted[i].reviewer < sorted[j].reviewer
+		})
+		b := ReviewBusFactor{
+			Owner:            repo.owner,
+			Repository:       repo.name,
+			Reviews:          total,
+			Reviewers:        int64(len(sorted)),
+			TopReviewer:      sorted[0].reviewer,
+			TopReviewerShare: float64(sorted[0].count) / float64(total),
+		}
+		var cumulative int64
+		for _, r := range sorted {
+			b.BusFactor++
+			cumulative += r.count
+			if cumulative*2 > total {
+				break
+			}
+		}
+		busFactors = append(busFactors, b)
+	}
+	sort.Slice(busFactors, func(i, j int) bool {
+		if busFactors[i].BusFactor != busFactors[j].BusFactor {
+			return busFactors[i].BusFactor < busFactors[j].BusFactor
+		}
+		if busFactors[i].Owner != busFactors[j].Owner {
+			return busFactors[i].Owner < busFactors[j].Owner
+		}
+		return busFactors[i].Repository < busFactors[j].Repository
+	})
+	return busFactors
+}
+
+// filterPullRequests returns the pull requests created in the filter's time range, and matching its author filter
+func filterPullRequests(filter ExportFilter, pullRequests []PullRequest) []PullRequest {
+	var filtered []PullRequest
+	for _, pr := range pullRequests {
+		if pr.CreationTime != nil && filter.Matches(pr.Owner, *pr.CreationTime) && filter.MatchesAuthor(pr.IsBot) {
+			filtered = append(filtered, pr)
+		}
+	}
+	return filtered
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/jenkins-x/cd-indicators/store/migration"
+)
+
+type DeploymentStore struct {
+	db       *sql.DB
+	notifier *store.Notifier
+}
+
+func (s *DeploymentStore) TableName() string {
+	return "deployments"
+}
+
+func (s *DeploymentStore) Migrations() []migration.SQLiteFunc {
+	return []migration.SQLiteFunc{
+		migration.ExecSQLiteFunc(`
+			CREATE TABLE deployments (
+				owner TEXT NOT NULL,
+				repository TEXT NOT NULL,
+				version TEXT NOT NULL,
+				environment TEXT NOT NULL,
+				deployment_time TEXT,
+				CONSTRAINT deployments_pkey PRIMARY KEY (owner, repository, version, environment)
+			);
+		`), migration.ExecSQLiteFunc(`
+			CREATE TABLE deployment_events (
+				owner TEXT NOT NULL,
+				repository TEXT NOT NULL,
+				version TEXT NOT NULL,
+				source_environment TEXT NOT NULL,
+				environment TEXT NOT NULL,
+				deployment_time TEXT,
+				CONSTRAINT deployment_events_pkey PRIMARY KEY (owner, repository, version, source_environment)
+			);
+		`),
+	}
+}
+
+func (s *DeploymentStore) Add(ctx context.Context, d store.Deployment) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start a new DB transaction: %w", err)
+	}
+	defer tx.Rollback() // nolint: errcheck
+
+	res, err := tx.ExecContext(ctx, `
+	INSERT INTO deployments (owner, repository, version, environment, deployment_time) 
+	VALUES (?, ?, ?, ?, ?) 
+	ON CONFLICT DO NOTHING;`,
+		d.Owner, d.Repository, d.Version, d.Environment, formatTime(d.DeploymentTime))
+	if err != nil {
+		return fmt.Errorf("failed to add deployment: %w", err)
+	}
+
+	// the environment is stored as found by the collector, and with its alias - which identifies the deployment it has been added to
+	_, err = tx.ExecContext(ctx, `
+	INSERT INTO deployment_events (owner, repository, version, source_environment, environment, deployment_time)
+	VALUES (?, ?, ?, ?, ?, ?)
+	ON CONFLICT DO NOTHING;`,
+		d.Owner, d.Repository, d.Version, d.EventEnvironment(), d.Environment, formatTime(d.DeploymentTime))
+	if err != nil {
+		return fmt.Errorf("failed to add deployment event: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit insertion of deployment: %w", err)
+	}
+
+	if added, _ := res.RowsAffected(); added > 0 {
+		s.notifier.DeploymentAdded(ctx, d)
+	}
+	return nil
+}
@@ -0,0 +1,415 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jenkins-x/cd-indicators/store"
+)
+
+// datasetQueries are the queries of each dataset, returning the dataset columns in order.
+// They receive the owner as ?1 (empty for all owners), the time range as ?2 and ?3,
+// and whether to exclude the automated changes as ?4.
+// The "dora", review and team datasets are computed in Go, because SQLite has no percentile function.
+var datasetQueries = map[string]string{
+	"pipelines": `
+		SELECT type, owner, repository, pull_request, context, build, status, author, start_time, end_time, duration, is_bot
+		FROM pipelines
+		WHERE (?1 = '' OR owner = ?1) AND start_time >= ?2 AND start_time < ?3 AND NOT (?4 AND is_bot)
+		ORDER BY start_time;`,
+	"pipelinesteps": `
+		SELECT type, owner, repository, pull_request, context, build, step_name, step_status, step_started_time, step_completed_time, step_duration, is_bot
+		FROM pipelinesteps
+		WHERE (?1 = '' OR owner = ?1) AND step_started_time >= ?2 AND step_started_time < ?3 AND NOT (?4 AND is_bot)
+		ORDER BY step_started_time;`,
+	"pull_requests": `
+		SELECT owner, repository, pull_request, author, state, reviews, reviewers, creation_time, ready_for_review_time, approved_time, time_to_review, merged_time, time_to_merge, approvers,
+			first_review_time, time_to_first_review, first_comment_time, time_to_first_comment, change_requests, pushes_after_first_review,
+			additions, deletions, changed_files, closed_time, closed_without_merge, is_bot
+		FROM pull_requests
+		WHERE (?1 = '' OR owner = ?1) AND creation_time >= ?2 AND creation_time < ?3 AND NOT (?4 AND is_bot)
+		ORDER BY creation_time;`,
+	"releases": `
+		SELECT owner, repository, version, contributors, release_time, is_bot
+		FROM releases
+		WHERE (?1 = '' OR owner = ?1) AND release_time >= ?2 AND release_time < ?3 AND NOT (?4 AND is_bot)
+		ORDER BY release_time;`,
+	// the deployments of the automated releases are excluded with them
+	"deployments": `
+		SELECT d.owner, d.repository, d.version, d.environment, d.deployment_time
+		FROM deployments d
+		WHERE (?1 = '' OR d.owner = ?1) AND d.deployment_time >= ?2 AND d.deployment_time < ?3
+			AND NOT (?4 AND EXISTS (SELECT 1 FROM releases r WHERE r.owner = d.owner AND r.repository = d.repository AND r.version = d.version AND r.is_bot))
+		ORDER BY d.deployment_time;`,
+	// the revisions of the automated releases are excluded with them, like their deployments
+	"helm_releases": `
+		SELECT h.namespace, h.name, h.revision, h.owner, h.repository, h.environment, h.chart, h.version, h.app_version, h.status, h.description,
+			h.deployment_time, h.is_rollback
+		FROM helm_releases h
+		WHERE (?1 = '' OR h.owner = ?1) AND h.deployment_time >= ?2 AND h.deployment_time < ?3
+			AND NOT (?4 AND EXISTS (SELECT 1 FROM releases r WHERE r.owner = h.owner AND r.repository = h.repository AND r.version = h.version AND r.is_bot))
+		ORDER BY h.deployment_time, h.namespace, h.name, h.revision;`,
+	// the syncs of the automated releases are excluded with them, like their deployments
+	"gitops_syncs": `
+		SELECT s.tool, s.kind, s.namespace, s.name, s.owner, s.repository, s.environment, s.revision, s.version, s.status, s.message, s.sync_time
+		FROM gitops_syncs s
+		WHERE (?1 = '' OR s.owner = ?1) AND s.sync_time >= ?2 AND s.sync_time < ?3
+			AND NOT (?4 AND EXISTS (SELECT 1 FROM releases r WHERE r.owner = s.owner AND r.repository = s.repository AND r.version = s.version AND r.is_bot))
+		ORDER BY s.sync_time, s.tool, s.kind, s.namespace, s.name;`,
+	// the incidents of the automated releases are excluded with them, like their deployments
+	"incidents": `
+		SELECT i.source, i.id, i.owner, i.repository, i.environment, i.version, i.description, i.detection_time, i.resolution_time,
+			CAST(ROUND((julianday(i.resolution_time) - julianday(i.detection_time)) * 86400) AS INTEGER)
+		FROM incidents i
+		WHERE (?1 = '' OR i.owner = ?1) AND i.detection_time >= ?2 AND i.detection_time < ?3
+			AND NOT (?4 AND EXISTS (SELECT 1 FROM releases r WHERE r.owner = i.owner AND r.repository = i.repository AND r.version = i.version AND r.is_bot))
+		ORDER BY i.detection_time, i.source, i.id;`,
+	// the retests are the jobs triggered again for a commit which already had a job of the same context, see store.MarkRetests
+	"lighthouse_jobs": `
+		SELECT j.name, j.type, j.job, j.owner, j.repository, j.pull_request, j.context, j.sha, j.build, j.state, j.author,
+			j.trigger_time, j.start_time, j.end_time, j.queue_duration, j.duration,
+			j.sha != '' AND j.type != 'periodic' AND EXISTS (
+				SELECT 1 FROM lighthouse_jobs p
+				WHERE p.type = j.type AND p.owner = j.owner AND p.repository = j.repository AND p.pull_request = j.pull_request
+					AND p.context = j.context AND p.sha = j.sha AND p.trigger_time < j.trigger_time
+			) AS is_retest,
+			j.is_bot
+		FROM lighthouse_jobs j
+		WHERE (?1 = '' OR j.owner = ?1) AND j.trigger_time >= ?2 AND j.trigger_time < ?3 AND NOT (?4 AND j.is_bot)
+		ORDER BY j.trigger_time;`,
+}
+
+type ExportStore struct {
+	db *sql.DB
+}
+
+func (s *ExportStore) Export(ctx context.Context, name string, filter store.ExportFilter, rowFunc func(values []any) error) error {
+	columns, err := store.DatasetColumns(name)
+	if err != nil {
+		return err
+	}
+
+	switch name {
+	case "dora", "team_dora":
+		return s.exportDORAMetrics(ctx, name, filter, rowFunc)
+	case "team_pull_requests":
+		return s.exportTeamPullRequestMetrics(ctx, filter, rowFunc)
+	case "reviewer_workload", "review_pairs", "review_bus_factor":
+		return s.exportReviewMetrics(ctx, name, filter, rowFunc)
+	}
+
+	// the rows are read before being sent to the - maybe slow - client, as the query holds the only connection
+	// and would block all the writes of the collectors until the end of the download
+	rows, err := s.readDataset(ctx, name, columns, filter)
+	if err != nil {
+		return err
+	}
+	for _, values := range rows {
+		if err = rowFunc(values); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readDataset returns all the rows of a dataset
+func (s *ExportStore) readDataset(ctx context.Context, name string, columns []store.Column, filter store.ExportFilter) ([][]any, error) {
+	rows, err := s.db.QueryContext(ctx, datasetQueries[name], filter.Owner, formatTime(filter.From), formatTime(filter.To), filter.ExcludeBots)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query dataset %s: %w", name, err)
+	}
+	defer rows.Close()
+
+	var dataset [][]any
+	for rows.Next() {
+		values := make([]any, len(columns))
+		dest := make([]any, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err = rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to read row of dataset %s: %w", name, err)
+		}
+		for i, column := range columns {
+			values[i], err = normalizeValue(column, values[i])
+			if err != nil {
+				return nil, fmt.Errorf("failed to read row of dataset %s: %w", name, err)
+			}
+		}
+		dataset = append(dataset, values)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query dataset %s: %w", name, err)
+	}
+	return dataset, nil
+}
+
+func (s *ExportStore) exportDORAMetrics(ctx context.Context, name string, filter store.ExportFilter, rowFunc func(values []any) error) error {
+	var (
+		deployments []store.Deployment
+		releases    []store.Release
+		pipelines   []store.Pipeline
+		incidents   []store.Incident
+	)
+	err := s.query(ctx, "SELECT owner, repository, version, environment, deployment_time FROM deployments WHERE ?1 = '' OR owner = ?1;", filter.Owner, func(rows *sql.Rows) error {
+		var (
+			d              store.Deployment
+			deploymentTime string
+		)
+		if err := rows.Scan(&d.Owner, &d.Repository, &d.Version, &d.Environment, &deploymentTime); err != nil {
+			return err
+		}
+		var err error
+		d.DeploymentTime, err = parseTime(deploymentTime)
+		deployments = append(deployments, d)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to query deployments: %w", err)
+	}
+	err = s.query(ctx, "SELECT owner, repository, version, release_time, is_bot FROM releases WHERE ?1 = '' OR owner = ?1;", filter.Owner, func(rows *sql.Rows) error {
+		var (
+			r           store.Release
+			releaseTime string
+		)
+		if err := rows.Scan(&r.Owner, &r.Repository, &r.Version, &releaseTime, &r.IsBot); err != nil {
+			return err
+		}
+		var err error
+		r.ReleaseTime, err = parseTime(releaseTime)
+		releases = append(releases, r)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to query releases: %w", err)
+	}
+	err = s.query(ctx, "SELECT owner, repository, status, start_time, is_bot FROM pipelines WHERE type = 'release' AND (?1 = '' OR owner = ?1);", filter.Owner, func(rows *sql.Rows) error {
+		var (
+			p         = store.Pipeline{Type: store.PipelineTypeRelease}
+			startTime string
+		)
+		if err := rows.Scan(&p.Owner, &p.Repository, &p.Status, &startTime, &p.IsBot); err != nil {
+			return err
+		}
+		var err error
+		p.StartTime, err = parseTime(startTime)
+		pipelines = append(pipelines, p)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to query pipelines: %w", err)
+	}
+	err = s.query(ctx, "SELECT owner, repository, environment, version, detection_time, resolution_time FROM incidents WHERE ?1 = '' OR owner = ?1;", filter.Owner, func(rows *sql.Rows) error {
+		var (
+			i              store.Incident
+			detectionTime  string
+			resolutionTime sql.NullString
+		)
+		if err := rows.Scan(&i.Owner, &i.Repository, &i.Environment, &i.Version, &detectionTime, &resolutionTime); err != nil {
+			return err
+		}
+		var err error
+		i.DetectionTime, err = parseTime(detectionTime)
+		if err == nil {
+			i.ResolutionTime, err = parseOptionalTime(resolutionTime)
+		}
+		incidents = append(incidents, i)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to query incidents: %w", err)
+	}
+
+	var rows [][]any
+	switch name {
+	case "dora":
+		for _, m := range store.ComputeDORAMetrics(filter, deployments, releases, pipelines, incidents) {
+			rows = append(rows, m.Values())
+		}
+	case "team_dora":
+		teams, err := listTeamRepositories(ctx, s.db)
+		if err != nil {
+			return err
+		}
+		for _, m := range store.ComputeTeamDORAMetrics(filter, teams, deployments, releases, pipelines, incidents) {
+			rows = append(rows, m.Values())
+		}
+	}
+
+	for _, row := range rows {
+		if err = rowFunc(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *ExportStore) exportTeamPullRequestMetrics(ctx context.Context, filter store.ExportFilter, rowFunc func(values []any) error) error {
+	teams, err := listTeamRepositories(ctx, s.db)
+	if err != nil {
+		return err
+	}
+
+	var pullRequests []store.PullRequest
+	err = s.query(ctx, "SELECT owner, repository, creation_time, merged_time, closed_time, COALESCE(time_to_first_review, 0), COALESCE(time_to_review, 0), COALESCE(time_to_merge, 0), is_bot FROM pull_requests WHERE ?1 = '' OR owner = ?1;", filter.Owner, func(rows *sql.Rows) error {
+		var (
+			pr                                           store.PullRequest
+			creationTime, mergedTime, closedTime         sql.NullString
+			timeToFirstReview, timeToReview, timeToMerge int64
+		)
+		err := rows.Scan(&pr.Owner, &pr.Repository, &creationTime, &mergedTime, &closedTime, &timeToFirstReview, &timeToReview, &timeToMerge, &pr.IsBot)
+		if err == nil {
+			pr.CreationTime, err = parseOptionalTime(creationTime)
+		}
+		if err == nil {
+			pr.MergedTime, err = parseOptionalTime(mergedTime)
+		}
+		if err == nil {
+			pr.ClosedTime, err = parseOptionalTime(closedTime)
+		}
+		pr.TimeToFirstReview = time.Duration(timeToFirstReview) * time.Second
+		pr.TimeToReview = time.Duration(timeToReview) * time.Second
+		pr.TimeToMerge = time.Duration(timeToMerge) * time.Second
+		pullRequests = append(pullRequests, pr)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to query pullrequests: %w", err)
+	}
+
+	for _, m := range store.ComputeTeamPullRequestMetrics(filter, teams, pullRequests) {
+		if err = rowFunc(m.Values()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *ExportStore) exportReviewMetrics(ctx context.Context, name string, filter store.ExportFilter, rowFunc func(values []any) error) error {
+	var (
+		pullRequests []store.PullRequest
+		events       []store.PullRequestEvent
+	)
+	err := s.query(ctx, "SELECT owner, repository, pull_request, author, reviewers, approvers, creation_time, ready_for_review_time, is_bot FROM pull_requests WHERE ?1 = '' OR owner = ?1;", filter.Owner, func(rows *sql.Rows) error {
+		var (
+			pr                                            store.PullRequest
+			reviewers, approvers, creationTime, readyTime sql.NullString
+		)
+		err := rows.Scan(&pr.Owner, &pr.Repository, &pr.PullRequest, &pr.Author, &reviewers, &approvers, &creationTime, &readyTime, &pr.IsBot)
+		if err == nil {
+			pr.Reviewers, err = parseStrings(reviewers)
+		}
+		if err == nil {
+			pr.Approvers, err = parseStrings(approvers)
+		}
+		if err == nil {
+			pr.CreationTime, err = parseOptionalTime(creationTime)
+		}
+		if err == nil {
+			pr.ReadyForReviewTime, err = parseOptionalTime(readyTime)
+		}
+		pullRequests = append(pullRequests, pr)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to query pullrequests: %w", err)
+	}
+
+	var rows [][]any
+	switch name {
+	case "reviewer_workload":
+		err = s.query(ctx, "SELECT owner, repository, pull_request, actor, COALESCE(actor_person, ''), event_time FROM pull_request_events WHERE action = 'submitted' AND (?1 = '' OR owner = ?1);", filter.Owner, func(rows *sql.Rows) error {
+			var (
+				e         = store.PullRequestEvent{Action: "submitted"}
+				eventTime string
+			)
+			if err := rows.Scan(&e.Owner, &e.Repository, &e.PullRequest, &e.Actor, &e.ActorPerson, &eventTime); err != nil {
+				return err
+			}
+			var err error
+			e.Time, err = parseTime(eventTime)
+			events = append(events, e)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to query pullrequest events: %w", err)
+		}
+		for _, w := range store.ComputeReviewerWorkload(filter, pullRequests, events) {
+			rows = append(rows, w.Values())
+		}
+	case "review_pairs":
+		for _, p := range store.ComputeReviewPairs(filter, pullRequests) {
+			rows = append(rows, p.Values())
+		}
+	case "review_bus_factor":
+		for _, b := range store.ComputeReviewBusFactors(filter, pullRequests) {
+			rows = append(rows, b.Values())
+		}
+	}
+
+	for _, row := range rows {
+		if err = rowFunc(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *ExportStore) query(ctx context.Context, query string, owner string, rowFunc func(rows *sql.Rows) error) error {
+	rows, err := s.db.QueryContext(ctx, query, owner)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err = rowFunc(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func normalizeValue(column store.Column, value any) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	switch column.Type {
+	case store.ColumnTypeString:
+		if v, ok := value.(string); ok {
+			return v, nil
+		}
+	case store.ColumnTypeInt:
+		switch v := value.(type) {
+		case int64:
+			return v, nil
+		case float64:
+			return int64(v), nil
+		}
+	case store.ColumnTypeFloat:
+		switch v := value.(type) {
+		case int64:
+			return float64(v), nil
+		case float64:
+			return v, nil
+		}
+	case store.ColumnTypeTime:
+		if v, ok := value.(string); ok {
+			return parseTime(v)
+		}
+	case store.ColumnTypeStrings:
+		if v, ok := value.(string); ok {
+			return parseStrings(sql.NullString{String: v, Valid: true})
+		}
+	case store.ColumnTypeBool:
+		if v, ok := value.(int64); ok {
+			return v != 0, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unexpected value %#v for column %s of type %s", value, column.Name, column.Type)
+}
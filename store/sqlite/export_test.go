@@ -0,0 +1,97 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/cd-indicators/store"
+)
+
+func TestExportDoesNotBlockTheWrites(t *testing.T) {
+	ctx := context.Background()
+	s, count := newTestStore(t)
+	started := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	pipeline := store.Pipeline{Type: store.PipelineTypeRelease, Owner: "org", Repository: "app", Context: "release", Build: 1, StartTime: started, EndTime: started.Add(time.Minute)}
+	if err := s.Pipelines.Add(ctx, pipeline); err != nil {
+		t.Fatal(err)
+	}
+
+	// a slow client: the collectors store new pipelines while the export is being sent
+	err := s.Export.Export(ctx, "pipelines", store.ExportFilter{From: started.AddDate(0, 0, -1), To: started.AddDate(0, 0, 1)}, func([]any) error {
+		writeCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		next := pipeline
+		next.Build++
+		return s.Pipelines.Add(writeCtx, next)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := count("SELECT COUNT(*) FROM pipelines;"); n != 2 {
+		t.Errorf("expected the pipeline stored during the export to be kept, got %d pipelines", n)
+	}
+}
+
+func TestReviewerWorkloadMatchesTheAliasesOfTheReviewers(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestStore(t)
+	created := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	person := func(login string) string {
+		if login == "bob-work" {
+			return "bob"
+		}
+		return login
+	}
+
+	events := []store.PullRequestEvent{
+		{Owner: "org", Repository: "app", PullRequest: 1, Action: store.OpenedAction, Actor: "alice", Author: "alice", Time: created, CreationTime: created},
+		// stored before the identity of bob-work was known
+		{Owner: "org", Repository: "app", PullRequest: 1, Action: "submitted", Actor: "bob-work", Author: "alice", Time: created.Add(time.Hour)},
+		{Owner: "org", Repository: "app", PullRequest: 1, Action: "submitted", Actor: "bob-work", ActorPerson: "bob", Author: "alice", Time: created.Add(2 * time.Hour)},
+	}
+	for _, e := range events {
+		if err := s.PullRequestEvents.Add(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err := s.Rebuild.Rebuild(ctx, store.RebuildFilter{}, store.Rebuilder{
+		PullRequest: func(e store.PullRequestEvent) (store.PullRequest, bool) {
+			pr := store.PullRequest{Owner: e.Owner, Repository: e.Repository, PullRequest: e.PullRequest, Author: e.Author}
+			switch e.Action {
+			case store.OpenedAction:
+				creationTime := e.CreationTime
+				pr.CreationTime = &creationTime
+			case "submitted":
+				reviewTime := e.Time
+				pr.Reviews = 1
+				pr.Reviewers = []string{person(e.Actor)}
+				pr.FirstReviewTime = &reviewTime
+			}
+			return pr, true
+		},
+		Person: person,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var rows [][]any
+	err = s.Export.Export(ctx, "reviewer_workload", store.ExportFilter{From: created.AddDate(0, 0, -1), To: created.AddDate(0, 0, 1)}, func(values []any) error {
+		rows = append(rows, values)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("expected 1 reviewer, got %v", rows)
+	}
+	workload := rows[0]
+	if workload[1] != "bob" || workload[2] != int64(1) || workload[3] != int64(2) {
+		t.Errorf("expected bob to have reviewed 1 pull request with 2 reviews, got %v", workload)
+	}
+	if responseTime, ok := workload[5].(float64); !ok || responseTime != time.Hour.Seconds() {
+		t.Errorf("expected a response time of 1 hour, got %v", workload[5])
+	}
+}
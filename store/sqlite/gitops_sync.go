@@ -0,0 +1,59 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/jenkins-x/cd-indicators/store/migration"
+)
+
+type GitOpsSyncStore struct {
+	db *sql.DB
+}
+
+func (s *GitOpsSyncStore) TableName() string {
+	return "gitops_syncs"
+}
+
+func (s *GitOpsSyncStore) Migrations() []migration.SQLiteFunc {
+	return []migration.SQLiteFunc{
+		migration.ExecSQLiteFunc(`
+			CREATE TABLE gitops_syncs (
+				tool TEXT NOT NULL,
+				kind TEXT NOT NULL,
+				namespace TEXT NOT NULL,
+				name TEXT NOT NULL,
+				owner TEXT NOT NULL,
+				repository TEXT NOT NULL,
+				environment TEXT NOT NULL,
+				revision TEXT NOT NULL,
+				version TEXT NOT NULL,
+				status TEXT NOT NULL,
+				message TEXT NOT NULL,
+				sync_time TEXT NOT NULL,
+				CONSTRAINT gitops_syncs_pkey PRIMARY KEY (tool, kind, namespace, name, revision)
+			);
+		`),
+		migration.ExecSQLiteFunc(`
+			CREATE INDEX gitops_syncs_repository_idx ON gitops_syncs (owner, repository, environment);
+		`),
+	}
+}
+
+func (s *GitOpsSyncStore) Add(ctx context.Context, sync store.GitOpsSync) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO gitops_syncs (tool, kind, namespace, name, owner, repository, environment, revision, version, status, message, sync_time)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (tool, kind, namespace, name, revision) DO UPDATE SET
+			status = excluded.status,
+			message = excluded.message,
+			sync_time = excluded.sync_time;
+	`, sync.Tool, sync.Kind, sync.Namespace, sync.Name, sync.Owner, sync.Repository, sync.Environment, sync.Revision, sync.Version, sync.Status, sync.Message,
+		formatTime(sync.SyncTime))
+	if err != nil {
+		return fmt.Errorf("failed to add %s sync of %s %s/%s revision %s: %w", sync.Tool, sync.Kind, sync.Namespace, sync.Name, sync.Revision, err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/jenkins-x/cd-indicators/store/migration"
+)
+
+type HelmReleaseStore struct {
+	db *sql.DB
+}
+
+func (s *HelmReleaseStore) TableName() string {
+	return "helm_releases"
+}
+
+func (s *HelmReleaseStore) Migrations() []migration.SQLiteFunc {
+	return []migration.SQLiteFunc{
+		migration.ExecSQLiteFunc(`
+			CREATE TABLE helm_releases (
+				namespace TEXT NOT NULL,
+				name TEXT NOT NULL,
+				revision INTEGER NOT NULL,
+				owner TEXT NOT NULL,
+				repository TEXT NOT NULL,
+				environment TEXT NOT NULL,
+				chart TEXT NOT NULL,
+				version TEXT NOT NULL,
+				app_version TEXT NOT NULL,
+				status TEXT NOT NULL,
+				description TEXT NOT NULL,
+				deployment_time TEXT NOT NULL,
+				is_rollback INTEGER NOT NULL DEFAULT 0,
+				CONSTRAINT helm_releases_pkey PRIMARY KEY (namespace, name, revision)
+			);
+		`),
+		migration.ExecSQLiteFunc(`
+			CREATE INDEX helm_releases_repository_idx ON helm_releases (owner, repository, environment);
+		`),
+	}
+}
+
+func (s *HelmReleaseStore) Add(ctx context.Context, r store.HelmRelease) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO helm_releases (namespace, name, revision, owner, repository, environment, chart, version, app_version, status, description, deployment_time, is_rollback)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (namespace, name, revision) DO UPDATE SET
+			status = excluded.status,
+			description = excluded.description,
+			deployment_time = excluded.deployment_time;
+	`, r.Namespace, r.Name, r.Revision, r.Owner, r.Repository, r.Environment, r.Chart, r.Version, r.AppVersion, r.Status, r.Description,
+		formatTime(r.DeploymentTime), r.IsRollback)
+	if err != nil {
+		return fmt.Errorf("failed to add helm release %s/%s revision %d: %w", r.Namespace, r.Name, r.Revision, err)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/jenkins-x/cd-indicators/store/migration"
+)
+
+type IdentityStore struct {
+	db *sql.DB
+}
+
+func (s *IdentityStore) TableName() string {
+	return "identities"
+}
+
+func (s *IdentityStore) Migrations() []migration.SQLiteFunc {
+	return []migration.SQLiteFunc{
+		migration.ExecSQLiteFunc(`
+			CREATE TABLE identities (
+				person TEXT NOT NULL,
+				team TEXT,
+				aliases TEXT,
+				CONSTRAINT identities_pkey PRIMARY KEY (person)
+			);
+		`),
+	}
+}
+
+func (s *IdentityStore) List(ctx context.Context) ([]store.Identity, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT person, COALESCE(team, ''), aliases FROM identities ORDER BY person;")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list identities: %w", err)
+	}
+	defer rows.Close()
+
+	var identities []store.Identity
+	for rows.Next() {
+		var (
+			identity store.Identity
+			aliases  sql.NullString
+		)
+		err = rows.Scan(&identity.Person, &identity.Team, &aliases)
+		if err == nil {
+			identity.Aliases, err = parseStrings(aliases)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read identity: %w", err)
+		}
+		identities = append(identities, identity)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list identities: %w", err)
+	}
+	return identities, nil
+}
+
+func (s *IdentityStore) Put(ctx context.Context, identity store.Identity) error {
+	if err := identity.Validate(); err != nil {
+		return err
+	}
+	aliases, err := formatStrings(identity.Aliases)
+	if err != nil {
+		return fmt.Errorf("failed to encode aliases of %s: %w", identity.Person, err)
+	}
+	_, err = s.db.ExecContext(ctx, `
+	INSERT INTO identities (person, team, aliases)
+	VALUES (?, ?, ?)
+	ON CONFLICT (person) DO UPDATE
+	SET team = excluded.team, aliases = excluded.aliases;`,
+		identity.Person, identity.Team, aliases)
+	if err != nil {
+		return fmt.Errorf("failed to put identity of %s: %w", identity.Person, err)
+	}
+	return nil
+}
+
+func (s *IdentityStore) Delete(ctx context.Context, person string) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM identities WHERE person = ?;", person)
+	if err != nil {
+		return fmt.Errorf("failed to delete identity of %s: %w", person, err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/jenkins-x/cd-indicators/store/migration"
+)
+
+type IncidentStore struct {
+	db *sql.DB
+}
+
+func (s *IncidentStore) TableName() string {
+	return "incidents"
+}
+
+func (s *IncidentStore) Migrations() []migration.SQLiteFunc {
+	return []migration.SQLiteFunc{
+		migration.ExecSQLiteFunc(`
+			CREATE TABLE incidents (
+				source TEXT NOT NULL,
+				id TEXT NOT NULL,
+				owner TEXT NOT NULL,
+				repository TEXT NOT NULL,
+				environment TEXT NOT NULL,
+				version TEXT NOT NULL,
+				description TEXT NOT NULL,
+				detection_time TEXT NOT NULL,
+				resolution_time TEXT,
+				CONSTRAINT incidents_pkey PRIMARY KEY (source, id)
+			);
+		`),
+		migration.ExecSQLiteFunc(`
+			CREATE INDEX incidents_repository_idx ON incidents (owner, repository, environment);
+		`),
+	}
+}
+
+func (s *IncidentStore) Add(ctx context.Context, i store.Incident) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO incidents (source, id, owner, repository, environment, version, description, detection_time, resolution_time)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (source, id) DO UPDATE SET
+			owner = COALESCE(NULLIF(excluded.owner, ''), incidents.owner),
+			repository = COALESCE(NULLIF(excluded.repository, ''), incidents.repository),
+			environment = COALESCE(NULLIF(excluded.environment, ''), incidents.environment),
+			version = COALESCE(NULLIF(excluded.version, ''), incidents.version),
+			description = COALESCE(NULLIF(excluded.description, ''), incidents.description),
+			detection_time = MIN(excluded.detection_time, incidents.detection_time),
+			resolution_time = COALESCE(excluded.resolution_time, incidents.resolution_time);
+	`, i.Source, i.ID, i.Owner, i.Repository, i.Environment, i.Version, i.Description,
+		formatTime(i.DetectionTime), formatOptionalTime(i.ResolutionTime))
+	if err != nil {
+		return fmt.Errorf("failed to add incident %s: %w", i, err)
+	}
+	return nil
+}
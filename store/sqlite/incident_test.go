@@ -0,0 +1,74 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/cd-indicators/store"
+)
+
+func TestIncidentsFailTheDeploymentsOfTheirVersion(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestStore(t)
+	deployed := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	detected := deployed.Add(time.Hour)
+	resolved := detected.Add(30 * time.Minute)
+
+	for _, d := range []store.Deployment{
+		{Owner: "org", Repository: "app", Version: "1.0.0", Environment: "production", DeploymentTime: deployed},
+		{Owner: "org", Repository: "app", Version: "1.1.0", Environment: "production", DeploymentTime: deployed.Add(24 * time.Hour)},
+		// the same version, in another environment
+		{Owner: "org", Repository: "app", Version: "1.1.0", Environment: "staging", DeploymentTime: deployed},
+	} {
+		if err := s.Deployments.Add(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, i := range []store.Incident{
+		// the resolution is received before the detection
+		{Source: "/pager", ID: "INC-1", Owner: "org", Repository: "app", Environment: "production", Version: "1.1.0", DetectionTime: resolved, ResolutionTime: &resolved},
+		{Source: "/pager", ID: "INC-1", Owner: "org", Repository: "app", Environment: "production", Version: "1.1.0", Description: "5xx errors", DetectionTime: detected},
+		// not in production
+		{Source: "/pager", ID: "INC-2", Owner: "org", Repository: "app", Environment: "staging", Version: "1.0.0", DetectionTime: detected},
+	} {
+		if err := s.Incidents.Add(ctx, i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	filter := store.ExportFilter{From: deployed.AddDate(0, 0, -1), To: deployed.AddDate(0, 0, 2)}
+	var incidents [][]any
+	err := s.Export.Export(ctx, "incidents", filter, func(values []any) error {
+		incidents = append(incidents, values)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(incidents) != 2 {
+		t.Fatalf("expected 2 incidents, got %v", incidents)
+	}
+	incident := incidents[0]
+	if incident[1] != "INC-1" || incident[6] != "5xx errors" || incident[7] != detected || incident[8] != resolved || incident[9] != int64(30*60) {
+		t.Errorf("expected the incident INC-1 to be merged, detected at %s and restored in 30 minutes, got %v", detected, incident)
+	}
+	if incidents[1][8] != nil || incidents[1][9] != nil {
+		t.Errorf("expected the unresolved incident to have no time to restore, got %v", incidents[1])
+	}
+
+	var dora [][]any
+	err = s.Export.Export(ctx, "dora", filter, func(values []any) error {
+		dora = append(dora, values)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dora) != 1 {
+		t.Fatalf("expected the DORA metrics of 1 repository, got %v", dora)
+	}
+	if metrics := dora[0]; metrics[2] != int64(2) || metrics[8] != 0.5 || metrics[9] != (30*time.Minute).Seconds() {
+		t.Errorf("expected 1 of the 2 production deployments to fail, and to be restored in 30 minutes, got %v", metrics)
+	}
+}
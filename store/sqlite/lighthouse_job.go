@@ -0,0 +1,75 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/jenkins-x/cd-indicators/store/migration"
+)
+
+type LighthouseJobStore struct {
+	db *sql.DB
+}
+
+func (s *LighthouseJobStore) TableName() string {
+	return "lighthouse_jobs"
+}
+
+func (s *LighthouseJobStore) Migrations() []migration.SQLiteFunc {
+	return []migration.SQLiteFunc{
+		migration.ExecSQLiteFunc(`
+			CREATE TABLE lighthouse_jobs (
+				name TEXT NOT NULL,
+				type TEXT NOT NULL,
+				job TEXT NOT NULL,
+				owner TEXT NOT NULL,
+				repository TEXT NOT NULL,
+				pull_request INTEGER NOT NULL,
+				context TEXT NOT NULL,
+				sha TEXT NOT NULL,
+				build INTEGER NOT NULL,
+				state TEXT NOT NULL,
+				author TEXT NOT NULL,
+				trigger_time TEXT NOT NULL,
+				start_time TEXT,
+				end_time TEXT,
+				queue_duration INTEGER,
+				duration INTEGER,
+				is_bot INTEGER NOT NULL DEFAULT 0,
+				CONSTRAINT lighthouse_jobs_pkey PRIMARY KEY (name)
+			);
+		`),
+		migration.ExecSQLiteFunc(`
+			CREATE INDEX lighthouse_jobs_commit_idx ON lighthouse_jobs (owner, repository, context, sha);
+		`),
+	}
+}
+
+func (s *LighthouseJobStore) Add(ctx context.Context, j store.LighthouseJob) error {
+	var queueDuration, duration any
+	if j.StartTime != nil {
+		queueDuration = int64(j.QueueDuration.Seconds())
+	}
+	if j.StartTime != nil && j.EndTime != nil {
+		duration = int64(j.Duration.Seconds())
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO lighthouse_jobs (name, type, job, owner, repository, pull_request, context, sha, build, state, author, trigger_time, start_time, end_time, queue_duration, duration, is_bot)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (name) DO UPDATE SET
+			build = excluded.build,
+			state = excluded.state,
+			start_time = excluded.start_time,
+			end_time = excluded.end_time,
+			queue_duration = excluded.queue_duration,
+			duration = excluded.duration;
+	`, j.Name, j.Type, j.Job, j.Owner, j.Repository, j.PullRequest, j.Context, j.SHA, j.Build, j.State, j.Author,
+		formatTime(j.TriggerTime), formatOptionalTime(j.StartTime), formatOptionalTime(j.EndTime), queueDuration, duration, j.IsBot)
+	if err != nil {
+		return fmt.Errorf("failed to add lighthouse job %s: %w", j.Name, err)
+	}
+	return nil
+}
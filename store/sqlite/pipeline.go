@@ -0,0 +1,111 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/jenkins-x/cd-indicators/store/migration"
+)
+
+type PipelineStore struct {
+	db       *sql.DB
+	notifier *store.Notifier
+}
+
+func (s *PipelineStore) TableName() string {
+	return "pipelines"
+}
+
+func (s *PipelineStore) Migrations() []migration.SQLiteFunc {
+	return []migration.SQLiteFunc{
+		migration.ExecSQLiteFunc(`
+			CREATE TABLE pipelines (
+				type TEXT NOT NULL,
+				owner TEXT NOT NULL,
+				repository TEXT NOT NULL,
+				pull_request INTEGER,
+				context TEXT NOT NULL,
+				build INTEGER NOT NULL,
+				status TEXT NOT NULL,
+				author TEXT,
+				start_time TEXT NOT NULL,
+				end_time TEXT NOT NULL,
+				duration INTEGER NOT NULL,
+				CONSTRAINT pipeline_pkey PRIMARY KEY (type, owner, repository, pull_request, context, build)
+			);
+		`), migration.ExecSQLiteFunc(`
+			CREATE TABLE pipelinesteps (
+				type TEXT NOT NULL,
+				owner TEXT NOT NULL,
+				repository TEXT NOT NULL,
+				pull_request INTEGER,
+				context TEXT NOT NULL,
+				build INTEGER NOT NULL,
+				step_name TEXT NOT NULL,
+				step_status TEXT NOT NULL,
+				step_started_time TEXT NOT NULL,
+				step_completed_time TEXT NOT NULL,
+				step_duration INTEGER NOT NULL,
+				CONSTRAINT pipelinesteps_pkey PRIMARY KEY (type, owner, repository, pull_request, context, build, step_name)
+			);
+		`), migration.ExecSQLiteFunc(`
+			ALTER TABLE pipelines ADD COLUMN is_bot INTEGER NOT NULL DEFAULT 0;
+			ALTER TABLE pipelinesteps ADD COLUMN is_bot INTEGER NOT NULL DEFAULT 0;
+		`), migration.ExecSQLiteFunc(`
+			CREATE TABLE pipeline_events (
+				type TEXT NOT NULL,
+				owner TEXT NOT NULL,
+				repository TEXT NOT NULL,
+				pull_request INTEGER,
+				context TEXT NOT NULL,
+				build INTEGER NOT NULL,
+				status TEXT NOT NULL,
+				author_login TEXT,
+				start_time TEXT NOT NULL,
+				end_time TEXT NOT NULL,
+				steps TEXT NOT NULL,
+				CONSTRAINT pipeline_events_pkey PRIMARY KEY (type, owner, repository, pull_request, context, build)
+			);
+		`),
+	}
+}
+
+func (s *PipelineStore) Add(ctx context.Context, p store.Pipeline) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start a new DB transaction: %w", err)
+	}
+	defer tx.Rollback() // nolint: errcheck
+
+	res, err := tx.ExecContext(ctx, "INSERT INTO pipelines (type, owner, repository, pull_request, context, build, status, author, start_time, end_time, duration, is_bot) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT DO NOTHING;", p.Type, p.Owner, p.Repository, p.PullRequest, p.Context, p.Build, p.Status, p.Author, formatTime(p.StartTime), formatTime(p.EndTime), formatDuration(p.Duration), p.IsBot)
+	if err != nil {
+		return fmt.Errorf("failed to add pipeline: %w", err)
+	}
+
+	steps, err := json.Marshal(p.Steps)
+	if err != nil {
+		return fmt.Errorf("failed to encode the steps of pipeline: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, "INSERT INTO pipeline_events (type, owner, repository, pull_request, context, build, status, author_login, start_time, end_time, steps) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT DO NOTHING;", p.Type, p.Owner, p.Repository, p.PullRequest, p.Context, p.Build, p.Status, p.EventAuthor(), formatTime(p.StartTime), formatTime(p.EndTime), string(steps))
+	if err != nil {
+		return fmt.Errorf("failed to add pipeline event: %w", err)
+	}
+
+	for _, step := range p.Steps {
+		_, err = tx.ExecContext(ctx, "INSERT INTO pipelinesteps (type, owner, repository, pull_request, context, build, step_name, step_status, step_started_time, step_completed_time, step_duration, is_bot) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT DO NOTHING;", p.Type, p.Owner, p.Repository, p.PullRequest, p.Context, p.Build, step.Name, step.Status, formatTime(step.StartedTimestamp), formatTime(step.CompletedTimestamp), formatDuration(step.Duration), p.IsBot)
+		if err != nil {
+			return fmt.Errorf("failed to add pipeline step: %w", err)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit insertion of pipeline: %w", err)
+	}
+
+	if added, _ := res.RowsAffected(); added > 0 {
+		s.notifier.PipelineAdded(ctx, p)
+	}
+	return nil
+}
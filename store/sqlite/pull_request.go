@@ -0,0 +1,170 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/jenkins-x/cd-indicators/store/migration"
+)
+
+type PullRequestStore struct {
+	db       *sql.DB
+	notifier *store.Notifier
+}
+
+func (s *PullRequestStore) TableName() string {
+	return "pull_requests"
+}
+
+func (s *PullRequestStore) Migrations() []migration.SQLiteFunc {
+	return []migration.SQLiteFunc{
+		migration.ExecSQLiteFunc(`
+			CREATE TABLE pull_requests (
+				owner TEXT NOT NULL,
+				repository TEXT NOT NULL,
+				pull_request INTEGER NOT NULL,
+				author TEXT,
+				state TEXT,
+				reviews INTEGER,
+				reviewers TEXT,
+				creation_time TEXT,
+				ready_for_review_time TEXT,
+				approved_time TEXT,
+				time_to_review INTEGER,
+				merged_time TEXT,
+				time_to_merge INTEGER,
+				CONSTRAINT pull_requests_pkey PRIMARY KEY (owner, repository, pull_request)
+			);
+		`),
+		migration.ExecSQLiteFunc(`
+			ALTER TABLE pull_requests ADD COLUMN approvers TEXT;
+		`),
+		migration.ExecSQLiteFunc(`
+			ALTER TABLE pull_requests ADD COLUMN first_review_time TEXT;
+			ALTER TABLE pull_requests ADD COLUMN time_to_first_review INTEGER;
+			ALTER TABLE pull_requests ADD COLUMN first_comment_time TEXT;
+			ALTER TABLE pull_requests ADD COLUMN time_to_first_comment INTEGER;
+			ALTER TABLE pull_requests ADD COLUMN change_requests INTEGER;
+			ALTER TABLE pull_requests ADD COLUMN pushes_after_first_review INTEGER;
+			ALTER TABLE pull_requests ADD COLUMN additions INTEGER;
+			ALTER TABLE pull_requests ADD COLUMN deletions INTEGER;
+			ALTER TABLE pull_requests ADD COLUMN changed_files INTEGER;
+			ALTER TABLE pull_requests ADD COLUMN closed_time TEXT;
+			ALTER TABLE pull_requests ADD COLUMN closed_without_merge INTEGER;
+		`),
+		migration.ExecSQLiteFunc(`
+			ALTER TABLE pull_requests ADD COLUMN is_bot INTEGER NOT NULL DEFAULT 0;
+		`),
+	}
+}
+
+func (s *PullRequestStore) Add(ctx context.Context, pr store.PullRequest) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start a new DB transaction: %w", err)
+	}
+	defer tx.Rollback() // nolint: errcheck
+
+	merged, newlyMerged, err := addPullRequest(ctx, tx, pr)
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit insertion of pullrequest: %w", err)
+	}
+
+	if newlyMerged {
+		s.notifier.PullRequestMerged(ctx, merged)
+	}
+	return nil
+}
+
+// addPullRequest merges the pull request with the stored one, within the given transaction.
+// It returns the merged pull request, and whether it has been merged by this addition
+func addPullRequest(ctx context.Context, tx *sql.Tx, pr store.PullRequest) (store.PullRequest, bool, error) {
+	var (
+		prFromDB                                                   store.PullRequest
+		creationTime, readyForReviewTime, approvedTime, mergedTime sql.NullString
+		firstReviewTime, firstCommentTime, closedTime              sql.NullString
+		reviewers, approvers                                       sql.NullString
+	)
+	err := tx.QueryRowContext(ctx, `
+	SELECT creation_time, ready_for_review_time, approved_time, merged_time, COALESCE(reviews, 0), reviewers, approvers,
+		first_review_time, first_comment_time, COALESCE(change_requests, 0), COALESCE(pushes_after_first_review, 0),
+		COALESCE(additions, 0), COALESCE(deletions, 0), COALESCE(changed_files, 0), closed_time
+	FROM pull_requests WHERE owner=? AND repository=? AND pull_request=?`, pr.Owner, pr.Repository, pr.PullRequest).Scan(
+		&creationTime,
+		&readyForReviewTime,
+		&approvedTime,
+		&mergedTime,
+		&prFromDB.Reviews,
+		&reviewers,
+		&approvers,
+		&firstReviewTime,
+		&firstCommentTime,
+		&prFromDB.ChangeRequests,
+		&prFromDB.PushesAfterFirstReview,
+		&prFromDB.Additions,
+		&prFromDB.Deletions,
+		&prFromDB.ChangedFiles,
+		&closedTime,
+	)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return pr, false, fmt.Errorf("failed to retrieve current pullrequest %s: %w", pr, err)
+	}
+	if prFromDB.Reviewers, err = parseStrings(reviewers); err != nil {
+		return pr, false, fmt.Errorf("failed to decode reviewers of pullrequest %s: %w", pr, err)
+	}
+	if prFromDB.Approvers, err = parseStrings(approvers); err != nil {
+		return pr, false, fmt.Errorf("failed to decode approvers of pullrequest %s: %w", pr, err)
+	}
+	for _, t := range []struct {
+		value sql.NullString
+		dest  **time.Time
+	}{
+		{value: creationTime, dest: &prFromDB.CreationTime},
+		{value: readyForReviewTime, dest: &prFromDB.ReadyForReviewTime},
+		{value: approvedTime, dest: &prFromDB.ApprovedTime},
+		{value: mergedTime, dest: &prFromDB.MergedTime},
+		{value: firstReviewTime, dest: &prFromDB.FirstReviewTime},
+		{value: firstCommentTime, dest: &prFromDB.FirstCommentTime},
+		{value: closedTime, dest: &prFromDB.ClosedTime},
+	} {
+		if *t.dest, err = parseOptionalTime(t.value); err != nil {
+			return pr, false, fmt.Errorf("failed to decode time of pullrequest %s: %w", pr, err)
+		}
+	}
+	// always merge, even with an empty pull request, see PullRequest.MergeWith
+	pr.MergeWith(prFromDB)
+	pr.ApplyApprovalRule()
+	pr.CalculateDurations()
+
+	encodedReviewers, err := formatStrings(pr.Reviewers)
+	if err != nil {
+		return pr, false, fmt.Errorf("failed to encode reviewers of pullrequest %s: %w", pr, err)
+	}
+	encodedApprovers, err := formatStrings(pr.Approvers)
+	if err != nil {
+		return pr, false, fmt.Errorf("failed to encode approvers of pullrequest %s: %w", pr, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	INSERT INTO pull_requests (owner, repository, pull_request, author, state, creation_time, ready_for_review_time, approved_time, time_to_review, merged_time, time_to_merge, reviews, reviewers, approvers,
+		first_review_time, time_to_first_review, first_comment_time, time_to_first_comment, change_requests, pushes_after_first_review, additions, deletions, changed_files, closed_time, closed_without_merge, is_bot) 
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) 
+	ON CONFLICT (owner, repository, pull_request) DO UPDATE 
+	SET state = excluded.state, creation_time = excluded.creation_time, ready_for_review_time = excluded.ready_for_review_time, approved_time = excluded.approved_time, time_to_review = excluded.time_to_review, merged_time = excluded.merged_time, time_to_merge = excluded.time_to_merge, reviews = excluded.reviews, reviewers = excluded.reviewers, approvers = excluded.approvers,
+		first_review_time = excluded.first_review_time, time_to_first_review = excluded.time_to_first_review, first_comment_time = excluded.first_comment_time, time_to_first_comment = excluded.time_to_first_comment, change_requests = excluded.change_requests, pushes_after_first_review = excluded.pushes_after_first_review,
+		additions = excluded.additions, deletions = excluded.deletions, changed_files = excluded.changed_files, closed_time = excluded.closed_time, closed_without_merge = excluded.closed_without_merge, is_bot = excluded.is_bot;`,
+		pr.Owner, pr.Repository, pr.PullRequest, pr.Author, pr.State, formatOptionalTime(pr.CreationTime), formatOptionalTime(pr.ReadyForReviewTime), formatOptionalTime(pr.ApprovedTime), formatDuration(pr.TimeToReview), formatOptionalTime(pr.MergedTime), formatDuration(pr.TimeToMerge), pr.Reviews, encodedReviewers, encodedApprovers,
+		formatOptionalTime(pr.FirstReviewTime), formatDuration(pr.TimeToFirstReview), formatOptionalTime(pr.FirstCommentTime), formatDuration(pr.TimeToFirstComment), pr.ChangeRequests, pr.PushesAfterFirstReview, pr.Additions, pr.Deletions, pr.ChangedFiles, formatOptionalTime(pr.ClosedTime), pr.ClosedWithoutMerge(), pr.IsBot)
+	if err != nil {
+		return pr, false, fmt.Errorf("failed to add pullrequest: %w", err)
+	}
+	return pr, prFromDB.MergedTime == nil && pr.MergedTime != nil, nil
+}
@@ -0,0 +1,130 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/jenkins-x/cd-indicators/store/migration"
+)
+
+type PullRequestEventStore struct {
+	db *sql.DB
+}
+
+func (s *PullRequestEventStore) TableName() string {
+	return "pull_request_events"
+}
+
+func (s *PullRequestEventStore) Migrations() []migration.SQLiteFunc {
+	return []migration.SQLiteFunc{
+		migration.ExecSQLiteFunc(`
+			CREATE TABLE pull_request_events (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				owner TEXT NOT NULL,
+				repository TEXT NOT NULL,
+				pull_request INTEGER NOT NULL,
+				action TEXT NOT NULL,
+				actor TEXT,
+				event_time TEXT NOT NULL,
+				author TEXT,
+				state TEXT,
+				draft INTEGER NOT NULL DEFAULT 0,
+				merged INTEGER NOT NULL DEFAULT 0,
+				creation_time TEXT,
+				label TEXT,
+				review_state TEXT,
+				additions INTEGER NOT NULL DEFAULT 0,
+				deletions INTEGER NOT NULL DEFAULT 0,
+				changed_files INTEGER NOT NULL DEFAULT 0
+			);
+		`),
+		migration.ExecSQLiteFunc(`
+			CREATE INDEX pull_request_events_pull_request_idx ON pull_request_events (owner, repository, pull_request);
+		`),
+		migration.ExecSQLiteFunc(`
+			ALTER TABLE pull_request_events ADD COLUMN actor_person TEXT;
+		`),
+		migration.ExecSQLiteFunc(`
+			ALTER TABLE pull_request_events ADD COLUMN changed_paths TEXT;
+		`),
+	}
+}
+
+func (s *PullRequestEventStore) Add(ctx context.Context, e store.PullRequestEvent) error {
+	var changedPaths any
+	if len(e.ChangedPaths) > 0 {
+		encoded, err := formatStrings(e.ChangedPaths)
+		if err != nil {
+			return fmt.Errorf("failed to encode changed paths of pullrequest event %s: %w", e, err)
+		}
+		changedPaths = encoded
+	}
+	_, err := s.db.ExecContext(ctx, `
+	INSERT INTO pull_request_events (owner, repository, pull_request, action, actor, event_time, author, state, draft, merged, creation_time, label, review_state, additions, deletions, changed_files, actor_person, changed_paths)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''), ?);`,
+		e.Owner, e.Repository, e.PullRequest, e.Action, e.Actor, formatTime(e.Time), e.Author, e.State, e.Draft, e.Merged, formatTime(e.CreationTime), e.Label, e.ReviewState, e.Additions, e.Deletions, e.ChangedFiles, e.ActorPerson, changedPaths)
+	if err != nil {
+		return fmt.Errorf("failed to add pullrequest event %s: %w", e, err)
+	}
+	return nil
+}
+
+func (s *PullRequestEventStore) List(ctx context.Context, filter store.PullRequestEventFilter, eventFunc func(store.PullRequestEvent) error) error {
+	// read all the events before calling eventFunc, which may use the single DB connection
+	events, err := listPullRequestEvents(ctx, s.db, filter)
+	if err != nil {
+		return err
+	}
+	for _, e := range events {
+		if err = eventFunc(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// listPullRequestEvents reads all the matching events, in the order in which they have been added
+func listPullRequestEvents(ctx context.Context, db queryer, filter store.PullRequestEventFilter) ([]store.PullRequestEvent, error) {
+	rows, err := db.QueryContext(ctx, `
+	SELECT owner, repository, pull_request, action, COALESCE(actor, ''), event_time, COALESCE(author, ''), COALESCE(state, ''), draft, merged, creation_time, COALESCE(label, ''), COALESCE(review_state, ''), additions, deletions, changed_files, COALESCE(actor_person, ''), changed_paths
+	FROM pull_request_events
+	WHERE (?1 = '' OR owner = ?1) AND (?2 = '' OR repository = ?2)
+	ORDER BY id;`, filter.Owner, filter.Repository)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pullrequest events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []store.PullRequestEvent
+	for rows.Next() {
+		var (
+			e                       store.PullRequestEvent
+			eventTime, creationTime string
+			changedPaths            sql.NullString
+		)
+		err = rows.Scan(&e.Owner, &e.Repository, &e.PullRequest, &e.Action, &e.Actor, &eventTime, &e.Author, &e.State, &e.Draft, &e.Merged, &creationTime, &e.Label, &e.ReviewState, &e.Additions, &e.Deletions, &e.ChangedFiles, &e.ActorPerson, &changedPaths)
+		if err == nil {
+			e.Time, err = parseTime(eventTime)
+		}
+		if err == nil {
+			e.ChangedPaths, err = parseStrings(changedPaths)
+		}
+		if err == nil {
+			e.CreationTime, err = parseTime(creationTime)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read pullrequest event: %w", err)
+		}
+		events = append(events, e)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list pullrequest events: %w", err)
+	}
+	return events, nil
+}
@@ -0,0 +1,223 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jenkins-x/cd-indicators/store"
+)
+
+type RebuildStore struct {
+	db *sql.DB
+}
+
+func (s *RebuildStore) Rebuild(ctx context.Context, filter store.RebuildFilter, rebuilder store.Rebuilder) (store.RebuildResult, error) {
+	var result store.RebuildResult
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return result, fmt.Errorf("failed to start a new DB transaction: %w", err)
+	}
+	defer tx.Rollback() // nolint: errcheck
+
+	if err = rebuildPullRequests(ctx, tx, filter, rebuilder, &result); err != nil {
+		return result, err
+	}
+	if err = rebuildPipelines(ctx, tx, filter, rebuilder, &result); err != nil {
+		return result, err
+	}
+	if err = rebuildDeployments(ctx, tx, filter, rebuilder, &result); err != nil {
+		return result, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return result, fmt.Errorf("failed to commit the rebuild: %w", err)
+	}
+	return result, nil
+}
+
+func rebuildPullRequests(ctx context.Context, tx *sql.Tx, filter store.RebuildFilter, rebuilder store.Rebuilder, result *store.RebuildResult) error {
+	events, err := listPullRequestEvents(ctx, tx, store.PullRequestEventFilter(filter))
+	if err != nil {
+		return err
+	}
+	if err = rebuildActorPersons(ctx, tx, filter, rebuilder, events); err != nil {
+		return err
+	}
+	events, result.IncompletePullRequests = store.CompleteEventHistories(events)
+	result.PullRequestEvents = len(events)
+
+	// the pull requests without a complete event history are kept as-is: their events would only rebuild a part of them
+	_, err = tx.ExecContext(ctx, `
+	DELETE FROM pull_requests
+	WHERE (?1 = '' OR owner = ?1) AND (?2 = '' OR repository = ?2)
+		AND EXISTS (SELECT 1 FROM pull_request_events e WHERE e.owner = pull_requests.owner AND e.repository = pull_requests.repository AND e.pull_request = pull_requests.pull_request AND e.action = ?3);`, filter.Owner, filter.Repository, store.OpenedAction)
+	if err != nil {
+		return fmt.Errorf("failed to delete pullrequests: %w", err)
+	}
+
+	type pullRequestKey struct {
+		owner, repository string
+		pullRequest       int
+	}
+	rebuilt := map[pullRequestKey]struct{}{}
+	for _, e := range events {
+		pr, ok := rebuilder.PullRequest(e)
+		if !ok {
+			continue
+		}
+		if _, _, err = addPullRequest(ctx, tx, pr); err != nil {
+			return err
+		}
+		rebuilt[pullRequestKey{owner: pr.Owner, repository: pr.Repository, pullRequest: pr.PullRequest}] = struct{}{}
+	}
+	result.PullRequests = len(rebuilt)
+	return nil
+}
+
+// rebuildActorPersons stores the current canonical person of the actors on the events
+func rebuildActorPersons(ctx context.Context, tx *sql.Tx, filter store.RebuildFilter, rebuilder store.Rebuilder, events []store.PullRequestEvent) error {
+	actors := map[string]struct{}{}
+	for _, e := range events {
+		actors[e.Actor] = struct{}{}
+	}
+	for actor := range actors {
+		_, err := tx.ExecContext(ctx, `
+		UPDATE pull_request_events SET actor_person = ?4
+		WHERE (?1 = '' OR owner = ?1) AND (?2 = '' OR repository = ?2) AND actor = ?3 AND actor_person IS NOT ?4;`,
+			filter.Owner, filter.Repository, actor, rebuilder.Person(actor))
+		if err != nil {
+			return fmt.Errorf("failed to update the pullrequest events of %q: %w", actor, err)
+		}
+	}
+	return nil
+}
+
+// rebuildPipelines updates the stored pipelines and their steps from their events
+func rebuildPipelines(ctx context.Context, tx *sql.Tx, filter store.RebuildFilter, rebuilder store.Rebuilder, result *store.RebuildResult) error {
+	rows, err := tx.QueryContext(ctx, `
+	SELECT e.type, e.owner, e.repository, e.pull_request, e.context, e.build, e.status, COALESCE(e.author_login, ''), e.start_time, e.end_time, e.steps
+	FROM pipeline_events e
+	WHERE (?1 = '' OR e.owner = ?1) AND (?2 = '' OR e.repository = ?2)
+		AND EXISTS (SELECT 1 FROM pipelines p WHERE p.type = e.type AND p.owner = e.owner AND p.repository = e.repository AND p.pull_request = e.pull_request AND p.context = e.context AND p.build = e.build);`, filter.Owner, filter.Repository)
+	if err != nil {
+		return fmt.Errorf("failed to list pipeline events: %w", err)
+	}
+	// read all the events before writing, which uses the same connection
+	var events []store.Pipeline
+	for rows.Next() {
+		var (
+			p                  store.Pipeline
+			startTime, endTime string
+			steps              string
+		)
+		err = rows.Scan(&p.Type, &p.Owner, &p.Repository, &p.PullRequest, &p.Context, &p.Build, &p.Status, &p.AuthorLogin, &startTime, &endTime, &steps)
+		if err == nil {
+			p.StartTime, err = parseTime(startTime)
+		}
+		if err == nil {
+			p.EndTime, err = parseTime(endTime)
+		}
+		if err == nil {
+			err = json.Unmarshal([]byte(steps), &p.Steps)
+		}
+		if err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read pipeline event: %w", err)
+		}
+		events = append(events, p)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("failed to list pipeline events: %w", err)
+	}
+
+	for _, e := range events {
+		p := rebuilder.Pipeline(e)
+		_, err = tx.ExecContext(ctx, `
+		UPDATE pipelines SET status = ?7, author = ?8, start_time = ?9, end_time = ?10, duration = ?11, is_bot = ?12
+		WHERE type = ?1 AND owner = ?2 AND repository = ?3 AND pull_request = ?4 AND context = ?5 AND build = ?6;`,
+			p.Type, p.Owner, p.Repository, p.PullRequest, p.Context, p.Build, p.Status, p.Author, formatTime(p.StartTime), formatTime(p.EndTime), formatDuration(p.Duration), p.IsBot)
+		if err != nil {
+			return fmt.Errorf("failed to update pipeline: %w", err)
+		}
+		for _, step := range p.Steps {
+			_, err = tx.ExecContext(ctx, `
+			UPDATE pipelinesteps SET step_status = ?8, step_started_time = ?9, step_completed_time = ?10, step_duration = ?11, is_bot = ?12
+			WHERE type = ?1 AND owner = ?2 AND repository = ?3 AND pull_request = ?4 AND context = ?5 AND build = ?6 AND step_name = ?7;`,
+				p.Type, p.Owner, p.Repository, p.PullRequest, p.Context, p.Build, step.Name, step.Status, formatTime(step.StartedTimestamp), formatTime(step.CompletedTimestamp), formatDuration(step.Duration), p.IsBot)
+			if err != nil {
+				return fmt.Errorf("failed to update pipeline step: %w", err)
+			}
+		}
+	}
+	result.Pipelines = len(events)
+	return nil
+}
+
+// rebuildDeployments replaces the stored deployments by the ones rebuilt from their events, whose environment may have changed.
+// The deployments of a version merged into the same environment keep the earliest deployment time.
+func rebuildDeployments(ctx context.Context, tx *sql.Tx, filter store.RebuildFilter, rebuilder store.Rebuilder, result *store.RebuildResult) error {
+	rows, err := tx.QueryContext(ctx, `
+	SELECT e.owner, e.repository, e.version, e.source_environment, e.environment, e.deployment_time
+	FROM deployment_events e
+	WHERE (?1 = '' OR e.owner = ?1) AND (?2 = '' OR e.repository = ?2)
+		AND EXISTS (SELECT 1 FROM deployments d WHERE d.owner = e.owner AND d.repository = e.repository AND d.version = e.version AND d.environment = e.environment);`, filter.Owner, filter.Repository)
+	if err != nil {
+		return fmt.Errorf("failed to list deployment events: %w", err)
+	}
+	// read all the events before writing, which uses the same connection
+	var events []store.Deployment
+	for rows.Next() {
+		var (
+			d              store.Deployment
+			deploymentTime string
+		)
+		err = rows.Scan(&d.Owner, &d.Repository, &d.Version, &d.SourceEnvironment, &d.Environment, &deploymentTime)
+		if err == nil {
+			d.DeploymentTime, err = parseTime(deploymentTime)
+		}
+		if err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read deployment event: %w", err)
+		}
+		events = append(events, d)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("failed to list deployment events: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	DELETE FROM deployments
+	WHERE (?1 = '' OR owner = ?1) AND (?2 = '' OR repository = ?2)
+		AND EXISTS (SELECT 1 FROM deployment_events e WHERE e.owner = deployments.owner AND e.repository = deployments.repository AND e.version = deployments.version AND e.environment = deployments.environment);`, filter.Owner, filter.Repository)
+	if err != nil {
+		return fmt.Errorf("failed to delete deployments: %w", err)
+	}
+
+	rebuilt := map[string]struct{}{}
+	for _, e := range events {
+		d := rebuilder.Deployment(e)
+		// the event keeps the environment of its deployment, so that the next rebuild finds it
+		_, err = tx.ExecContext(ctx, `
+		UPDATE deployment_events SET environment = ?5
+		WHERE owner = ?1 AND repository = ?2 AND version = ?3 AND source_environment = ?4;`,
+			e.Owner, e.Repository, e.Version, e.SourceEnvironment, d.Environment)
+		if err != nil {
+			return fmt.Errorf("failed to update deployment event: %w", err)
+		}
+		_, err = tx.ExecContext(ctx, `
+		INSERT INTO deployments (owner, repository, version, environment, deployment_time)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (owner, repository, version, environment) DO UPDATE SET deployment_time = MIN(deployments.deployment_time, excluded.deployment_time);`,
+			d.Owner, d.Repository, d.Version, d.Environment, formatTime(d.DeploymentTime))
+		if err != nil {
+			return fmt.Errorf("failed to add deployment: %w", err)
+		}
+		rebuilt[d.String()] = struct{}{}
+	}
+	result.Deployments = len(rebuilt)
+	return nil
+}
@@ -0,0 +1,138 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/cd-indicators/store"
+)
+
+func TestRebuildKeepsThePullRequestsWithAnIncompleteEventHistory(t *testing.T) {
+	ctx := context.Background()
+	s, count := newTestStore(t)
+	created := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	// collected before the events were stored: only its last review has an event
+	partial := store.PullRequest{Owner: "org", Repository: "app", PullRequest: 1, Author: "alice", CreationTime: &created, Reviews: 2, Reviewers: []string{"bob", "carol"}}
+	if err := s.PullRequests.Add(ctx, partial); err != nil {
+		t.Fatal(err)
+	}
+	events := []store.PullRequestEvent{
+		{Owner: "org", Repository: "app", PullRequest: 1, Action: "submitted", Actor: "dave", Author: "alice", Time: created.Add(time.Hour)},
+		{Owner: "org", Repository: "app", PullRequest: 2, Action: store.OpenedAction, Actor: "alice", Author: "alice", Time: created, CreationTime: created},
+		{Owner: "org", Repository: "app", PullRequest: 2, Action: "submitted", Actor: "bob", Author: "alice", Time: created.Add(time.Hour)},
+	}
+	for _, e := range events {
+		if err := s.PullRequestEvents.Add(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the summary of the complete pull request is wrong, and fixed by the rebuild
+	if err := s.PullRequests.Add(ctx, store.PullRequest{Owner: "org", Repository: "app", PullRequest: 2, Author: "alice", Reviews: 5}); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := s.Rebuild.Rebuild(ctx, store.RebuildFilter{}, store.Rebuilder{
+		PullRequest: func(e store.PullRequestEvent) (store.PullRequest, bool) {
+			pr := store.PullRequest{Owner: e.Owner, Repository: e.Repository, PullRequest: e.PullRequest, Author: e.Author}
+			switch e.Action {
+			case store.OpenedAction:
+				creationTime := e.CreationTime
+				pr.CreationTime = &creationTime
+			case "submitted":
+				pr.Reviews = 1
+				pr.Reviewers = []string{e.Actor}
+			}
+			return pr, true
+		},
+		Person: func(login string) string { return login },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.PullRequests != 1 || result.PullRequestEvents != 2 || result.IncompletePullRequests != 1 {
+		t.Errorf("expected 1 pull request rebuilt from 2 events and 1 incomplete pull request, got %+v", result)
+	}
+
+	if n := count("SELECT reviews FROM pull_requests WHERE pull_request = 1 AND creation_time IS NOT NULL;"); n != 2 {
+		t.Errorf("expected the partially-evented pull request to be kept as-is, got %d reviews", n)
+	}
+	if n := count("SELECT reviews FROM pull_requests WHERE pull_request = 2 AND creation_time = ?;", formatTime(created)); n != 1 {
+		t.Errorf("expected the complete pull request to be rebuilt from its events, got %d reviews", n)
+	}
+}
+
+func TestRebuildAppliesTheCurrentIdentitiesAndEnvironmentAliases(t *testing.T) {
+	ctx := context.Background()
+	s, count := newTestStore(t)
+	started := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	pipeline := store.Pipeline{Type: store.PipelineTypeRelease, Owner: "org", Repository: "app", Context: "release", Build: 1, Status: "Succeeded", Author: "alice-gh", AuthorLogin: "alice-gh", StartTime: started, EndTime: started.Add(time.Minute), Duration: time.Minute,
+		Steps: []store.SimplifiedActivityStep{{Name: "build", Status: "Succeeded", StartedTimestamp: started, CompletedTimestamp: started.Add(time.Minute), Duration: time.Minute}}}
+	if err := s.Pipelines.Add(ctx, pipeline); err != nil {
+		t.Fatal(err)
+	}
+	for i, environment := range []string{"prod-eu", "prod-us"} {
+		d := store.Deployment{Owner: "org", Repository: "app", Version: "1.0.0", Environment: environment, SourceEnvironment: environment, DeploymentTime: started.Add(time.Duration(i+1) * time.Hour)}
+		if err := s.Deployments.Add(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rebuild := func(persons, aliases map[string]string) store.RebuildResult {
+		t.Helper()
+		result, err := s.Rebuild.Rebuild(ctx, store.RebuildFilter{}, store.Rebuilder{
+			PullRequest: func(store.PullRequestEvent) (store.PullRequest, bool) { return store.PullRequest{}, false },
+			Person:      func(login string) string { return login },
+			Pipeline: func(p store.Pipeline) store.Pipeline {
+				p.Author = p.AuthorLogin
+				if person, ok := persons[p.AuthorLogin]; ok {
+					p.Author = person
+				}
+				p.Duration = p.EndTime.Sub(p.StartTime)
+				return p
+			},
+			Deployment: func(d store.Deployment) store.Deployment {
+				d.Environment = d.SourceEnvironment
+				if alias, ok := aliases[d.SourceEnvironment]; ok {
+					d.Environment = alias
+				}
+				return d
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	result := rebuild(map[string]string{"alice-gh": "alice"}, map[string]string{"prod-eu": "production", "prod-us": "production"})
+	if result.Pipelines != 1 || result.Deployments != 1 {
+		t.Errorf("expected 1 pipeline and 1 deployment rebuilt, got %+v", result)
+	}
+	if n := count("SELECT COUNT(*) FROM pipelines WHERE author = 'alice' AND duration = 60;"); n != 1 {
+		t.Errorf("expected the pipeline to be rebuilt with the canonical person of its author, got %d", n)
+	}
+	if n := count("SELECT COUNT(*) FROM pipelinesteps WHERE step_name = 'build' AND step_duration = 60;"); n != 1 {
+		t.Errorf("expected the pipeline step to be kept, got %d", n)
+	}
+	if n := count("SELECT COUNT(*) FROM deployments;"); n != 1 {
+		t.Errorf("expected the deployments to be merged into their aliased environment, got %d", n)
+	}
+	if n := count("SELECT COUNT(*) FROM deployments WHERE environment = 'production' AND deployment_time = ?;", formatTime(started.Add(time.Hour))); n != 1 {
+		t.Errorf("expected the deployment to keep its earliest deployment time, got %d", n)
+	}
+
+	// the events keep the environment of their deployment, so that a later rebuild can split it again
+	result = rebuild(nil, nil)
+	if result.Pipelines != 1 || result.Deployments != 2 {
+		t.Errorf("expected 1 pipeline and 2 deployments rebuilt, got %+v", result)
+	}
+	if n := count("SELECT COUNT(*) FROM deployments WHERE environment IN ('prod-eu', 'prod-us');"); n != 2 {
+		t.Errorf("expected the deployments to be moved back to their environments, got %d", n)
+	}
+	if n := count("SELECT COUNT(*) FROM pipelines WHERE author = 'alice-gh';"); n != 1 {
+		t.Errorf("expected the pipeline to be rebuilt with the login of its author, got %d", n)
+	}
+}
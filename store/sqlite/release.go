@@ -0,0 +1,54 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/jenkins-x/cd-indicators/store/migration"
+)
+
+type ReleaseStore struct {
+	db       *sql.DB
+	notifier *store.Notifier
+}
+
+func (s *ReleaseStore) TableName() string {
+	return "releases"
+}
+
+func (s *ReleaseStore) Migrations() []migration.SQLiteFunc {
+	return []migration.SQLiteFunc{
+		migration.ExecSQLiteFunc(`
+			CREATE TABLE releases (
+				owner TEXT NOT NULL,
+				repository TEXT NOT NULL,
+				version TEXT,
+				contributors TEXT,
+				release_time TEXT NOT NULL,
+				CONSTRAINT releases_pkey PRIMARY KEY (owner, repository, version)
+			);
+		`),
+		migration.ExecSQLiteFunc(`
+			ALTER TABLE releases ADD COLUMN is_bot INTEGER NOT NULL DEFAULT 0;
+		`),
+	}
+}
+
+func (s *ReleaseStore) Add(ctx context.Context, r store.Release) error {
+	contributors, err := formatStrings(r.Contributors)
+	if err != nil {
+		return fmt.Errorf("failed to encode contributors of release %s: %w", r, err)
+	}
+
+	res, err := s.db.ExecContext(ctx, "INSERT INTO releases (owner, repository, version, contributors, release_time, is_bot) VALUES (?, ?, ?, ?, ?, ?) ON CONFLICT DO NOTHING;", r.Owner, r.Repository, r.Version, contributors, formatTime(r.ReleaseTime), r.IsBot)
+	if err != nil {
+		return fmt.Errorf("failed to add release: %w", err)
+	}
+
+	if added, _ := res.RowsAffected(); added > 0 {
+		s.notifier.ReleaseAdded(ctx, r)
+	}
+	return nil
+}
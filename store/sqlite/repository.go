@@ -0,0 +1,127 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/jenkins-x/cd-indicators/store/migration"
+)
+
+type RepositoryStore struct {
+	db *sql.DB
+}
+
+func (s *RepositoryStore) TableName() string {
+	return "repositories"
+}
+
+func (s *RepositoryStore) Migrations() []migration.SQLiteFunc {
+	return []migration.SQLiteFunc{
+		migration.ExecSQLiteFunc(`
+			CREATE TABLE repositories (
+				provider TEXT NOT NULL,
+				owner TEXT NOT NULL,
+				repository TEXT NOT NULL,
+				url TEXT NOT NULL,
+				labels TEXT NOT NULL DEFAULT '{}',
+				creation_time TEXT,
+				CONSTRAINT repositories_pkey PRIMARY KEY (owner, repository)
+			);
+		`),
+	}
+}
+
+func (s *RepositoryStore) Add(ctx context.Context, r store.Repository) error {
+	labels := r.Labels
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	data, err := json.Marshal(labels)
+	if err != nil {
+		return fmt.Errorf("failed to encode labels of repository %s: %w", r, err)
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO repositories (provider, owner, repository, url, labels, creation_time) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (owner, repository) DO UPDATE SET
+			provider = excluded.provider,
+			url = excluded.url,
+			labels = excluded.labels,
+			creation_time = excluded.creation_time;
+	`, r.Provider, r.Owner, r.Name, r.URL, string(data), formatTime(r.CreationTime))
+	if err != nil {
+		return fmt.Errorf("failed to add repository %s: %w", r, err)
+	}
+	return nil
+}
+
+func (s *RepositoryStore) Delete(ctx context.Context, owner, name string) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM repositories WHERE owner = ? AND repository = ?;", owner, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete repository %s/%s: %w", owner, name, err)
+	}
+	return nil
+}
+
+func (s *RepositoryStore) ListActivity(ctx context.Context, owner string) ([]store.RepositoryActivity, error) {
+	// the times are stored in a sortable layout, so max returns the latest one
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT r.provider, r.owner, r.repository, r.url, r.labels, r.creation_time,
+			(SELECT max(creation_time) FROM pull_requests WHERE owner = r.owner AND repository = r.repository),
+			(SELECT max(start_time) FROM pipelines WHERE owner = r.owner AND repository = r.repository),
+			(SELECT max(release_time) FROM releases WHERE owner = r.owner AND repository = r.repository),
+			(SELECT max(deployment_time) FROM deployments WHERE owner = r.owner AND repository = r.repository)
+		FROM repositories r
+		WHERE ?1 = '' OR r.owner = ?1
+		ORDER BY r.owner, r.repository;
+	`, owner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repositories: %w", err)
+	}
+	defer rows.Close()
+
+	var activities []store.RepositoryActivity
+	for rows.Next() {
+		var (
+			a                                                          store.RepositoryActivity
+			labels                                                     string
+			creationTime                                               sql.NullString
+			lastPullRequest, lastPipeline, lastRelease, lastDeployment sql.NullString
+		)
+		err = rows.Scan(&a.Provider, &a.Owner, &a.Name, &a.URL, &labels, &creationTime,
+			&lastPullRequest, &lastPipeline, &lastRelease, &lastDeployment)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read repository: %w", err)
+		}
+		if err = json.Unmarshal([]byte(labels), &a.Labels); err != nil {
+			return nil, fmt.Errorf("failed to decode labels of repository %s: %w", a.Repository, err)
+		}
+		if creationTime.Valid {
+			if a.CreationTime, err = parseTime(creationTime.String); err != nil {
+				return nil, fmt.Errorf("failed to parse creation time of repository %s: %w", a.Repository, err)
+			}
+		}
+		for _, t := range []struct {
+			value sql.NullString
+			field **time.Time
+		}{
+			{lastPullRequest, &a.LastPullRequestTime},
+			{lastPipeline, &a.LastPipelineTime},
+			{lastRelease, &a.LastReleaseTime},
+			{lastDeployment, &a.LastDeploymentTime},
+		} {
+			if *t.field, err = parseOptionalTime(t.value); err != nil {
+				return nil, fmt.Errorf("failed to parse activity time of repository %s: %w", a.Repository, err)
+			}
+		}
+		activities = append(activities, a)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list repositories: %w", err)
+	}
+	return activities, nil
+}
@@ -0,0 +1,587 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/jenkins-x/cd-indicators/store/migration"
+)
+
+type retentionTable struct {
+	timeColumn string
+	// timeFallbacks are the columns replacing the time column when it is NULL
+	timeFallbacks []string
+	rollup        *rollup
+}
+
+// timeExpression returns the time of the rows, compared with the cutoff
+func (t retentionTable) timeExpression() string {
+	if len(t.timeFallbacks) == 0 {
+		return t.timeColumn
+	}
+	return fmt.Sprintf("COALESCE(%s, %s)", t.timeColumn, strings.Join(t.timeFallbacks, ", "))
+}
+
+// rollup describes how the raw rows of a table are aggregated per period before being deleted:
+// the number of rows of each group, and optionally their outcome and the percentiles of their duration.
+// SQLite has no percentile function, so the aggregates are computed in Go.
+type rollup struct {
+	groupBy []string
+	// isBot is the expression of the is_bot dimension, so that the aggregates can exclude the automated changes
+	isBot       string
+	countColumn string
+	outcome     *rollupOutcome
+	duration    *rollupDuration
+}
+
+// rollupOutcome counts the rows matching a condition - such as the succeeded pipelines - the other rows, and the ratio of the matching rows
+type rollupOutcome struct {
+	condition     string
+	matchedColumn string
+	otherColumn   string
+	rateColumn    string
+}
+
+// rollupDuration aggregates the non-NULL durations of the rows in the <prefix>_avg, _p50, _p90, _p95 and _max columns
+type rollupDuration struct {
+	expression string
+	prefix     string
+}
+
+var retentionTables = map[string]retentionTable{
+	"pipelines": {
+		timeColumn: "start_time",
+		rollup: &rollup{
+			groupBy:     []string{"type", "owner", "repository", "context"},
+			isBot:       "is_bot",
+			countColumn: "runs",
+			outcome: &rollupOutcome{
+				condition:     "status = 'Succeeded'",
+				matchedColumn: "succeeded",
+				otherColumn:   "failed",
+				rateColumn:    "success_rate",
+			},
+			duration: &rollupDuration{expression: "duration", prefix: "duration"},
+		},
+	},
+	"pipelinesteps": {
+		timeColumn: "step_started_time",
+		rollup: &rollup{
+			groupBy:     []string{"type", "owner", "repository", "context", "step_name"},
+			isBot:       "is_bot",
+			countColumn: "runs",
+			outcome: &rollupOutcome{
+				condition:     "step_status = 'Succeeded'",
+				matchedColumn: "succeeded",
+				otherColumn:   "failed",
+				rateColumn:    "success_rate",
+			},
+			duration: &rollupDuration{expression: "step_duration", prefix: "duration"},
+		},
+	},
+	"pull_requests": {
+		timeColumn:    "creation_time",
+		timeFallbacks: []string{"ready_for_review_time", "first_comment_time", "first_review_time", "approved_time", "merged_time", "closed_time"},
+		rollup: &rollup{
+			groupBy:     []string{"owner", "repository"},
+			isBot:       "is_bot",
+			countColumn: "pull_requests",
+			outcome: &rollupOutcome{
+				condition:     "merged_time IS NOT NULL",
+				matchedColumn: "merged",
+				otherColumn:   "not_merged",
+				rateColumn:    "merge_rate",
+			},
+			duration: &rollupDuration{expression: "CASE WHEN merged_time IS NOT NULL THEN time_to_merge END", prefix: "time_to_merge"},
+		},
+	},
+	"pull_request_events": {
+		timeColumn: "event_time",
+	},
+	"pipeline_events": {
+		timeColumn: "start_time",
+	},
+	"deployment_events": {
+		timeColumn: "deployment_time",
+	},
+	"releases": {
+		timeColumn: "release_time",
+		rollup: &rollup{
+			groupBy:     []string{"owner", "repository"},
+			isBot:       "is_bot",
+			countColumn: "releases",
+		},
+	},
+	"deployments": {
+		timeColumn: "deployment_time",
+		rollup: &rollup{
+			groupBy: []string{"owner", "repository", "environment"},
+			// a deployment is automated when its release is
+			isBot:       "EXISTS (SELECT 1 FROM releases r WHERE r.owner = deployments.owner AND r.repository = deployments.repository AND r.version = deployments.version AND r.is_bot)",
+			countColumn: "deployments",
+		},
+	},
+	"lighthouse_jobs": {
+		timeColumn: "trigger_time",
+		rollup: &rollup{
+			groupBy:     []string{"type", "owner", "repository", "context"},
+			isBot:       "is_bot",
+			countColumn: "jobs",
+			outcome: &rollupOutcome{
+				condition:     "state = 'success'",
+				matchedColumn: "succeeded",
+				otherColumn:   "failed",
+				rateColumn:    "success_rate",
+			},
+			duration: &rollupDuration{expression: "queue_duration", prefix: "queue_duration"},
+		},
+	},
+	"helm_releases": {
+		timeColumn: "deployment_time",
+	},
+	"gitops_syncs": {
+		timeColumn: "sync_time",
+	},
+	"incidents": {
+		timeColumn: "detection_time",
+	},
+}
+
+type rollupPeriod struct {
+	tableSuffix string
+	truncate    func(t time.Time) time.Time
+}
+
+var rollupPeriods = []rollupPeriod{
+	{
+		tableSuffix: "daily",
+		truncate: func(t time.Time) time.Time {
+			return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		},
+	},
+	{
+		tableSuffix: "weekly",
+		truncate: func(t time.Time) time.Time {
+			day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+			return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+		},
+	},
+}
+
+type RetentionStore struct {
+	db *sql.DB
+}
+
+func (s *RetentionStore) TableName() string {
+	return "retention"
+}
+
+func (s *RetentionStore) Migrations() []migration.SQLiteFunc {
+	return []migration.SQLiteFunc{
+		migration.ExecSQLiteFunc(`
+			CREATE TABLE retention_archive (
+				table_name TEXT NOT NULL,
+				archived_time TEXT NOT NULL,
+				data TEXT NOT NULL
+			);
+			CREATE INDEX retention_archive_table_name_idx ON retention_archive (table_name);
+		`),
+		migration.ExecSQLiteFunc(`
+			CREATE TABLE pipelines_daily (
+				period_start TEXT NOT NULL,
+				type TEXT NOT NULL,
+				owner TEXT NOT NULL,
+				repository TEXT NOT NULL,
+				context TEXT NOT NULL,
+				runs INTEGER NOT NULL,
+				succeeded INTEGER NOT NULL,
+				failed INTEGER NOT NULL,
+				success_rate REAL NOT NULL,
+				duration_avg REAL,
+				duration_p50 REAL,
+				duration_p90 REAL,
+				duration_p95 REAL,
+				duration_max INTEGER,
+				CONSTRAINT pipelines_daily_pkey PRIMARY KEY (period_start, type, owner, repository, context)
+			);
+			CREATE TABLE pipelines_weekly (
+				period_start TEXT NOT NULL,
+				type TEXT NOT NULL,
+				owner TEXT NOT NULL,
+				repository TEXT NOT NULL,
+				context TEXT NOT NULL,
+				runs INTEGER NOT NULL,
+				succeeded INTEGER NOT NULL,
+				failed INTEGER NOT NULL,
+				success_rate REAL NOT NULL,
+				duration_avg REAL,
+				duration_p50 REAL,
+				duration_p90 REAL,
+				duration_p95 REAL,
+				duration_max INTEGER,
+				CONSTRAINT pipelines_weekly_pkey PRIMARY KEY (period_start, type, owner, repository, context)
+			);
+		`),
+		migration.ExecSQLiteFunc(`
+			CREATE TABLE pipelinesteps_daily (
+				period_start TEXT NOT NULL,
+				type TEXT NOT NULL,
+				owner TEXT NOT NULL,
+				repository TEXT NOT NULL,
+				context TEXT NOT NULL,
+				step_name TEXT NOT NULL,
+				runs INTEGER NOT NULL,
+				succeeded INTEGER NOT NULL,
+				failed INTEGER NOT NULL,
+				success_rate REAL NOT NULL,
+				duration_avg REAL,
+				duration_p50 REAL,
+				duration_p90 REAL,
+				duration_p95 REAL,
+				duration_max INTEGER,
+				CONSTRAINT pipelinesteps_daily_pkey PRIMARY KEY (period_start, type, owner, repository, context, step_name)
+			);
+			CREATE TABLE pipelinesteps_weekly (
+				period_start TEXT NOT NULL,
+				type TEXT NOT NULL,
+				owner TEXT NOT NULL,
+				repository TEXT NOT NULL,
+				context TEXT NOT NULL,
+				step_name TEXT NOT NULL,
+				runs INTEGER NOT NULL,
+				succeeded INTEGER NOT NULL,
+				failed INTEGER NOT NULL,
+				success_rate REAL NOT NULL,
+				duration_avg REAL,
+				duration_p50 REAL,
+				duration_p90 REAL,
+				duration_p95 REAL,
+				duration_max INTEGER,
+				CONSTRAINT pipelinesteps_weekly_pkey PRIMARY KEY (period_start, type, owner, repository, context, step_name)
+			);
+		`),
+		// SQLite can't change a primary key: the tables are re-created, and the aggregates computed before are kept as not automated
+		migration.ExecSQLiteFunc(`
+			ALTER TABLE pipelines_daily RENAME TO pipelines_daily_old;
+			ALTER TABLE pipelines_weekly RENAME TO pipelines_weekly_old;
+			ALTER TABLE pipelinesteps_daily RENAME TO pipelinesteps_daily_old;
+			ALTER TABLE pipelinesteps_weekly RENAME TO pipelinesteps_weekly_old;
+		` + rollupTablesSQL("pipelines", `
+				type TEXT NOT NULL,
+				owner TEXT NOT NULL,
+				repository TEXT NOT NULL,
+				context TEXT NOT NULL,
+				runs INTEGER NOT NULL,
+				succeeded INTEGER NOT NULL,
+				failed INTEGER NOT NULL,
+				success_rate REAL NOT NULL,
+				duration_avg REAL,
+				duration_p50 REAL,
+				duration_p90 REAL,
+				duration_p95 REAL,
+				duration_max INTEGER,
+				is_bot INTEGER NOT NULL DEFAULT 0`, "type, owner, repository, context, is_bot") + rollupTablesSQL("pipelinesteps", `
+				type TEXT NOT NULL,
+				owner TEXT NOT NULL,
+				repository TEXT NOT NULL,
+				context TEXT NOT NULL,
+				step_name TEXT NOT NULL,
+				runs INTEGER NOT NULL,
+				succeeded INTEGER NOT NULL,
+				failed INTEGER NOT NULL,
+				success_rate REAL NOT NULL,
+				duration_avg REAL,
+				duration_p50 REAL,
+				duration_p90 REAL,
+				duration_p95 REAL,
+				duration_max INTEGER,
+				is_bot INTEGER NOT NULL DEFAULT 0`, "type, owner, repository, context, step_name, is_bot") + `
+			INSERT INTO pipelines_daily SELECT *, 0 FROM pipelines_daily_old;
+			INSERT INTO pipelines_weekly SELECT *, 0 FROM pipelines_weekly_old;
+			INSERT INTO pipelinesteps_daily SELECT *, 0 FROM pipelinesteps_daily_old;
+			INSERT INTO pipelinesteps_weekly SELECT *, 0 FROM pipelinesteps_weekly_old;
+			DROP TABLE pipelines_daily_old;
+			DROP TABLE pipelines_weekly_old;
+			DROP TABLE pipelinesteps_daily_old;
+			DROP TABLE pipelinesteps_weekly_old;
+		`),
+		migration.ExecSQLiteFunc(rollupTablesSQL("pull_requests", `
+				owner TEXT NOT NULL,
+				repository TEXT NOT NULL,
+				is_bot INTEGER NOT NULL,
+				pull_requests INTEGER NOT NULL,
+				merged INTEGER NOT NULL,
+				not_merged INTEGER NOT NULL,
+				merge_rate REAL NOT NULL,
+				time_to_merge_avg REAL,
+				time_to_merge_p50 REAL,
+				time_to_merge_p90 REAL,
+				time_to_merge_p95 REAL,
+				time_to_merge_max INTEGER`, "owner, repository, is_bot") + rollupTablesSQL("releases", `
+				owner TEXT NOT NULL,
+				repository TEXT NOT NULL,
+				is_bot INTEGER NOT NULL,
+				releases INTEGER NOT NULL`, "owner, repository, is_bot") + rollupTablesSQL("deployments", `
+				owner TEXT NOT NULL,
+				repository TEXT NOT NULL,
+				environment TEXT NOT NULL,
+				is_bot INTEGER NOT NULL,
+				deployments INTEGER NOT NULL`, "owner, repository, environment, is_bot") + rollupTablesSQL("lighthouse_jobs", `
+				type TEXT NOT NULL,
+				owner TEXT NOT NULL,
+				repository TEXT NOT NULL,
+				context TEXT NOT NULL,
+				is_bot INTEGER NOT NULL,
+				jobs INTEGER NOT NULL,
+				succeeded INTEGER NOT NULL,
+				failed INTEGER NOT NULL,
+				success_rate REAL NOT NULL,
+				queue_duration_avg REAL,
+				queue_duration_p50 REAL,
+				queue_duration_p90 REAL,
+				queue_duration_p95 REAL,
+				queue_duration_max INTEGER`, "type, owner, repository, context, is_bot")),
+	}
+}
+
+// rollupTablesSQL returns the statements creating the daily and weekly rollup tables of a table,
+// with the given columns after the period start, and the given primary key columns after it
+func rollupTablesSQL(table, columns, primaryKey string) string {
+	var statements []string
+	for _, suffix := range []string{"daily", "weekly"} {
+		statements = append(statements, fmt.Sprintf(`
+			CREATE TABLE %[1]s_%[2]s (
+				period_start TEXT NOT NULL,%[3]s,
+				CONSTRAINT %[1]s_%[2]s_pkey PRIMARY KEY (period_start, %[4]s)
+			);`, table, suffix, columns, primaryKey))
+	}
+	return strings.Join(statements, "")
+}
+
+// Apply rolls up, archives and deletes the rows of the policy's table which are older than its cutoff
+func (s *RetentionStore) Apply(ctx context.Context, policy store.RetentionPolicy, now time.Time) (store.RetentionResult, error) {
+	var result store.RetentionResult
+	if err := policy.Validate(); err != nil {
+		return result, err
+	}
+	table := retentionTables[policy.Table]
+	cutoff := formatTime(policy.Cutoff(now))
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return result, fmt.Errorf("failed to start a new DB transaction: %w", err)
+	}
+	defer tx.Rollback() // nolint: errcheck
+
+	// the rows without any time yet - such as the pull requests only known by their label events - never expire
+	timeExpression := table.timeExpression()
+	where := fmt.Sprintf("%s < ?1", timeExpression)
+
+	if table.rollup != nil {
+		result.RolledUp, err = table.rollup.apply(ctx, tx, policy.Table, timeExpression, where, cutoff)
+		if err != nil {
+			return result, fmt.Errorf("failed to roll up %s: %w", policy.Table, err)
+		}
+	}
+
+	if policy.Archive {
+		result.Archived, err = archive(ctx, tx, policy.Table, where, cutoff, now)
+		if err != nil {
+			return result, fmt.Errorf("failed to archive %s: %w", policy.Table, err)
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE %s;", policy.Table, where), cutoff)
+	if err != nil {
+		return result, fmt.Errorf("failed to delete expired rows from %s: %w", policy.Table, err)
+	}
+	result.Deleted, _ = res.RowsAffected()
+
+	if err = tx.Commit(); err != nil {
+		return result, fmt.Errorf("failed to commit retention of %s: %w", policy.Table, err)
+	}
+
+	return result, nil
+}
+
+type aggregate struct {
+	periodStart string
+	groupValues []any
+	isBot       bool
+	count       int64
+	matched     int64
+	durations   []float64
+}
+
+// apply aggregates the rows matching the where clause - and having a time - into the table's daily and weekly rollup tables.
+// Aggregates which already exist are kept: they were computed before the rows were deleted,
+// so rows re-inserted later by a resync are already accounted for.
+func (r *rollup) apply(ctx context.Context, tx *sql.Tx, table, timeExpression, where string, args ...any) (int64, error) {
+	outcome, duration := "0", "NULL"
+	if r.outcome != nil {
+		outcome = r.outcome.condition
+	}
+	if r.duration != nil {
+		duration = r.duration.expression
+	}
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf("SELECT %s, %s, %s, %s, %s FROM %s WHERE %s AND %s IS NOT NULL;",
+		strings.Join(r.groupBy, ", "), r.isBot, timeExpression, outcome, duration, table, where, timeExpression), args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	aggregates := make([]map[string]*aggregate, len(rollupPeriods))
+	for i := range aggregates {
+		aggregates[i] = map[string]*aggregate{}
+	}
+	for rows.Next() {
+		var (
+			groupValues = make([]string, len(r.groupBy))
+			dest        = make([]any, 0, len(r.groupBy)+4)
+			isBot       bool
+			timeValue   string
+			matched     bool
+			d           sql.NullFloat64
+		)
+		for i := range groupValues {
+			dest = append(dest, &groupValues[i])
+		}
+		dest = append(dest, &isBot, &timeValue, &matched, &d)
+		if err = rows.Scan(dest...); err != nil {
+			return 0, err
+		}
+		t, err := parseTime(timeValue)
+		if err != nil {
+			return 0, err
+		}
+
+		for i, period := range rollupPeriods {
+			periodStart := formatTime(period.truncate(t))
+			key := fmt.Sprintf("%s\x00%s\x00%v", periodStart, strings.Join(groupValues, "\x00"), isBot)
+			agg := aggregates[i][key]
+			if agg == nil {
+				agg = &aggregate{periodStart: periodStart, isBot: isBot}
+				for _, v := range groupValues {
+					agg.groupValues = append(agg.groupValues, v)
+				}
+				aggregates[i][key] = agg
+			}
+			agg.count++
+			if matched {
+				agg.matched++
+			}
+			if d.Valid {
+				agg.durations = append(agg.durations, d.Float64)
+			}
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+	rows.Close()
+
+	columns := append(append([]string{"period_start"}, r.groupBy...), "is_bot", r.countColumn)
+	if r.outcome != nil {
+		columns = append(columns, r.outcome.matchedColumn, r.outcome.otherColumn, r.outcome.rateColumn)
+	}
+	if r.duration != nil {
+		columns = append(columns, r.duration.prefix+"_avg", r.duration.prefix+"_p50", r.duration.prefix+"_p90", r.duration.prefix+"_p95", r.duration.prefix+"_max")
+	}
+
+	var rolledUp int64
+	for i, period := range rollupPeriods {
+		targetTable := fmt.Sprintf("%s_%s", table, period.tableSuffix)
+		query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (?%s) ON CONFLICT DO NOTHING;",
+			targetTable, strings.Join(columns, ", "), strings.Repeat(", ?", len(columns)-1))
+		for _, agg := range aggregates[i] {
+			values := append([]any{agg.periodStart}, agg.groupValues...)
+			values = append(values, agg.isBot, agg.count)
+			if r.outcome != nil {
+				values = append(values, agg.matched, agg.count-agg.matched, float64(agg.matched)/float64(agg.count))
+			}
+			if r.duration != nil {
+				values = append(values, durationAggregates(agg.durations)...)
+			}
+			res, err := tx.ExecContext(ctx, query, values...)
+			if err != nil {
+				return rolledUp, fmt.Errorf("failed to insert into %s: %w", targetTable, err)
+			}
+			n, _ := res.RowsAffected()
+			rolledUp += n
+		}
+	}
+
+	return rolledUp, nil
+}
+
+// durationAggregates returns the average, percentiles and maximum of durations - all NULL without any duration
+func durationAggregates(durations []float64) []any {
+	if len(durations) == 0 {
+		return []any{nil, nil, nil, nil, nil}
+	}
+	var sum, max float64
+	for _, d := range durations {
+		sum += d
+		if d > max {
+			max = d
+		}
+	}
+	return []any{
+		sum / float64(len(durations)),
+		store.Percentile(durations, 0.5), store.Percentile(durations, 0.9), store.Percentile(durations, 0.95),
+		int64(max),
+	}
+}
+
+// archive copies the rows matching the where clause to the archive table, as JSON objects
+func archive(ctx context.Context, tx *sql.Tx, table, where string, cutoff string, now time.Time) (int64, error) {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf("SELECT * FROM %s WHERE %s;", table, where), cutoff)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	var archived []string
+	for rows.Next() {
+		values := make([]any, len(columns))
+		dest := make([]any, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err = rows.Scan(dest...); err != nil {
+			return 0, err
+		}
+		object := make(map[string]any, len(columns))
+		for i, column := range columns {
+			object[column] = values[i]
+		}
+		data, err := json.Marshal(object)
+		if err != nil {
+			return 0, err
+		}
+		archived = append(archived, string(data))
+	}
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+	rows.Close()
+
+	for _, data := range archived {
+		_, err = tx.ExecContext(ctx, "INSERT INTO retention_archive (table_name, archived_time, data) VALUES (?, ?, ?);", table, formatTime(now), data)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return int64(len(archived)), nil
+}
@@ -0,0 +1,98 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/cd-indicators/store"
+)
+
+func newTestStore(t *testing.T) (*store.Store, func(query string, args ...any) int) {
+	t.Helper()
+	ctx := context.Background()
+	db, err := Open(filepath.Join(t.TempDir(), "indicators.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	s, err := New(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := func(query string, args ...any) int {
+		t.Helper()
+		var n int
+		if err := db.QueryRowContext(ctx, query, args...).Scan(&n); err != nil {
+			t.Fatalf("failed to run %q: %v", query, err)
+		}
+		return n
+	}
+	return s, count
+}
+
+func TestRetentionRollsUpBeforeDeleting(t *testing.T) {
+	ctx := context.Background()
+	s, count := newTestStore(t)
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	old := now.AddDate(0, 0, -40)
+	merged := old.Add(2 * time.Hour)
+
+	prs := []store.PullRequest{
+		{Owner: "org", Repository: "app", PullRequest: 1, Author: "alice", CreationTime: &old, MergedTime: &merged, TimeToMerge: 2 * time.Hour},
+		{Owner: "org", Repository: "app", PullRequest: 2, Author: "renovate[bot]", IsBot: true, CreationTime: &old},
+		// collected without its creation time: expires with its merge time
+		{Owner: "org", Repository: "app", PullRequest: 3, Author: "bob", MergedTime: &merged},
+		// no time yet: never expires
+		{Owner: "org", Repository: "app", PullRequest: 4, Author: "bob"},
+		{Owner: "org", Repository: "app", PullRequest: 5, Author: "bob", CreationTime: &now},
+	}
+	for _, pr := range prs {
+		if err := s.PullRequests.Add(ctx, pr); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Releases.Add(ctx, store.Release{Owner: "org", Repository: "app", Version: "1.0.0", ReleaseTime: old, IsBot: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Deployments.Add(ctx, store.Deployment{Owner: "org", Repository: "app", Version: "1.0.0", Environment: "production", DeploymentTime: old}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, table := range []string{"pull_requests", "releases", "deployments"} {
+		result, err := s.Retention.Apply(ctx, store.RetentionPolicy{Table: table, MaxAge: 30 * 24 * time.Hour}, now)
+		if err != nil {
+			t.Fatalf("failed to apply the retention of %s: %v", table, err)
+		}
+		if result.RolledUp == 0 {
+			t.Errorf("expected %s to be rolled up", table)
+		}
+	}
+
+	if n := count("SELECT COUNT(*) FROM pull_requests;"); n != 2 {
+		t.Errorf("expected only the recent pull request and the one without a time to be kept, got %d pull requests", n)
+	}
+	if n := count("SELECT COUNT(*) FROM pull_requests WHERE pull_request = 4;"); n != 1 {
+		t.Errorf("expected the pull request without a time to be kept, got %d", n)
+	}
+	if n := count("SELECT pull_requests FROM pull_requests_daily WHERE NOT is_bot;"); n != 2 {
+		t.Errorf("expected 2 human pull requests with a time to be rolled up, got %d", n)
+	}
+	if n := count("SELECT merged FROM pull_requests_daily WHERE NOT is_bot;"); n != 2 {
+		t.Errorf("expected 2 merged pull requests, got %d", n)
+	}
+	if n := count("SELECT pull_requests FROM pull_requests_daily WHERE is_bot;"); n != 1 {
+		t.Errorf("expected 1 bot pull request to be rolled up, got %d", n)
+	}
+	if n := count("SELECT COUNT(*) FROM releases_weekly WHERE is_bot AND releases = 1;"); n != 1 {
+		t.Errorf("expected the bot release to be rolled up, got %d rows", n)
+	}
+	// the deployed release is already deleted: the deployment is rolled up as not automated
+	if n := count("SELECT COUNT(*) FROM deployments_daily WHERE environment = 'production' AND deployments = 1;"); n != 1 {
+		t.Errorf("expected the deployment to be rolled up, got %d rows", n)
+	}
+	if n := count("SELECT COUNT(*) FROM deployments;"); n != 0 {
+		t.Errorf("expected the deployment to be deleted, got %d deployments", n)
+	}
+}
@@ -0,0 +1,181 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/jenkins-x/cd-indicators/store/migration"
+	_ "modernc.org/sqlite" // registers the "sqlite" database/sql driver
+)
+
+// timeLayout is the fixed-width layout of the timestamps stored as text, so that they can be compared as strings
+const timeLayout = "2006-01-02 15:04:05.000000"
+
+// Open opens - and creates if needed - the SQLite database file at the given path
+func Open(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)", path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SQLite database %s: %w", path, err)
+	}
+	// SQLite only supports a single writer: serializing all the operations on a single connection
+	// gives us the same isolation as the PostgreSQL transactions, without any "database is locked" error
+	db.SetMaxOpenConns(1)
+	return db, nil
+}
+
+// New returns a store backed by SQLite, after running the migrations
+func New(ctx context.Context, db *sql.DB) (*store.Store, error) {
+	notifier := &store.Notifier{}
+	var (
+		pipelines = &PipelineStore{
+			db:       db,
+			notifier: notifier,
+		}
+		pullRequests = &PullRequestStore{
+			db:       db,
+			notifier: notifier,
+		}
+		pullRequestEvents = &PullRequestEventStore{
+			db: db,
+		}
+		releases = &ReleaseStore{
+			db:       db,
+			notifier: notifier,
+		}
+		deployments = &DeploymentStore{
+			db:       db,
+			notifier: notifier,
+		}
+		identities = &IdentityStore{
+			db: db,
+		}
+		teams = &TeamStore{
+			db: db,
+		}
+		repositories = &RepositoryStore{
+			db: db,
+		}
+		lighthouseJobs = &LighthouseJobStore{
+			db: db,
+		}
+		helmReleases = &HelmReleaseStore{
+			db: db,
+		}
+		gitOpsSyncs = &GitOpsSyncStore{
+			db: db,
+		}
+		incidents = &IncidentStore{
+			db: db,
+		}
+		retention = &RetentionStore{
+			db: db,
+		}
+	)
+
+	err := (&migration.SQLiteMigrator{
+		DB: db,
+	}).Migrate(ctx,
+		pipelines,
+		pullRequests,
+		pullRequestEvents,
+		releases,
+		deployments,
+		identities,
+		teams,
+		repositories,
+		lighthouseJobs,
+		helmReleases,
+		gitOpsSyncs,
+		incidents,
+		retention,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run store migrations: %w", err)
+	}
+
+	return &store.Store{
+		Pipelines:         pipelines,
+		PullRequests:      pullRequests,
+		PullRequestEvents: pullRequestEvents,
+		Releases:          releases,
+		Deployments:       deployments,
+		Identities:        identities,
+		Teams:             teams,
+		Repositories:      repositories,
+		LighthouseJobs:    lighthouseJobs,
+		HelmReleases:      helmReleases,
+		GitOpsSyncs:       gitOpsSyncs,
+		Incidents:         incidents,
+		Retention:         retention,
+		Notifier:          notifier,
+		Rebuild: &RebuildStore{
+			db: db,
+		},
+		Export: &ExportStore{
+			db: db,
+		},
+		Health: healthChecker{db: db},
+	}, nil
+}
+
+type healthChecker struct {
+	db *sql.DB
+}
+
+func (c healthChecker) Ping(ctx context.Context) error {
+	return c.db.PingContext(ctx)
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+func formatOptionalTime(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return formatTime(*t)
+}
+
+func parseTime(value string) (time.Time, error) {
+	return time.ParseInLocation(timeLayout, value, time.UTC)
+}
+
+func parseOptionalTime(value sql.NullString) (*time.Time, error) {
+	if !value.Valid {
+		return nil, nil
+	}
+	t, err := parseTime(value.String)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// formatDuration stores durations as a number of seconds, like the PostgreSQL store
+func formatDuration(d time.Duration) int64 {
+	return int64(math.Round(d.Seconds()))
+}
+
+// formatStrings stores lists as JSON arrays
+func formatStrings(values []string) (string, error) {
+	if values == nil {
+		values = []string{}
+	}
+	data, err := json.Marshal(values)
+	return string(data), err
+}
+
+func parseStrings(value sql.NullString) ([]string, error) {
+	if !value.Valid || value.String == "" {
+		return nil, nil
+	}
+	var values []string
+	err := json.Unmarshal([]byte(value.String), &values)
+	return values, err
+}
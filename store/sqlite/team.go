@@ -0,0 +1,83 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jenkins-x/cd-indicators/store"
+	"github.com/jenkins-x/cd-indicators/store/migration"
+)
+
+type TeamStore struct {
+	db *sql.DB
+}
+
+func (s *TeamStore) TableName() string {
+	return "team_repositories"
+}
+
+func (s *TeamStore) Migrations() []migration.SQLiteFunc {
+	return []migration.SQLiteFunc{
+		migration.ExecSQLiteFunc(`
+			CREATE TABLE team_repositories (
+				team TEXT NOT NULL,
+				owner TEXT NOT NULL,
+				repository TEXT NOT NULL,
+				source TEXT NOT NULL,
+				CONSTRAINT team_repositories_pkey PRIMARY KEY (team, owner, repository, source)
+			);
+		`),
+		migration.ExecSQLiteFunc(`
+			CREATE INDEX team_repositories_repository_idx ON team_repositories (owner, repository);
+		`),
+	}
+}
+
+func (s *TeamStore) List(ctx context.Context) ([]store.TeamRepository, error) {
+	return listTeamRepositories(ctx, s.db)
+}
+
+func (s *TeamStore) Replace(ctx context.Context, source string, mappings []store.TeamRepository) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start a new DB transaction: %w", err)
+	}
+	defer tx.Rollback() // nolint: errcheck
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM team_repositories WHERE source = ?;", source); err != nil {
+		return fmt.Errorf("failed to delete the team repositories of %s: %w", source, err)
+	}
+	for _, m := range mappings {
+		_, err = tx.ExecContext(ctx, "INSERT INTO team_repositories (team, owner, repository, source) VALUES (?, ?, ?, ?) ON CONFLICT DO NOTHING;", m.Team, m.Owner, m.Repository, source)
+		if err != nil {
+			return fmt.Errorf("failed to add team repository %s/%s of %s: %w", m.Owner, m.Repository, m.Team, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit replacement of the team repositories of %s: %w", source, err)
+	}
+	return nil
+}
+
+func listTeamRepositories(ctx context.Context, db queryer) ([]store.TeamRepository, error) {
+	rows, err := db.QueryContext(ctx, "SELECT team, owner, repository, source FROM team_repositories ORDER BY team, owner, repository, source;")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list team repositories: %w", err)
+	}
+	defer rows.Close()
+
+	var mappings []store.TeamRepository
+	for rows.Next() {
+		var m store.TeamRepository
+		if err = rows.Scan(&m.Team, &m.Owner, &m.Repository, &m.Source); err != nil {
+			return nil, fmt.Errorf("failed to read team repository: %w", err)
+		}
+		mappings = append(mappings, m)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list team repositories: %w", err)
+	}
+	return mappings, nil
+}
@@ -1,47 +1,32 @@
 package store
 
-import (
-	"context"
-	"fmt"
-
-	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/jenkins-x/cd-indicators/store/migration"
-)
+import "context"
 
+// Store gives access to all the stores of a storage backend.
+// PullRequestEvents is the history from which the PullRequests summaries can be rebuilt.
+// Retention, Rebuild, Export, Health and Notifier are optional: they are nil when the backend doesn't support them.
 type Store struct {
-	Pipelines    *PipelineStore
-	PullRequests *PullRequestStore
-	Releases     *ReleaseStore
-	Deployments  *DeploymentStore
+	Pipelines         PipelineStore
+	PullRequests      PullRequestStore
+	PullRequestEvents PullRequestEventStore
+	Releases          ReleaseStore
+	Deployments       DeploymentStore
+	Identities        IdentityStore
+	Teams             TeamStore
+	Repositories      RepositoryStore
+	LighthouseJobs    LighthouseJobStore
+	HelmReleases      HelmReleaseStore
+	GitOpsSyncs       GitOpsSyncStore
+	Incidents         IncidentStore
+	Retention         RetentionStore
+	Rebuild           RebuildStore
+	Export            ExportStore
+	Health            HealthChecker
+	// Notifier is optional: it is nil when the backend doesn't notify the changes of the stored entities
+	Notifier *Notifier
 }
 
-func New(ctx context.Context, connPool *pgxpool.Pool) (*Store, error) {
-	store := &Store{
-		Pipelines: &PipelineStore{
-			connPool: connPool,
-		},
-		PullRequests: &PullRequestStore{
-			connPool: connPool,
-		},
-		Releases: &ReleaseStore{
-			connPool: connPool,
-		},
-		Deployments: &DeploymentStore{
-			connPool: connPool,
-		},
-	}
-
-	err := (&migration.Migrator{
-		ConnPool: connPool,
-	}).Migrate(ctx,
-		store.Pipelines,
-		store.PullRequests,
-		store.Releases,
-		store.Deployments,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to run store migrations: %w", err)
-	}
-
-	return store, nil
+// HealthChecker checks that the storage backend is reachable
+type HealthChecker interface {
+	Ping(ctx context.Context) error
 }
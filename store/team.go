@@ -0,0 +1,141 @@
+package store
+
+import (
+	"context"
+	"sort"
+
+	"github.com/scylladb/go-set/strset"
+)
+
+// The sources of the team mappings: each source replaces its own mappings
+const (
+	TeamSourceConfig           = "config"
+	TeamSourceCodeOwners       = "codeowners"
+	TeamSourceSourceRepository = "sourcerepository"
+)
+
+// AllRepositories is the repository of the mappings of all the repositories of an owner
+const AllRepositories = "*"
+
+// TeamRepository maps a repository - or all the repositories of an owner - to a team.
+// A repository may belong to several teams: its indicators are then counted for each of them
+type TeamRepository struct {
+	Team       string `json:"team"`
+	Owner      string `json:"owner"`
+	Repository string `json:"repository"`
+	Source     string `json:"source"`
+}
+
+// Matches returns true if the mapping applies to the given repository
+func (t TeamRepository) Matches(owner, repository string) bool {
+	return t.Owner == owner && (t.Repository == repository || t.Repository == AllRepositories)
+}
+
+// TeamStore stores the repositories of the teams, as found by all the sources
+type TeamStore interface {
+	// List returns the mappings of all the sources
+	List(ctx context.Context) ([]TeamRepository, error)
+	// Replace replaces all the mappings of the given source
+	Replace(ctx context.Context, source string, mappings []TeamRepository) error
+}
+
+// TeamPullRequestMetrics are the pull request metrics of a team, as exported in the "team_pull_requests" dataset
+type TeamPullRequestMetrics struct {
+	Team                 string
+	PullRequests         int64
+	Merged               int64
+	ClosedWithoutMerge   int64
+	TimeToFirstReviewP50 *float64
+	TimeToReviewP50      *float64
+	TimeToMergeP50       *float64
+}
+
+// Values returns the values of the "team_pull_requests" dataset columns
+func (m TeamPullRequestMetrics) Values() []any {
+	return []any{
+		m.Team, m.PullRequests, m.Merged, m.ClosedWithoutMerge,
+		floatValue(m.TimeToFirstReviewP50), floatValue(m.TimeToReviewP50), floatValue(m.TimeToMergeP50),
+	}
+}
+
+// ComputeTeamPullRequestMetrics computes the metrics of the pull requests created in the time range, per team.
+// The repositories without team are ignored.
+func ComputeTeamPullRequestMetrics(filter ExportFilter, teams []TeamRepository, pullRequests []PullRequest) []TeamPullRequestMetrics {
+	type counters struct {
+		pullRequests, merged, closedWithoutMerge int64
+		timesToFirstReview, timesToReview        []float64
+		timesToMerge                             []float64
+	}
+
+	teamsOf := repositoryTeams(teams)
+	perTeam := map[string]*counters{}
+	for _, pr := range filterPullRequests(filter, pullRequests) {
+		for _, team := range teamsOf(pr.Owner, pr.Repository) {
+			c := perTeam[team]
+			if c == nil {
+				c = &counters{}
+				perTeam[team] = c
+			}
+			c.pullRequests++
+			if pr.MergedTime != nil {
+				c.merged++
+			}
+			if pr.ClosedWithoutMerge() {
+				c.closedWithoutMerge++
+			}
+			if pr.TimeToFirstReview > 0 {
+				c.timesToFirstReview = append(c.timesToFirstReview, pr.TimeToFirstReview.Seconds())
+			}
+			if pr.TimeToReview > 0 {
+				c.timesToReview = append(c.timesToReview, pr.TimeToReview.Seconds())
+			}
+			if pr.TimeToMerge > 0 {
+				c.timesToMerge = append(c.timesToMerge, pr.TimeToMerge.Seconds())
+			}
+		}
+	}
+
+	var metrics []TeamPullRequestMetrics
+	for team, c := range perTeam {
+		metrics = append(metrics, TeamPullRequestMetrics{
+			Team:                 team,
+			PullRequests:         c.pullRequests,
+			Merged:               c.merged,
+			ClosedWithoutMerge:   c.closedWithoutMerge,
+			TimeToFirstReviewP50: median(c.timesToFirstReview),
+			TimeToReviewP50:      median(c.timesToReview),
+			TimeToMergeP50:       median(c.timesToMerge),
+		})
+	}
+	sort.Slice(metrics, func(i, j int) bool {
+		return metrics[i].Team < metrics[j].Team
+	})
+	return metrics
+}
+
+// repositoryTeams returns a function giving the teams of a repository, without duplicates
+func repositoryTeams(mappings []TeamRepository) func(owner, repository string) []string {
+	cache := map[string][]string{}
+	return func(owner, repository string) []string {
+		key := owner + "/" + repository
+		if teams, ok := cache[key]; ok {
+			return teams
+		}
+		teams := strset.New()
+		for _, m := range mappings {
+			if m.Matches(owner, repository) {
+				teams.Add(m.Team)
+			}
+		}
+		cache[key] = teams.List()
+		return cache[key]
+	}
+}
+
+func median(values []float64) *float64 {
+	if len(values) == 0 {
+		return nil
+	}
+	p50 := Percentile(values, 0.5)
+	return &p50
+}